@@ -10,7 +10,7 @@ require (
 	github.com/rs/zerolog v1.30.0
 	github.com/sirupsen/logrus v1.9.3
 	go.uber.org/multierr v1.11.0
-	go.uber.org/zap v1.23.0
+	go.uber.org/zap v1.27.0
 	gopkg.in/inconshreveable/log15.v2 v2.16.0
 )
 
@@ -115,6 +115,24 @@ func BenchmarkDisabledWithoutFields(b *testing.B) {
 			}
 		})
 	})
+	b.Run("slog/ladslog", func(b *testing.B) {
+		logger := newDisabledLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(getMessage(0))
+			}
+		})
+	})
+	b.Run("slog/ladslog.LogAttrs", func(b *testing.B) {
+		logger := newDisabledLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.LogAttrs(context.Background(), slog.LevelInfo, getMessage(0))
+			}
+		})
+	})
 }
 
 func BenchmarkDisabledAccumulatedContext(b *testing.B) {
@@ -202,6 +220,24 @@ func BenchmarkDisabledAccumulatedContext(b *testing.B) {
 			}
 		})
 	})
+	b.Run("slog/ladslog", func(b *testing.B) {
+		logger := newDisabledLadSlog(fakeSlogFields()...)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(getMessage(0))
+			}
+		})
+	})
+	b.Run("slog/ladslog.LogAttrs", func(b *testing.B) {
+		logger := newDisabledLadSlog(fakeSlogFields()...)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.LogAttrs(context.Background(), slog.LevelInfo, getMessage(0))
+			}
+		})
+	})
 }
 
 func BenchmarkDisabledAddingFields(b *testing.B) {
@@ -280,6 +316,24 @@ func BenchmarkDisabledAddingFields(b *testing.B) {
 			}
 		})
 	})
+	b.Run("slog/ladslog", func(b *testing.B) {
+		logger := newDisabledLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(getMessage(0), fakeSlogArgs()...)
+			}
+		})
+	})
+	b.Run("slog/ladslog.LogAttrs", func(b *testing.B) {
+		logger := newDisabledLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.LogAttrs(context.Background(), slog.LevelInfo, getMessage(0), fakeSlogFields()...)
+			}
+		})
+	})
 }
 
 func BenchmarkWithoutFields(b *testing.B) {
@@ -438,6 +492,24 @@ func BenchmarkWithoutFields(b *testing.B) {
 			}
 		})
 	})
+	b.Run("slog/ladslog", func(b *testing.B) {
+		logger := newLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(getMessage(0))
+			}
+		})
+	})
+	b.Run("slog/ladslog.LogAttrs", func(b *testing.B) {
+		logger := newLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.LogAttrs(context.Background(), slog.LevelInfo, getMessage(0))
+			}
+		})
+	})
 }
 
 func BenchmarkAccumulatedContext(b *testing.B) {
@@ -578,6 +650,24 @@ func BenchmarkAccumulatedContext(b *testing.B) {
 			}
 		})
 	})
+	b.Run("slog/ladslog", func(b *testing.B) {
+		logger := newLadSlog(fakeSlogFields()...)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(getMessage(0))
+			}
+		})
+	})
+	b.Run("slog/ladslog.LogAttrs", func(b *testing.B) {
+		logger := newLadSlog(fakeSlogFields()...)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.LogAttrs(context.Background(), slog.LevelInfo, getMessage(0))
+			}
+		})
+	})
 }
 
 func BenchmarkAddingFields(b *testing.B) {
@@ -700,4 +790,22 @@ func BenchmarkAddingFields(b *testing.B) {
 			}
 		})
 	})
+	b.Run("slog/ladslog", func(b *testing.B) {
+		logger := newLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(getMessage(0), fakeSlogArgs()...)
+			}
+		})
+	})
+	b.Run("slog/ladslog.LogAttrs", func(b *testing.B) {
+		logger := newLadSlog()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.LogAttrs(context.Background(), slog.LevelInfo, getMessage(0), fakeSlogFields()...)
+			}
+		})
+	})
 }
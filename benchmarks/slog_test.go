@@ -23,6 +23,9 @@ package benchmarks
 import (
 	"io"
 	"log/slog"
+
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladslog"
 )
 
 func newSlog(fields ...slog.Attr) *slog.Logger {
@@ -33,6 +36,16 @@ func newDisabledSlog(fields ...slog.Attr) *slog.Logger {
 	return slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}).WithAttrs(fields))
 }
 
+// newLadSlog builds a slog.Logger that writes through ladslog to the same
+// Core as the Zap benchmarks.
+func newLadSlog(fields ...slog.Attr) *slog.Logger {
+	return slog.New(ladslog.NewHandler(newZapLogger(lad.DebugLevel).Core()).WithAttrs(fields))
+}
+
+func newDisabledLadSlog(fields ...slog.Attr) *slog.Logger {
+	return slog.New(ladslog.NewHandler(newZapLogger(lad.ErrorLevel).Core()).WithAttrs(fields))
+}
+
 func fakeSlogFields() []slog.Attr {
 	return []slog.Attr{
 		slog.Int("int", _tenInts[0]),
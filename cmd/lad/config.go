@@ -0,0 +1,104 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tnngo/lad/internal/logparse"
+	"github.com/tnngo/lad/ladcore"
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfig reads the encoder configuration of the logs from a YAML or
+// JSON file holding either a lad.Config or just its encoderConfig. The
+// configuration's keys and console separator tell the parser where to look,
+// and its timeEncoder how to parse times; if it has none, tf is kept.
+func loadConfig(name string, tf logparse.TimeFormat) (ladcore.EncoderConfig, logparse.TimeFormat, error) {
+	var cfg ladcore.EncoderConfig
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return cfg, tf, err
+	}
+
+	var doc struct {
+		EncoderConfig yaml.Node `yaml:"encoderConfig"`
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return cfg, tf, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if err := root.Decode(&doc); err != nil {
+		return cfg, tf, fmt.Errorf("parse %s: %w", name, err)
+	}
+	node := &root
+	if doc.EncoderConfig.Kind != 0 {
+		node = &doc.EncoderConfig
+	}
+
+	var times struct {
+		TimeEncoder yaml.Node `yaml:"timeEncoder"`
+	}
+	if err := node.Decode(&cfg); err != nil {
+		return cfg, tf, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if err := node.Decode(&times); err != nil {
+		return cfg, tf, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if times.TimeEncoder.Kind != 0 {
+		if tf, err = timeFormatOf(&times.TimeEncoder); err != nil {
+			return cfg, tf, fmt.Errorf("parse %s: %w", name, err)
+		}
+	}
+	return cfg, tf, nil
+}
+
+// timeFormatOf returns the format written by the TimeEncoder configured
+// with node, mirroring TimeEncoder.UnmarshalYAML.
+func timeFormatOf(node *yaml.Node) (logparse.TimeFormat, error) {
+	var o struct {
+		Layout string `yaml:"layout"`
+	}
+	if node.Kind == yaml.MappingNode {
+		if err := node.Decode(&o); err != nil {
+			return "", err
+		}
+		return logparse.TimeFormat(o.Layout), nil
+	}
+
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return "", err
+	}
+	switch s {
+	case "rfc3339nano", "RFC3339Nano":
+		return "rfc3339nano", nil
+	case "rfc3339", "RFC3339":
+		return "rfc3339", nil
+	case "iso8601", "ISO8601":
+		return "iso8601", nil
+	case "millis", "nanos":
+		return logparse.TimeFormat(s), nil
+	default:
+		return "epoch", nil
+	}
+}
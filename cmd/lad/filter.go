@@ -0,0 +1,229 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tnngo/lad/internal/logparse"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// filter selects the records to print.
+type filter struct {
+	level      *ladcore.Level
+	loggers    []string
+	since      time.Time
+	until      time.Time
+	message    string
+	predicates []predicate
+}
+
+func (f *filter) match(rec logparse.Record) bool {
+	if f.level != nil && rec.Level < *f.level {
+		return false
+	}
+	if len(f.loggers) > 0 && !matchLogger(f.loggers, rec.LoggerName) {
+		return false
+	}
+	if !f.since.IsZero() && rec.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !rec.Time.Before(f.until) {
+		return false
+	}
+	if f.message != "" && !strings.Contains(rec.Message, f.message) {
+		return false
+	}
+	for _, p := range f.predicates {
+		if !p.match(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchLogger reports whether name is one of the loggers, or a logger named
+// after one of them.
+func matchLogger(loggers []string, name string) bool {
+	for _, l := range loggers {
+		if name == l || strings.HasPrefix(name, l+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTime parses a time given on the command line: a duration before
+// now, such as "15m", an RFC 3339 time, or a local time in the default
+// layout, with or without the time of day.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{string(logparse.DefaultTimeFormat), "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected a duration, an RFC 3339 time or %q", s, logparse.DefaultTimeFormat)
+}
+
+// _operators lists the predicate operators, longest first.
+var _operators = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// predicate compares a field with a value. The field's key may be a dotted
+// path into nested objects.
+type predicate struct {
+	key   string
+	op    string // empty if the field only has to exist
+	value string
+}
+
+// parsePredicate parses a predicate such as "status>=500", "user.name=ada",
+// "path~/api" or just "error".
+func parsePredicate(s string) (predicate, error) {
+	i := strings.IndexAny(s, "!=<>~")
+	if i < 0 {
+		return predicate{key: s}, nil
+	}
+	if i == 0 {
+		return predicate{}, errors.New("missing field name")
+	}
+	for _, op := range _operators {
+		if strings.HasPrefix(s[i:], op) {
+			return predicate{key: s[:i], op: op, value: s[i+len(op):]}, nil
+		}
+	}
+	return predicate{}, fmt.Errorf("invalid operator in %q", s)
+}
+
+func (p predicate) String() string {
+	return p.key + p.op + p.value
+}
+
+// match reports whether the record satisfies the predicate. Fields that are
+// missing only match "!=". Values are compared as numbers if both are
+// numbers, and as text otherwise.
+func (p predicate) match(rec logparse.Record) bool {
+	v, ok := lookup(rec.Fields, p.key)
+	if !ok {
+		return p.op == "!="
+	}
+	text := logparse.Text(v)
+	switch p.op {
+	case "":
+		return true
+	case "=":
+		return text == p.value
+	case "!=":
+		return text != p.value
+	case "~":
+		return strings.Contains(text, p.value)
+	}
+
+	var cmp int
+	x, errX := strconv.ParseFloat(text, 64)
+	y, errY := strconv.ParseFloat(p.value, 64)
+	switch {
+	case errX == nil && errY == nil && x < y:
+		cmp = -1
+	case errX == nil && errY == nil && x > y:
+		cmp = 1
+	case errX == nil && errY == nil:
+		cmp = 0
+	default:
+		cmp = strings.Compare(text, p.value)
+	}
+	switch p.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+// lookup finds the value of a field by key, or by a dotted path into nested
+// objects.
+func lookup(fields []ladcore.Field, key string) (interface{}, bool) {
+	for _, f := range fields {
+		if f.Key == key {
+			return logparse.Value(f), true
+		}
+	}
+	for i := len(key) - 1; i > 0; i-- {
+		if key[i] != '.' {
+			continue
+		}
+		for _, f := range fields {
+			if f.Key != key[:i] {
+				continue
+			}
+			if obj, ok := logparse.Value(f).(logparse.Object); ok {
+				if v, ok := lookup(obj, key[i+1:]); ok {
+					return v, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// predicates is a flag.Value collecting -where flags.
+type predicates []predicate
+
+func (ps *predicates) String() string {
+	parts := make([]string, len(*ps))
+	for i, p := range *ps {
+		parts[i] = p.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (ps *predicates) Set(s string) error {
+	p, err := parsePredicate(s)
+	if err != nil {
+		return err
+	}
+	*ps = append(*ps, p)
+	return nil
+}
+
+// loggers is a flag.Value collecting -logger flags.
+type loggers []string
+
+func (ls *loggers) String() string { return strings.Join(*ls, ", ") }
+
+func (ls *loggers) Set(s string) error {
+	*ls = append(*ls, s)
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad/internal/logparse"
+	"github.com/tnngo/lad/ladcore"
+)
+
+func TestParsePredicate(t *testing.T) {
+	tests := []struct {
+		give    string
+		want    predicate
+		wantErr string
+	}{
+		{give: "error", want: predicate{key: "error"}},
+		{give: "status>=500", want: predicate{key: "status", op: ">=", value: "500"}},
+		{give: "user.name=ada", want: predicate{key: "user.name", op: "=", value: "ada"}},
+		{give: "path~/api", want: predicate{key: "path", op: "~", value: "/api"}},
+		{give: "k!=", want: predicate{key: "k", op: "!=", value: ""}},
+		{give: "a=b=c", want: predicate{key: "a", op: "=", value: "b=c"}},
+		{give: "=x", wantErr: "missing field name"},
+		{give: "a!b", wantErr: `invalid operator in "a!b"`},
+	}
+	for _, tt := range tests {
+		got, err := parsePredicate(tt.give)
+		if tt.wantErr != "" {
+			assert.EqualError(t, err, tt.wantErr, "Unexpected error parsing %q.", tt.give)
+			continue
+		}
+		require.NoError(t, err, "Unexpected error parsing %q.", tt.give)
+		assert.Equal(t, tt.want, got, "Unexpected predicate for %q.", tt.give)
+	}
+}
+
+func TestPredicateMatch(t *testing.T) {
+	rec, err := logparse.NewParser(ladcore.EncoderConfig{MessageKey: "msg"}, "epoch").ParseJSON([]byte(
+		`{"msg":"m","status":503,"path":"/api/users","user":{"name":"ada","roles":["admin"]},"req.id":"r1","ok":false}`))
+	require.NoError(t, err, "Unexpected error parsing record.")
+
+	tests := []struct {
+		predicate string
+		want      bool
+	}{
+		{"status", true},
+		{"missing", false},
+		{"status=503", true},
+		{"status>=500", true},
+		{"status>600", false},
+		{"status<1000", true},
+		{"path~/api", true},
+		{"path=/api", false},
+		{"path>/a", true},
+		{"user.name=ada", true},
+		{"user.roles=[\"admin\"]", true},
+		{"user.age", false},
+		{"req.id=r1", true},
+		{"ok=false", true},
+		{"missing!=x", true},
+		{"status!=503", false},
+		{"msg", false},
+	}
+	for _, tt := range tests {
+		p, err := parsePredicate(tt.predicate)
+		require.NoError(t, err, "Unexpected error parsing %q.", tt.predicate)
+		assert.Equal(t, tt.want, p.match(rec), "Unexpected result for %q.", tt.predicate)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		give string
+		want time.Time
+	}{
+		{"90m", now.Add(-90 * time.Minute)},
+		{"2024-05-01T10:00:00Z", time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-05-01 10:00:00.250", time.Date(2024, time.May, 1, 10, 0, 0, 250000000, time.Local)},
+		{"2024-05-01 10:00", time.Date(2024, time.May, 1, 10, 0, 0, 0, time.Local)},
+		{"2024-05-01", time.Date(2024, time.May, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.give, now)
+		require.NoError(t, err, "Unexpected error parsing %q.", tt.give)
+		assert.True(t, tt.want.Equal(got), "%q: expected %v, got %v.", tt.give, tt.want, got)
+	}
+
+	_, err := parseTime("yesterday", now)
+	assert.Error(t, err, "Expected an error for an unsupported time.")
+}
+
+func TestFilterMatch(t *testing.T) {
+	warn := ladcore.WarnLevel
+	ts := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	rec := logparse.Record{Entry: ladcore.Entry{
+		Time:       ts,
+		Level:      ladcore.ErrorLevel,
+		LoggerName: "http.server",
+		Message:    "request failed",
+	}}
+
+	tests := []struct {
+		desc   string
+		filter filter
+		want   bool
+	}{
+		{"empty", filter{}, true},
+		{"level", filter{level: &warn}, true},
+		{"logger", filter{loggers: []string{"db", "http"}}, true},
+		{"logger prefix", filter{loggers: []string{"http.serv"}}, false},
+		{"since", filter{since: ts}, true},
+		{"until", filter{until: ts}, false},
+		{"message", filter{message: "failed"}, true},
+		{"message mismatch", filter{message: "succeeded"}, false},
+		{"predicate", filter{predicates: []predicate{{key: "status"}}}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.filter.match(rec), "%s: unexpected result.", tt.desc)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// errIdle is returned by a follower that has caught up with its file.
+var errIdle = errors.New("no new data")
+
+// follower reads a file as it grows, like tail -F. When it reaches the end
+// of the file, it checks whether the file has been rotated, in which case it
+// continues with the new file of the same name, or truncated, in which case
+// it starts over. Otherwise it returns errIdle, and reading can be retried
+// later.
+type follower struct {
+	name   string
+	f      *os.File
+	offset int64
+}
+
+func newFollower(name string) (*follower, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &follower{name: name, f: f}, nil
+}
+
+func (fl *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := fl.f.Read(p)
+		fl.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		if !fl.reopen() {
+			return 0, errIdle
+		}
+	}
+}
+
+// reopen switches to the file now at fl.name if the one being read was
+// rotated, or rewinds it if it was truncated, reporting whether it did
+// either. A missing file is assumed to be in the middle of being rotated.
+func (fl *follower) reopen() bool {
+	current, err := fl.f.Stat()
+	if err != nil {
+		return false
+	}
+	latest, err := os.Stat(fl.name)
+	if err != nil {
+		return false
+	}
+	if !os.SameFile(current, latest) {
+		f, err := os.Open(fl.name)
+		if err != nil {
+			return false
+		}
+		fl.f.Close()
+		fl.f, fl.offset = f, 0
+		return true
+	}
+	if current.Size() < fl.offset {
+		if _, err := fl.f.Seek(0, io.SeekStart); err != nil {
+			return false
+		}
+		fl.offset = 0
+		return true
+	}
+	return false
+}
+
+func (fl *follower) Close() error {
+	return fl.f.Close()
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command lad filters and tails logs written by lad's JSON and console
+// encoders, and prints the matching entries with the pretty encoder:
+//
+//	lad -level warn -logger http -where 'status>=500' -since 1h app.json
+//	lad -f -where 'user.name=ada' /var/log/app.log
+//
+// Files are read in order, or standard input if none are given. Each line
+// is parsed as JSON if it starts with "{", and as console output otherwise;
+// lines of console output that don't start an entry are taken to be the
+// stack trace of the entry before them. With -f, lad keeps reading files as
+// they grow, picking up the new file when one is rotated, until interrupted.
+//
+// Entries are expected to use the keys of lad.NewProductionEncoderConfig,
+// unless -config names a YAML or JSON file holding the lad.Config (or just
+// the encoderConfig) the logs were written with.
+//
+// Field predicates given with -where compare a field, or a nested field
+// given by a dotted path, with a value:
+//
+//	key         the field is present
+//	key=value   the field's text is value (objects and arrays are JSON)
+//	key!=value  the field is missing or its text isn't value
+//	key~text    the field's text contains text
+//	key>value   also >=, < and <=; numbers are compared as numbers
+//
+// All filters must match for an entry to be printed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/internal/logparse"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// _pollInterval is how long to wait for followed files to grow.
+var _pollInterval = 250 * time.Millisecond
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "lad:", err)
+		os.Exit(1)
+	}
+}
+
+// query holds the state shared by the inputs of a run.
+type query struct {
+	parser *logparse.Parser
+	format logparse.Format
+	filter filter
+	core   ladcore.Core
+	stderr io.Writer
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("lad", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var (
+		q          = query{stderr: ladcore.Lock(ladcore.AddSync(stderr))}
+		where      predicates
+		names      loggers
+		level      string
+		since      string
+		until      string
+		configFile = flags.String("config", "", "YAML or JSON file with the lad.Config or EncoderConfig of the logs")
+		separator  = flags.String("separator", "", "console field separator (default tab)")
+		timeFormat = flags.String("time-format", string(logparse.DefaultTimeFormat),
+			"time layout of console logs, or one of epoch, millis, nanos, iso8601, rfc3339 and rfc3339nano")
+		output = flags.String("o", "pretty", "output format: pretty, console or json")
+		follow = flags.Bool("f", false, "follow files as they grow, including across rotation")
+	)
+	flags.Var(&q.format, "format", "input format: auto, console or json")
+	flags.StringVar(&level, "level", "", "minimum level to print")
+	flags.Var(&names, "logger", "print entries of this logger and the loggers named after it; repeatable")
+	flags.StringVar(&since, "since", "", "print entries at or after this time, or this long ago, such as 15m")
+	flags.StringVar(&until, "until", "", "print entries before this time, or this long ago")
+	flags.StringVar(&q.filter.message, "msg", "", "print entries whose message contains this text")
+	flags.Var(&where, "where", "print entries whose fields match this predicate; repeatable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := lad.NewProductionEncoderConfig()
+	tf := logparse.TimeFormat(*timeFormat)
+	if *configFile != "" {
+		var err error
+		if cfg, tf, err = loadConfig(*configFile, tf); err != nil {
+			return err
+		}
+		flags.Visit(func(f *flag.Flag) {
+			if f.Name == "time-format" {
+				tf = logparse.TimeFormat(*timeFormat)
+			}
+		})
+	}
+	if *separator != "" {
+		cfg.ConsoleSeparator = *separator
+	}
+	q.parser = logparse.NewParser(cfg, tf)
+
+	now := time.Now()
+	if level != "" {
+		var l ladcore.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return err
+		}
+		q.filter.level = &l
+	}
+	for _, t := range []struct {
+		text string
+		dst  *time.Time
+	}{{since, &q.filter.since}, {until, &q.filter.until}} {
+		if t.text == "" {
+			continue
+		}
+		var err error
+		if *t.dst, err = parseTime(t.text, now); err != nil {
+			return err
+		}
+	}
+	q.filter.loggers = names
+	q.filter.predicates = where
+
+	enc, err := newEncoder(*output, q.parser)
+	if err != nil {
+		return err
+	}
+	q.core = ladcore.NewCore(enc, ladcore.Lock(ladcore.AddSync(stdout)), ladcore.DebugLevel)
+
+	if flags.NArg() == 0 {
+		return q.read(ctx, "<stdin>", stdin)
+	}
+	if !*follow {
+		for _, name := range flags.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			err = q.read(ctx, name, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var followers []*follower
+	defer func() {
+		for _, fl := range followers {
+			fl.Close()
+		}
+	}()
+	for _, name := range flags.Args() {
+		fl, err := newFollower(name)
+		if err != nil {
+			return err
+		}
+		followers = append(followers, fl)
+	}
+
+	// Following only ends when interrupted, so stop cleanly then, printing
+	// any records still held back. Otherwise interrupts keep their default
+	// behavior of exiting right away.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i, fl := range followers {
+		wg.Add(1)
+		go func(name string, fl *follower) {
+			defer wg.Done()
+			if err := q.read(ctx, name, fl); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(flags.Arg(i), fl)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// newEncoder creates the encoder for the output format.
+func newEncoder(output string, parser *logparse.Parser) (ladcore.Encoder, error) {
+	cfg := parser.EncoderConfig()
+	if output == "json" {
+		return ladcore.NewJSONEncoder(cfg), nil
+	}
+
+	cfg.EncodeLevel = ladcore.CapitalLevelEncoder
+	cfg.EncodeTime = logparse.DefaultTimeFormat.Encoder()
+	switch output {
+	case "pretty":
+		cfg.ConsoleSeparator = ""
+		return ladcore.NewPrettyEncoder(cfg), nil
+	case "console":
+		cfg.ColorMode = ladcore.AutoColorMode
+		return ladcore.NewConsoleEncoder(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", output)
+	}
+}
+
+// read prints the matching records read from r until its end, or, for
+// followed files, until ctx is done. Lines that can't be parsed are reported
+// and skipped.
+func (q *query) read(ctx context.Context, name string, r io.Reader) error {
+	records := q.parser.NewReader(r, q.format)
+	for {
+		rec, err := records.Next()
+		var lineErr *logparse.LineError
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, errIdle):
+			select {
+			case <-ctx.Done():
+				return q.flush(records)
+			case <-time.After(_pollInterval):
+			}
+			continue
+		case errors.As(err, &lineErr):
+			fmt.Fprintf(q.stderr, "lad: %s:%d: %v\n", name, lineErr.Line, lineErr.Err)
+			continue
+		case err != nil:
+			if ferr := q.flush(records); ferr != nil {
+				return ferr
+			}
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+
+		if err := q.write(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// flush prints the record records is holding back, if it matches.
+func (q *query) flush(records *logparse.Reader) error {
+	if rec, ok := records.Flush(); ok {
+		return q.write(rec)
+	}
+	return nil
+}
+
+// write prints rec if it matches the filter.
+func (q *query) write(rec logparse.Record) error {
+	if !q.filter.match(rec) {
+		return nil
+	}
+	return q.core.Write(rec.Entry, rec.Fields)
+}
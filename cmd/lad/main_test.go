@@ -0,0 +1,228 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const _jsonLog = `{"level":"info","ts":1714557600.25,"logger":"http","msg":"served","status":200,"path":"/users"}
+{"level":"error","ts":1714557660.5,"logger":"http.server","caller":"srv/handler.go:42","msg":"failed","status":503,"path":"/api"}
+{"level":"warn","ts":1714557720,"logger":"db","msg":"slow query","took":2.5}
+`
+
+func runQuery(t *testing.T, input string, args ...string) (stdout, stderr string) {
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("COLUMNS", "0")
+	var out, errOut bytes.Buffer
+	require.NoError(t, run(context.Background(), args, strings.NewReader(input), &out, &errOut),
+		"Unexpected error running lad: %s", errOut.String())
+	return out.String(), errOut.String()
+}
+
+func TestQueryJSON(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+		want []string // messages
+	}{
+		{"everything", nil, []string{"served", "failed", "slow query"}},
+		{"level", []string{"-level", "warn"}, []string{"failed", "slow query"}},
+		{"logger", []string{"-logger", "http"}, []string{"served", "failed"}},
+		{"message", []string{"-msg", "query"}, []string{"slow query"}},
+		{"predicates", []string{"-where", "status>=500", "-where", "path~api"}, []string{"failed"}},
+		{"time", []string{"-since", "2024-05-01T10:01:00Z", "-until", "2024-05-01T10:02:00Z"}, []string{"failed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out, _ := runQuery(t, _jsonLog, tt.args...)
+			var msgs []string
+			for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+				for _, msg := range []string{"served", "failed", "slow query"} {
+					if strings.Contains(line, "  "+msg) {
+						msgs = append(msgs, msg)
+					}
+				}
+			}
+			assert.Equal(t, tt.want, msgs, "Unexpected entries in output:\n%s", out)
+		})
+	}
+}
+
+func TestQueryPrettyOutput(t *testing.T) {
+	out, _ := runQuery(t, _jsonLog, "-level", "error", "-time-format", "epoch")
+	ts := time.Unix(1714557660, 500000000).Format("2006-01-02 15:04:05.000")
+	assert.Equal(t,
+		ts+"  ERROR   http.server   srv/handler.go:42  failed  status=503 path=/api\n",
+		out, "Unexpected pretty output.")
+}
+
+func TestQueryConsoleWithConfig(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`
+level: info
+encoding: console
+encoderConfig:
+  messageKey: M
+  levelKey: L
+  timeKey: T
+  stacktraceKey: S
+  consoleSeparator: " | "
+  timeEncoder:
+    layout: "15:04:05"
+`), 0o644), "Failed to write config.")
+
+	input := "garbage\n" +
+		"10:00:00 | INFO | started\n" +
+		"10:00:01 | ERROR | boom | {\"code\": 7}\n" +
+		"main.run\n" +
+		"\t/src/main.go:12\n"
+	out, stderr := runQuery(t, input, "-config", config, "-o", "json", "-level", "error")
+	assert.Equal(t,
+		`{"L":"error","T":"10:00:01","M":"boom","code":7,"S":"main.run\n\t/src/main.go:12"}`+"\n",
+		out, "Unexpected output.")
+	assert.Equal(t, "lad: <stdin>:1: not a log entry: parsing time \"garbage\" as \"15:04:05\": cannot parse \"garbage\" as \"15\"\n",
+		stderr, "Expected the unparseable line to be reported.")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFollow(t *testing.T) {
+	defer func(d time.Duration) { _pollInterval = d }(_pollInterval)
+	_pollInterval = time.Millisecond
+	t.Setenv("NO_COLOR", "1")
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(name, []byte(`{"level":"info","msg":"one"}`+"\n"), 0o644),
+		"Failed to write log file.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out, errOut syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{"-f", "-o", "json", "-where", "n", name}, nil, &out, &errOut)
+	}()
+
+	appendLine := func(line string) {
+		f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+		require.NoError(t, err, "Failed to open log file.")
+		_, err = f.WriteString(line + "\n")
+		require.NoError(t, err, "Failed to write to log file.")
+		require.NoError(t, f.Close(), "Failed to close log file.")
+	}
+	waitFor := func(want string) {
+		assert.Eventually(t, func() bool { return strings.Contains(out.String(), want) },
+			5*time.Second, time.Millisecond, "Expected %q in output:\n%s", want, out.String())
+	}
+
+	appendLine(`{"level":"info","msg":"two","n":2}`)
+	waitFor(`"msg":"two"`)
+
+	require.NoError(t, os.Rename(name, name+".1"), "Failed to rotate log file.")
+	appendLine(`{"level":"info","msg":"three","n":3}`)
+	waitFor(`"msg":"three"`)
+
+	require.NoError(t, os.Truncate(name, 0), "Failed to truncate log file.")
+	appendLine(`{"level":"info","msg":"four","n":4}`)
+	waitFor(`"msg":"four"`)
+
+	cancel()
+	require.NoError(t, <-done, "Unexpected error following file: %s", errOut.String())
+	assert.Equal(t,
+		`{"level":"info","msg":"two","n":2}`+"\n"+
+			`{"level":"info","msg":"three","n":3}`+"\n"+
+			`{"level":"info","msg":"four","n":4}`+"\n",
+		out.String(), "Expected only matching entries, once each.")
+}
+
+func TestFollowConsoleStack(t *testing.T) {
+	defer func(d time.Duration) { _pollInterval = d }(_pollInterval)
+	_pollInterval = time.Millisecond
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`
+encoding: console
+encoderConfig:
+  messageKey: M
+  levelKey: L
+  stacktraceKey: S
+`), 0o644), "Failed to write config.")
+	name := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(name, []byte("ERROR\tboom\n"), 0o644), "Failed to write log file.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out, errOut syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{"-f", "-config", config, "-o", "json", name}, nil, &out, &errOut)
+	}()
+	waitFor := func(want string) {
+		assert.Eventually(t, func() bool { return strings.Contains(out.String(), want) },
+			5*time.Second, time.Millisecond, "Expected %q in output:\n%s", want, out.String())
+	}
+
+	// The last entry is printed once following catches up, without waiting
+	// for the next one.
+	waitFor(`"M":"boom"`)
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err, "Failed to open log file.")
+	_, err = f.WriteString("ERROR\tfailed\nmain.run\n\t/src/main.go:12\n")
+	require.NoError(t, err, "Failed to write to log file.")
+	require.NoError(t, f.Close(), "Failed to close log file.")
+	waitFor(`"M":"failed"`)
+
+	cancel()
+	require.NoError(t, <-done, "Unexpected error following file.")
+	assert.Empty(t, errOut.String(), "Expected the stack trace to be parsed.")
+	assert.Equal(t,
+		`{"L":"error","M":"boom"}`+"\n"+
+			`{"L":"error","M":"failed","S":"main.run\n\t/src/main.go:12"}`+"\n",
+		out.String(), "Expected entries as soon as following catches up, with their stack traces.")
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tnngo/lad/internal/logparse"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// logfmtWriter writes records as logfmt key=value pairs. Fields holding
+// objects are flattened into dotted keys, and arrays are written as JSON.
+type logfmtWriter struct {
+	w       io.Writer
+	cfg     ladcore.EncoderConfig
+	time    logparse.TimeFormat
+	capital bool
+
+	buf bytes.Buffer
+}
+
+func (l *logfmtWriter) write(rec logparse.Record) error {
+	l.buf.Reset()
+	if l.cfg.TimeKey != "" && !rec.Time.IsZero() {
+		l.pair(l.cfg.TimeKey, l.time.Format(rec.Time))
+	}
+	if l.cfg.LevelKey != "" {
+		level := rec.Level.String()
+		if l.capital {
+			level = rec.Level.CapitalString()
+		}
+		l.pair(l.cfg.LevelKey, level)
+	}
+	if l.cfg.NameKey != "" && rec.LoggerName != "" {
+		l.pair(l.cfg.NameKey, rec.LoggerName)
+	}
+	if rec.Caller.Defined {
+		if l.cfg.CallerKey != "" {
+			l.pair(l.cfg.CallerKey, rec.Caller.FullPath())
+		}
+		if l.cfg.FunctionKey != "" && rec.Caller.Function != "" {
+			l.pair(l.cfg.FunctionKey, rec.Caller.Function)
+		}
+	}
+	if l.cfg.MessageKey != "" {
+		l.pair(l.cfg.MessageKey, rec.Message)
+	}
+	l.fields("", rec.Fields)
+	if l.cfg.StacktraceKey != "" && rec.Stack != "" {
+		l.pair(l.cfg.StacktraceKey, rec.Stack)
+	}
+	l.buf.WriteByte('\n')
+	_, err := l.w.Write(l.buf.Bytes())
+	return err
+}
+
+func (l *logfmtWriter) fields(prefix string, fields []ladcore.Field) {
+	for _, f := range fields {
+		key := prefix + f.Key
+		switch v := logparse.Value(f).(type) {
+		case logparse.Object:
+			l.fields(key+".", v)
+		default:
+			l.pair(key, logparse.Text(v))
+		}
+	}
+}
+
+func (l *logfmtWriter) pair(key, value string) {
+	if l.buf.Len() > 0 {
+		l.buf.WriteByte(' ')
+	}
+	l.buf.WriteString(key)
+	l.buf.WriteByte('=')
+	if needsQuotes(value) {
+		l.buf.WriteString(strconv.Quote(value))
+	} else {
+		l.buf.WriteString(value)
+	}
+}
+
+// needsQuotes reports whether a logfmt value has to be quoted.
+func needsQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar || !unicode.IsPrint(r)
+	}) >= 0
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command ladconv converts logs written by lad's console and JSON encoders.
+// It reads console output, such as the files written by ladglobal.File, and
+// writes it as JSON or logfmt, or pretty-prints JSON logs in console format:
+//
+//	ladconv -to json app.log > app.json
+//	ladconv -from json -to console app.json
+//
+// Files are read in order, or standard input if none are given. Entries are
+// expected to use the keys of lad.NewProductionEncoderConfig; console output
+// is parsed using the separator and time format given by the flags, and
+// lines that don't start an entry are taken to be the stack trace of the
+// entry before them. Lines that can't be parsed are reported and skipped.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/internal/logparse"
+	"github.com/tnngo/lad/ladcore"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "ladconv:", err)
+		os.Exit(1)
+	}
+}
+
+type options struct {
+	from       logparse.Format
+	to         string
+	separator  string
+	timeFormat string
+	capital    bool
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("ladconv", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	opts := options{from: logparse.ConsoleFormat}
+	flags.Var(&opts.from, "from", "input format: console, json or auto")
+	flags.StringVar(&opts.to, "to", "json", "output format: json, logfmt or console")
+	flags.StringVar(&opts.separator, "separator", "\t", "console field separator")
+	flags.StringVar(&opts.timeFormat, "time-format", string(logparse.DefaultTimeFormat),
+		"time layout, or one of epoch, millis, nanos, iso8601, rfc3339 and rfc3339nano")
+	flags.BoolVar(&opts.capital, "capital", false, "write levels in capitals")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := lad.NewProductionEncoderConfig()
+	cfg.ConsoleSeparator = opts.separator
+	parser := logparse.NewParser(cfg, logparse.TimeFormat(opts.timeFormat))
+	write, err := newWriter(opts, parser, stdout)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	convert := func(name string, r io.Reader) error {
+		records := parser.NewReader(r, opts.from)
+		for {
+			rec, err := records.Next()
+			var lineErr *logparse.LineError
+			switch {
+			case errors.Is(err, io.EOF):
+				return nil
+			case errors.As(err, &lineErr):
+				failed++
+				fmt.Fprintf(stderr, "ladconv: %s:%d: %v\n", name, lineErr.Line, lineErr.Err)
+				continue
+			case err != nil:
+				return fmt.Errorf("read %s: %w", name, err)
+			}
+			if err := write(rec); err != nil {
+				return err
+			}
+		}
+	}
+
+	if flags.NArg() == 0 {
+		if err := convert("<stdin>", stdin); err != nil {
+			return err
+		}
+	}
+	for _, name := range flags.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = convert(name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("skipped %d unparseable lines", failed)
+	}
+	return nil
+}
+
+// newWriter returns a function writing records to w in the output format.
+func newWriter(opts options, parser *logparse.Parser, w io.Writer) (func(logparse.Record) error, error) {
+	cfg := parser.EncoderConfig()
+	if opts.capital {
+		cfg.EncodeLevel = ladcore.CapitalLevelEncoder
+	}
+
+	var enc ladcore.Encoder
+	switch opts.to {
+	case "json":
+		enc = ladcore.NewJSONEncoder(cfg)
+	case "console":
+		cfg.ColorMode = ladcore.AutoColorMode
+		enc = ladcore.NewConsoleEncoder(cfg)
+	case "logfmt":
+		lw := &logfmtWriter{w: w, cfg: cfg, time: parser.TimeFormat(), capital: opts.capital}
+		return lw.write, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", opts.to)
+	}
+
+	core := ladcore.NewCore(enc, ladcore.AddSync(w), ladcore.DebugLevel)
+	return func(rec logparse.Record) error {
+		return core.Write(rec.Entry, rec.Fields)
+	}, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/internal/logparse"
+	"github.com/tnngo/lad/ladcore"
+)
+
+var _ts = time.Date(2024, time.May, 1, 10, 0, 0, 250000000, time.Local)
+
+// testEntries returns entries as ladglobal.File would log them.
+func testEntries() []ladcore.Entry {
+	return []ladcore.Entry{
+		{Time: _ts, Level: ladcore.InfoLevel, Message: "started"},
+		{
+			Time:       _ts,
+			Level:      ladcore.ErrorLevel,
+			LoggerName: "db",
+			Caller:     ladcore.EntryCaller{Defined: true, File: "store/query.go", Line: 17},
+			Message:    "query failed",
+			Stack:      "main.query\n\t/src/store/query.go:17",
+		},
+	}
+}
+
+var _testFields = [][]ladcore.Field{
+	nil,
+	{
+		lad.String("table", "users"),
+		lad.Int("attempt", 2),
+		lad.Namespace("conn"),
+		lad.String("host", "db-1"),
+		lad.Bool("tls", true),
+	},
+}
+
+func encodeAll(t *testing.T, enc ladcore.Encoder) string {
+	var out strings.Builder
+	for i, ent := range testEntries() {
+		buf, err := enc.EncodeEntry(ent, _testFields[i])
+		require.NoError(t, err, "Unexpected error encoding entry.")
+		out.Write(buf.Bytes())
+		buf.Free()
+	}
+	return out.String()
+}
+
+func testEncoderConfig(capital bool) ladcore.EncoderConfig {
+	cfg := lad.NewProductionEncoderConfig()
+	cfg.EncodeTime = ladcore.TimeEncoderOfLayout(string(logparse.DefaultTimeFormat))
+	cfg.EncodeCaller = ladcore.FullCallerEncoder
+	if capital {
+		cfg.EncodeLevel = ladcore.CapitalLevelEncoder
+	}
+	return cfg
+}
+
+func runConv(t *testing.T, input string, args ...string) (stdout, stderr string, err error) {
+	var out, errOut bytes.Buffer
+	err = run(args, strings.NewReader(input), &out, &errOut)
+	return out.String(), errOut.String(), err
+}
+
+func TestConsoleToJSON(t *testing.T) {
+	console := encodeAll(t, ladcore.NewConsoleEncoder(testEncoderConfig(false)))
+	out, stderr, err := runConv(t, console)
+	require.NoError(t, err, "Unexpected error converting: %s", stderr)
+	assert.Equal(t, encodeAll(t, ladcore.NewJSONEncoder(testEncoderConfig(false))), out,
+		"Expected the JSON the entries would have been logged as.")
+}
+
+func TestJSONToConsole(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	json := encodeAll(t, ladcore.NewJSONEncoder(testEncoderConfig(false)))
+	out, stderr, err := runConv(t, json, "-from", "json", "-to", "console", "-capital")
+	require.NoError(t, err, "Unexpected error converting: %s", stderr)
+	assert.Equal(t, encodeAll(t, ladcore.NewConsoleEncoder(testEncoderConfig(true))), out,
+		"Expected the console output the entries would have been logged as.")
+}
+
+func TestConsoleRoundTrip(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	console := encodeAll(t, ladcore.NewConsoleEncoder(testEncoderConfig(false)))
+	json, stderr, err := runConv(t, console, "-to", "json")
+	require.NoError(t, err, "Unexpected error converting to JSON: %s", stderr)
+	back, stderr, err := runConv(t, json, "-from", "json", "-to", "console")
+	require.NoError(t, err, "Unexpected error converting to console: %s", stderr)
+	assert.Equal(t, console, back, "Expected converting to JSON and back not to change the log.")
+}
+
+func TestConsoleToLogfmt(t *testing.T) {
+	console := encodeAll(t, ladcore.NewConsoleEncoder(testEncoderConfig(false)))
+	out, stderr, err := runConv(t, console, "-to", "logfmt")
+	require.NoError(t, err, "Unexpected error converting: %s", stderr)
+	assert.Equal(t,
+		`ts="2024-05-01 10:00:00.250" level=info msg=started`+"\n"+
+			`ts="2024-05-01 10:00:00.250" level=error logger=db caller=store/query.go:17 msg="query failed" `+
+			`table=users attempt=2 conn.host=db-1 conn.tls=true stacktrace="main.query\n\t/src/store/query.go:17"`+"\n",
+		out, "Unexpected logfmt output.")
+}
+
+func TestFilesAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.log")
+	bad := filepath.Join(dir, "bad.log")
+	require.NoError(t, os.WriteFile(good, []byte("2024-05-01 10:00:00.250\tinfo\tone\n"), 0o644),
+		"Failed to write log file.")
+	require.NoError(t, os.WriteFile(bad, []byte("garbage\n2024-05-01 10:00:00.250\twarn\ttwo\n"), 0o644),
+		"Failed to write log file.")
+
+	out, stderr, err := runConv(t, "", "-to", "logfmt", good, bad)
+	assert.EqualError(t, err, "skipped 1 unparseable lines", "Expected an error for the bad line.")
+	assert.Contains(t, stderr, "bad.log:1: not a log entry", "Expected the bad line to be reported.")
+	assert.Equal(t,
+		`ts="2024-05-01 10:00:00.250" level=info msg=one`+"\n"+
+			`ts="2024-05-01 10:00:00.250" level=warn msg=two`+"\n",
+		out, "Expected entries from both files.")
+
+	_, _, err = runConv(t, "", "-to", "yaml")
+	assert.EqualError(t, err, `unknown output format "yaml"`, "Expected an error for an unknown format.")
+}
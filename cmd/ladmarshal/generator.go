@@ -0,0 +1,765 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const _tagName = "lad"
+
+// _basicMethods maps predeclared types to the suffix of the ObjectEncoder
+// and ArrayEncoder methods that encode them.
+var _basicMethods = map[string]string{
+	"bool":       "Bool",
+	"string":     "String",
+	"int":        "Int",
+	"int64":      "Int64",
+	"int32":      "Int32",
+	"int16":      "Int16",
+	"int8":       "Int8",
+	"rune":       "Int32",
+	"uint":       "Uint",
+	"uint64":     "Uint64",
+	"uint32":     "Uint32",
+	"uint16":     "Uint16",
+	"uint8":      "Uint8",
+	"byte":       "Uint8",
+	"uintptr":    "Uintptr",
+	"float64":    "Float64",
+	"float32":    "Float32",
+	"complex128": "Complex128",
+	"complex64":  "Complex64",
+}
+
+type kind int
+
+const (
+	kindReflected kind = iota
+	kindBasic
+	kindTime
+	kindDuration
+	kindError
+	kindObject
+	kindArray
+	kindTagged
+	kindBinary
+	kindSlice
+	kindPointer
+)
+
+// typeInfo describes how a value of some Go type is encoded.
+type typeInfo struct {
+	kind   kind
+	method string    // encoder method suffix for kindBasic
+	conv   string    // conversion to a predeclared type for named basic types
+	expr   string    // Go source for the type
+	elem   *typeInfo // for kindSlice and kindPointer
+	addr   bool      // whether the marshaler needs a pointer receiver
+	// omit is a format string producing the "not empty" condition for
+	// omitempty. It's empty if values of this type are never omitted.
+	omit string
+}
+
+// Generate parses the non-test Go files in dir and returns the gofmt-ed
+// source of a file implementing lad's marshaler interfaces for the named
+// types. The file named skip, typically the previous output, is ignored.
+func Generate(dir string, typeNames []string, skip string) ([]byte, error) {
+	g, err := newGenerator(dir, skip)
+	if err != nil {
+		return nil, err
+	}
+	return g.generate(typeNames)
+}
+
+type generator struct {
+	pkgName string
+	specs   map[string]*ast.TypeSpec
+	files   map[string]*ast.File // file declaring each type
+
+	// Types with a MarshalLogObject or MarshalLogArray method, whether
+	// handwritten or about to be generated.
+	objects map[string]bool
+	arrays  map[string]bool
+	ptrRecv map[string]bool // whether those methods have pointer receivers
+
+	helpers     map[string]*typeInfo
+	prefix      string               // distinguishes helpers between output files
+	needLad     bool                 // whether package lad is used
+	needTime    bool                 // whether a helper type refers to package time
+	timeImports map[*ast.File]string // local name of package time
+	file        *ast.File            // file of the type being generated
+
+	buf bytes.Buffer
+}
+
+func newGenerator(dir, skip string) (*generator, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		specs:       make(map[string]*ast.TypeSpec),
+		files:       make(map[string]*ast.File),
+		objects:     make(map[string]bool),
+		arrays:      make(map[string]bool),
+		ptrRecv:     make(map[string]bool),
+		helpers:     make(map[string]*typeInfo),
+		timeImports: make(map[*ast.File]string),
+	}
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if name == skip || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if g.pkgName == "" {
+			g.pkgName = f.Name.Name
+		} else if g.pkgName != f.Name.Name {
+			return nil, fmt.Errorf("found packages %v and %v in %v", g.pkgName, f.Name.Name, dir)
+		}
+		g.collect(f)
+	}
+	if g.pkgName == "" {
+		return nil, fmt.Errorf("no Go files found in %v", dir)
+	}
+	return g, nil
+}
+
+// collect records the type declarations, marshaler methods and time import
+// of a parsed file.
+func (g *generator) collect(f *ast.File) {
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == "time" {
+			name := "time"
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			g.timeImports[f] = name
+		}
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && ts.TypeParams == nil {
+					g.specs[ts.Name.Name] = ts
+					g.files[ts.Name.Name] = f
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) != 1 {
+				continue
+			}
+			recv := decl.Recv.List[0].Type
+			ptr := false
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv, ptr = star.X, true
+			}
+			ident, ok := recv.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			switch decl.Name.Name {
+			case "MarshalLogObject":
+				g.objects[ident.Name] = true
+				g.ptrRecv[ident.Name] = ptr
+			case "MarshalLogArray":
+				g.arrays[ident.Name] = true
+				g.ptrRecv[ident.Name] = ptr
+			}
+		}
+	}
+}
+
+func (g *generator) generate(typeNames []string) ([]byte, error) {
+	// Register every requested type first so that they can refer to each
+	// other regardless of order.
+	for _, name := range typeNames {
+		spec, ok := g.specs[name]
+		if !ok {
+			return nil, fmt.Errorf("type %v not found in package %v", name, g.pkgName)
+		}
+		switch t := spec.Type.(type) {
+		case *ast.StructType:
+			g.objects[name] = true
+			g.ptrRecv[name] = false
+		case *ast.ArrayType:
+			if t.Len != nil {
+				return nil, fmt.Errorf("type %v: arrays are not supported, use a slice", name)
+			}
+			g.arrays[name] = true
+			g.ptrRecv[name] = false
+		default:
+			return nil, fmt.Errorf("type %v: must be a struct or a slice", name)
+		}
+	}
+
+	// Helper types are unexported but package-scoped, so prefix them with
+	// the first type name, which the output file is named after, to keep
+	// several generated files in one package from declaring the same ones.
+	g.prefix = typeNames[0] + "_"
+
+	var body bytes.Buffer
+	for _, name := range typeNames {
+		g.buf.Reset()
+		spec := g.specs[name]
+		g.file = g.files[name]
+		var err error
+		switch t := spec.Type.(type) {
+		case *ast.StructType:
+			err = g.genObject(name, t)
+		case *ast.ArrayType:
+			err = g.genArray(name, t)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("type %v: %v", name, err)
+		}
+		body.Write(g.buf.Bytes())
+	}
+
+	g.buf.Reset()
+	g.genHelpers()
+	body.Write(g.buf.Bytes())
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by ladmarshal -type=%s; DO NOT EDIT.\n\n", strings.Join(typeNames, ","))
+	fmt.Fprintf(&out, "package %s\n\n", g.pkgName)
+	out.WriteString("import (\n")
+	if g.needTime {
+		if name := g.anyTimeImport(); name != "time" {
+			fmt.Fprintf(&out, "%s ", name)
+		}
+		out.WriteString("\"time\"\n\n")
+	}
+	if g.needLad {
+		out.WriteString("\"github.com/tnngo/lad\"\n")
+	}
+	out.WriteString("\"github.com/tnngo/lad/ladcore\"\n)\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) genObject(name string, st *ast.StructType) error {
+	recv := g.receiverName(name)
+	g.printf("\n// MarshalLogObject implements ladcore.ObjectMarshaler.\n")
+	g.printf("func (%s %s) MarshalLogObject(enc ladcore.ObjectEncoder) error {\n", recv, name)
+	if err := g.genFields(recv, st, map[string]bool{name: true}); err != nil {
+		return err
+	}
+	g.printf("return nil\n}\n")
+	return nil
+}
+
+// genFields emits code adding the fields of the struct val to the current
+// object. Embedded structs are inlined; seen guards against cycles among
+// them.
+func (g *generator) genFields(val string, st *ast.StructType, seen map[string]bool) error {
+	for _, field := range st.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			raw, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(raw).Get(_tagName)
+		}
+		if tag == "-" {
+			continue
+		}
+		key, opts, _ := strings.Cut(tag, ",")
+		omitEmpty, redact := false, false
+		for opts != "" {
+			var opt string
+			opt, opts, _ = strings.Cut(opts, ",")
+			switch opt {
+			case "omitempty":
+				omitEmpty = true
+			case "redact":
+				redact = true
+			}
+		}
+
+		if len(field.Names) == 0 {
+			embedded := embeddedName(field.Type)
+			if key == "" {
+				ok, err := g.genEmbedded(val+"."+embedded, embedded, field.Type, seen)
+				if err != nil {
+					return err
+				}
+				if ok {
+					continue
+				}
+			}
+			if !ast.IsExported(embedded) {
+				continue
+			}
+			if key == "" {
+				key = embedded
+			}
+			g.genField(key, val+"."+embedded, field.Type, omitEmpty, redact)
+			continue
+		}
+
+		for _, n := range field.Names {
+			if !n.IsExported() {
+				continue
+			}
+			k := key
+			if k == "" {
+				k = n.Name
+			}
+			g.genField(k, val+"."+n.Name, field.Type, omitEmpty, redact)
+		}
+	}
+	return nil
+}
+
+// genEmbedded inlines the fields of an embedded struct into the current
+// object, either through its MarshalLogObject method or by expanding its
+// fields. It reports false if the embedded type can't be inlined.
+func (g *generator) genEmbedded(val, name string, t ast.Expr, seen map[string]bool) (bool, error) {
+	spec, ok := g.specs[name]
+	if _, isSel := unstar(t).(*ast.SelectorExpr); isSel || !ok || seen[name] {
+		return false, nil
+	}
+	st, isStruct := spec.Type.(*ast.StructType)
+	if !g.objects[name] && !isStruct {
+		return false, nil
+	}
+
+	if _, ok := t.(*ast.StarExpr); ok {
+		g.printf("if %s != nil {\n", val)
+		defer g.printf("}\n")
+	}
+	if g.objects[name] {
+		g.checkErr("%s.MarshalLogObject(enc)", val)
+		return true, nil
+	}
+	seen[name] = true
+	defer delete(seen, name)
+	return true, g.genFields(val, st, seen)
+}
+
+func (g *generator) genArray(name string, at *ast.ArrayType) error {
+	elem := g.classify(at.Elt)
+	if elem.kind == kindReflected {
+		return fmt.Errorf("unsupported element type %v", exprString(at.Elt))
+	}
+	recv := g.receiverName(name)
+	g.printf("\n// MarshalLogArray implements ladcore.ArrayMarshaler.\n")
+	g.printf("func (%s %s) MarshalLogArray(enc ladcore.ArrayEncoder) error {\n", recv, name)
+	g.printf("for i := range %s {\n", recv)
+	g.genAppend(recv+"[i]", elem)
+	g.printf("}\nreturn nil\n}\n")
+	return nil
+}
+
+func (g *generator) genField(key, val string, t ast.Expr, omitEmpty, redact bool) {
+	info := g.classify(t)
+	omit := omitEmpty && info.omit != ""
+	if omit {
+		g.printf("if "+info.omit+" {\n", val)
+		defer g.printf("}\n")
+	}
+	if redact {
+		g.needLad = true
+		g.printf("enc.AddString(%q, lad.RedactedValue)\n", key)
+		return
+	}
+
+	// The omitempty check already rules out nil values.
+	switch {
+	case omit && info.kind == kindPointer:
+		val, info = deref(val, info.elem)
+	case omit && info.kind == kindError:
+		g.printf("enc.AddString(%q, %s.Error())\n", key, val)
+		return
+	}
+	g.genAdd(strconv.Quote(key), val, info)
+}
+
+// genAdd emits code adding val, of the given type, to an ObjectEncoder.
+func (g *generator) genAdd(key, val string, info *typeInfo) {
+	switch info.kind {
+	case kindBasic:
+		g.printf("enc.Add%s(%s, %s)\n", info.method, key, convert(info.conv, val))
+	case kindTime:
+		g.printf("enc.AddTime(%s, %s)\n", key, val)
+	case kindDuration:
+		g.printf("enc.AddDuration(%s, %s)\n", key, val)
+	case kindBinary:
+		g.printf("enc.AddBinary(%s, %s)\n", key, val)
+	case kindError:
+		g.printf("if %s != nil {\nenc.AddString(%s, %s.Error())\n} else ", val, key, val)
+		g.checkErr("enc.AddReflected(%s, nil)", key)
+	case kindObject:
+		g.checkErr("enc.AddObject(%s, %s)", key, addr(info, val))
+	case kindArray:
+		g.checkErr("enc.AddArray(%s, %s)", key, addr(info, val))
+	case kindTagged:
+		g.needLad = true
+		g.checkErr("enc.AddObject(%s, lad.TaggedObject(%s))", key, val)
+	case kindSlice:
+		g.checkErr("enc.AddArray(%s, %s(%s))", key, g.helper(info), val)
+	case kindPointer:
+		g.printf("if %s == nil {\n", val)
+		g.checkErr("enc.AddReflected(%s, nil)", key)
+		g.printf("} else {\n")
+		v, elem := deref(val, info.elem)
+		g.genAdd(key, v, elem)
+		g.printf("}\n")
+	default:
+		// lad.Tagged still honors the tags of structs whose types can't be
+		// seen from here, and falls back to AddReflected for other values.
+		g.needLad = true
+		g.printf("lad.Tagged(%s, %s).AddTo(enc)\n", key, val)
+	}
+}
+
+// genAppend emits code appending val, of the given type, to an
+// ArrayEncoder.
+func (g *generator) genAppend(val string, info *typeInfo) {
+	switch info.kind {
+	case kindBasic:
+		g.printf("enc.Append%s(%s)\n", info.method, convert(info.conv, val))
+	case kindTime:
+		g.printf("enc.AppendTime(%s)\n", val)
+	case kindDuration:
+		g.printf("enc.AppendDuration(%s)\n", val)
+	case kindBinary:
+		g.printf("enc.AppendByteString(%s)\n", val)
+	case kindError:
+		g.printf("if %s != nil {\nenc.AppendString(%s.Error())\n} else ", val, val)
+		g.checkErr("enc.AppendReflected(nil)")
+	case kindObject:
+		g.checkErr("enc.AppendObject(%s)", addr(info, val))
+	case kindArray:
+		g.checkErr("enc.AppendArray(%s)", addr(info, val))
+	case kindTagged:
+		g.needLad = true
+		g.checkErr("enc.AppendObject(lad.TaggedObject(%s))", val)
+	case kindSlice:
+		g.checkErr("enc.AppendArray(%s(%s))", g.helper(info), val)
+	case kindPointer:
+		g.printf("if %s == nil {\n", val)
+		g.checkErr("enc.AppendReflected(nil)")
+		g.printf("} else {\n")
+		v, elem := deref(val, info.elem)
+		g.genAppend(v, elem)
+		g.printf("}\n")
+	default:
+		g.checkErr("enc.AppendReflected(%s)", val)
+	}
+}
+
+func (g *generator) checkErr(format string, args ...interface{}) {
+	g.printf("if err := "+format+"; err != nil {\nreturn err\n}\n", args...)
+}
+
+// helper returns the name of a generated slice type implementing
+// ArrayMarshaler for a slice field, registering it if needed.
+func (g *generator) helper(info *typeInfo) string {
+	name := "ladmarshal" + g.prefix + exportedName(info.elem.expr) + "Array"
+	g.helpers[name] = info
+	return name
+}
+
+func (g *generator) genHelpers() {
+	// Helpers may register further helpers for nested slices, so keep going
+	// until no new ones appear.
+	done := make(map[string]bool)
+	for {
+		var names []string
+		for name := range g.helpers {
+			if !done[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			done[name] = true
+			info := g.helpers[name]
+			g.needTime = g.needTime || usesTime(info)
+			g.printf("\ntype %s %s\n\n", name, info.expr)
+			g.printf("func (a %s) MarshalLogArray(enc ladcore.ArrayEncoder) error {\n", name)
+			g.printf("for i := range a {\n")
+			g.genAppend("a[i]", info.elem)
+			g.printf("}\nreturn nil\n}\n")
+		}
+	}
+}
+
+// classify determines how values of the type expression t are encoded.
+func (g *generator) classify(t ast.Expr) *typeInfo {
+	info := &typeInfo{expr: exprString(t)}
+	switch t := t.(type) {
+	case *ast.Ident:
+		g.classifyIdent(t.Name, info)
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok || pkg.Name != g.timeImports[g.file] {
+			break
+		}
+		switch t.Sel.Name {
+		case "Time":
+			info.kind, info.omit = kindTime, "!%s.IsZero()"
+		case "Duration":
+			info.kind, info.omit = kindDuration, "%s != 0"
+		}
+	case *ast.StarExpr:
+		elem := g.classify(t.X)
+		if elem.kind != kindReflected {
+			info.kind, info.elem, info.omit = kindPointer, elem, "%s != nil"
+		}
+	case *ast.ArrayType:
+		if t.Len != nil {
+			break
+		}
+		if id, ok := t.Elt.(*ast.Ident); ok && (id.Name == "byte" || id.Name == "uint8") {
+			info.kind, info.omit = kindBinary, "len(%s) != 0"
+			break
+		}
+		elem := g.classify(t.Elt)
+		if elem.kind != kindReflected {
+			info.kind, info.elem, info.omit = kindSlice, elem, "len(%s) != 0"
+		}
+	case *ast.MapType, *ast.InterfaceType:
+		info.omit = "%s != nil"
+	}
+	return info
+}
+
+func (g *generator) classifyIdent(name string, info *typeInfo) {
+	if name == "error" {
+		info.kind, info.omit = kindError, "%s != nil"
+		return
+	}
+	if m, ok := _basicMethods[name]; ok {
+		info.kind, info.method = kindBasic, m
+		info.omit = basicOmit(name)
+		return
+	}
+
+	spec, ok := g.specs[name]
+	switch {
+	case g.objects[name]:
+		info.kind, info.addr = kindObject, g.ptrRecv[name]
+	case g.arrays[name]:
+		info.kind, info.addr = kindArray, g.ptrRecv[name]
+	case ok:
+		// Other structs are encoded through their tags at run time, so
+		// that options such as redact aren't lost. Named types with a
+		// predeclared underlying type are converted back to it.
+		if _, ok := spec.Type.(*ast.StructType); ok {
+			info.kind = kindTagged
+			return
+		}
+		if under, ok := spec.Type.(*ast.Ident); ok {
+			if m, ok := _basicMethods[under.Name]; ok {
+				info.kind, info.method, info.conv = kindBasic, m, under.Name
+				info.omit = basicOmit(under.Name)
+			}
+		}
+		return
+	default:
+		return
+	}
+
+	switch st := spec.Type.(type) {
+	case *ast.ArrayType:
+		if st.Len == nil {
+			info.omit = "len(%s) != 0"
+		}
+	case *ast.MapType:
+		info.omit = "len(%s) != 0"
+	}
+}
+
+// usesTime reports whether the Go source for a type refers to package time.
+func usesTime(info *typeInfo) bool {
+	for ; info != nil; info = info.elem {
+		if info.kind == kindTime || info.kind == kindDuration {
+			return true
+		}
+	}
+	return false
+}
+
+func basicOmit(name string) string {
+	switch name {
+	case "bool":
+		return "%s"
+	case "string":
+		return `%s != ""`
+	default:
+		return "%s != 0"
+	}
+}
+
+// anyTimeImport returns the local name used for package time by the files
+// that refer to it, preferring the first renamed import in sorted order so
+// that the output doesn't depend on map iteration.
+func (g *generator) anyTimeImport() string {
+	var names []string
+	for _, name := range g.timeImports {
+		if name != "time" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "time"
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+func convert(conv, val string) string {
+	if conv == "" {
+		return val
+	}
+	return conv + "(" + val + ")"
+}
+
+// deref returns the expression for the value a pointer points to. Objects
+// and arrays keep the pointer, since its method set includes the value's,
+// as do tagged structs, which lad.TaggedObject dereferences itself.
+func deref(val string, elem *typeInfo) (string, *typeInfo) {
+	switch elem.kind {
+	case kindObject, kindArray, kindTagged:
+		e := *elem
+		e.addr = false
+		return val, &e
+	}
+	return "*" + val, elem
+}
+
+// addr takes the address of val if its marshaler method is declared on the
+// pointer receiver.
+func addr(info *typeInfo, val string) string {
+	if info.addr {
+		return "&" + val
+	}
+	return val
+}
+
+func unstar(t ast.Expr) ast.Expr {
+	if star, ok := t.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return t
+}
+
+// receiverName returns the receiver name for the methods of a type: its
+// lowercased initial, unless that's one of the names the generated code
+// uses inside methods, in which case it's "recv".
+func (g *generator) receiverName(typeName string) string {
+	taken := map[string]bool{
+		"i": true, "enc": true, "err": true, "lad": true, "ladcore": true,
+		g.anyTimeImport(): true,
+	}
+	if name, ok := g.timeImports[g.file]; ok {
+		taken[name] = true
+	}
+	if name := string(unicode.ToLower([]rune(typeName)[0])); !taken[name] {
+		return name
+	}
+	return "recv"
+}
+
+func embeddedName(t ast.Expr) string {
+	switch t := unstar(t).(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+// exportedName turns a type expression into a CamelCase identifier
+// fragment, such as "PtrTimeTime" for "*time.Time".
+func exportedName(expr string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range expr {
+		switch {
+		case r == '*':
+			sb.WriteString("Ptr")
+			upper = true
+		case r == '[' || r == ']':
+			if r == ']' {
+				sb.WriteString("Slice")
+			}
+			upper = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			sb.WriteRune(r)
+		default:
+			upper = true
+		}
+	}
+	return sb.String()
+}
+
+func exprString(t ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), t)
+	return buf.String()
+}
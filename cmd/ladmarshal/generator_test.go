@@ -0,0 +1,176 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_update = flag.Bool("update", false, "update golden files")
+
+	// _importer type-checks imported packages from source. It's shared so
+	// that each package is only checked once.
+	_importFset = token.NewFileSet()
+	_importer   = importer.ForCompiler(_importFset, "source", nil)
+)
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		dir   string
+		types []string
+	}{
+		{dir: "user", types: []string{"User", "Address", "Base", "Users"}},
+		{dir: "alias", types: []string{"Event"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			dir := filepath.Join("testdata", tt.dir)
+			got, err := Generate(dir, tt.types, "")
+			require.NoError(t, err, "Unexpected error generating code.")
+			typeCheck(t, dir, got)
+
+			golden := filepath.Join("testdata", tt.dir+".golden")
+			if *_update {
+				require.NoError(t, os.WriteFile(golden, got, 0o644), "Failed to update golden file.")
+				return
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "Failed to read golden file; run with -update to create it.")
+			assert.Equal(t, string(want), string(got), "Generated code doesn't match %v.", golden)
+		})
+	}
+}
+
+func TestGenerateSeparateFiles(t *testing.T) {
+	dir := filepath.Join("testdata", "split")
+	items, err := Generate(dir, []string{"Item", "Items"}, "")
+	require.NoError(t, err, "Unexpected error generating code for Item.")
+	order, err := Generate(dir, []string{"Order"}, "")
+	require.NoError(t, err, "Unexpected error generating code for Order.")
+
+	assert.Contains(t, string(items), "func (recv Items) MarshalLogArray", "Expected the receiver to avoid the loop variable.")
+	typeCheck(t, dir, items, order)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		dir     string
+		types   []string
+		wantErr string
+	}{
+		{
+			desc:    "unknown type",
+			dir:     "user",
+			types:   []string{"Missing"},
+			wantErr: "type Missing not found",
+		},
+		{
+			desc:    "unsupported type",
+			dir:     "user",
+			types:   []string{"Role"},
+			wantErr: "must be a struct or a slice",
+		},
+		{
+			desc:    "no Go files",
+			dir:     "missing",
+			types:   []string{"User"},
+			wantErr: "no Go files found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Generate(filepath.Join("testdata", tt.dir), tt.types, "")
+			require.Error(t, err, "Expected an error.")
+			assert.Contains(t, err.Error(), tt.wantErr, "Unexpected error message.")
+		})
+	}
+}
+
+func TestAnyTimeImport(t *testing.T) {
+	g := &generator{timeImports: map[*ast.File]string{
+		{}: "time",
+		{}: "stdtime",
+		{}: "gotime",
+	}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "gotime", g.anyTimeImport(), "Expected the first renamed import in sorted order.")
+	}
+	assert.Equal(t, "time", (&generator{}).anyTimeImport(), "Expected the default name without renamed imports.")
+}
+
+func TestRun(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.go")
+	require.NoError(t, run([]string{"-type=Event", "-output=" + out, "testdata/alias"}), "Unexpected error running ladmarshal.")
+
+	got, err := os.ReadFile(out)
+	require.NoError(t, err, "Failed to read output.")
+	assert.True(t, strings.HasPrefix(string(got), "// Code generated by ladmarshal"), "Expected a generated-code header.")
+
+	assert.Error(t, run(nil), "Expected an error without -type.")
+}
+
+// typeCheck type-checks the package in dir together with the generated
+// files.
+func typeCheck(t *testing.T, dir string, generated ...[]byte) {
+	t.Helper()
+
+	abs, err := filepath.Abs(dir)
+	require.NoError(t, err, "Failed to resolve %v.", dir)
+	paths, err := filepath.Glob(filepath.Join(abs, "*.go"))
+	require.NoError(t, err, "Failed to list %v.", dir)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		require.NoError(t, err, "Failed to parse %v.", path)
+		files = append(files, f)
+	}
+	for i, src := range generated {
+		// Name the files as if they were in dir, so that imports resolve
+		// against this module.
+		name := filepath.Join(abs, "generated"+strconv.Itoa(i)+".go")
+		f, err := parser.ParseFile(fset, name, src, 0)
+		require.NoError(t, err, "Failed to parse generated code.")
+		files = append(files, f)
+	}
+
+	conf := types.Config{Importer: _importer}
+	_, err = conf.Check(files[0].Name.Name, fset, files, nil)
+	require.NoError(t, err, "Generated code doesn't type-check.")
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command ladmarshal generates MarshalLogObject and MarshalLogArray methods
+// for Go types, so that they can be logged with lad.Object and lad.Array
+// without reflection.
+//
+// It is meant to be invoked with go:generate:
+//
+//	//go:generate go run github.com/tnngo/lad/cmd/ladmarshal -type=User,Users
+//
+// Struct fields follow the same `lad` tag conventions as lad.Tagged:
+//
+//	type User struct {
+//		Name     string `lad:"name"`
+//		Email    string `lad:"email,omitempty"`
+//		Password string `lad:"password,redact"`
+//		Internal string `lad:"-"`
+//	}
+//
+// Struct types get a MarshalLogObject method, and slice types get a
+// MarshalLogArray method. Fields holding other structs of the package are
+// encoded with lad.TaggedObject, and fields of types that ladmarshal can't
+// resolve statically with lad.Tagged, so that their tags still apply.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "ladmarshal:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("ladmarshal", flag.ContinueOnError)
+	typeNames := flags.String("type", "", "comma-separated list of type names; required")
+	output := flags.String("output", "", "output file name; default <dir>/<type>_ladmarshal.go")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *typeNames == "" {
+		flags.Usage()
+		return fmt.Errorf("-type is required")
+	}
+
+	dir := "."
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		dir = flags.Arg(0)
+	default:
+		return fmt.Errorf("expected at most one directory, got %d", flags.NArg())
+	}
+
+	types := strings.Split(*typeNames, ",")
+	outName := *output
+	if outName == "" {
+		outName = filepath.Join(dir, strings.ToLower(types[0])+"_ladmarshal.go")
+	}
+
+	src, err := Generate(dir, types, filepath.Base(outName))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outName, src, 0o644)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package user
+
+import (
+	"time"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+type Role string
+
+type Base struct {
+	ID      int64     `lad:"id"`
+	Created time.Time `lad:"created,omitempty"`
+}
+
+type Address struct {
+	City string `lad:"city"`
+	Zip  string `lad:"zip,omitempty"`
+}
+
+type User struct {
+	Base
+
+	Name     string            `lad:"name"`
+	Password string            `lad:"password,redact"`
+	Token    string            `lad:"token,omitempty,redact"`
+	Nickname *string           `lad:"nickname,omitempty"`
+	Internal string            `lad:"-"`
+	Age      int               `lad:"age"`
+	Admin    bool              `lad:"admin"`
+	Role     Role              `lad:"role"`
+	TTL      time.Duration     `lad:"ttl"`
+	Tags     []string          `lad:"tags,omitempty"`
+	Avatar   []byte            `lad:"avatar"`
+	Address  Address           `lad:"address"`
+	Previous *Address          `lad:"previous"`
+	History  []Address         `lad:"history"`
+	Session  Session           `lad:"session"`
+	Err      error             `lad:"err,omitempty"`
+	Labels   map[string]string `lad:"labels,omitempty"`
+	Matrix   [][]int           `lad:"matrix"`
+	Card     *Card             `lad:"card,omitempty"`
+	Wallet   []Card            `lad:"wallet"`
+	Caller   ladcore.EntryCaller
+	Untagged float64
+
+	secret string
+}
+
+type Users []*User
+
+// Card isn't generated for, so it's encoded through its tags at run time.
+type Card struct {
+	Holder string `lad:"holder"`
+	Number string `lad:"number,redact"`
+}
+
+// Session has a handwritten marshaler with a pointer receiver.
+type Session struct{ id string }
+
+func (s *Session) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	enc.AddString("id", s.id)
+	return nil
+}
@@ -38,13 +38,16 @@ var (
 		"json": func(encoderConfig ladcore.EncoderConfig) (ladcore.Encoder, error) {
 			return ladcore.NewJSONEncoder(encoderConfig), nil
 		},
+		"pretty": func(encoderConfig ladcore.EncoderConfig) (ladcore.Encoder, error) {
+			return ladcore.NewPrettyEncoder(encoderConfig), nil
+		},
 	}
 	_encoderMutex sync.RWMutex
 )
 
 // RegisterEncoder registers an encoder constructor, which the Config struct
-// can then reference. By default, the "json" and "console" encoders are
-// registered.
+// can then reference. By default, the "json", "console" and "pretty" encoders
+// are registered.
 //
 // Attempting to register an encoder whose name is already taken returns an
 // error.
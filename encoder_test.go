@@ -28,7 +28,7 @@ import (
 )
 
 func TestRegisterDefaultEncoders(t *testing.T) {
-	testEncodersRegistered(t, "console", "json")
+	testEncodersRegistered(t, "console", "json", "pretty")
 }
 
 func TestRegisterEncoder(t *testing.T) {
@@ -21,7 +21,12 @@
 package lad
 
 import (
+	"errors"
+	"fmt"
+	"io"
+
 	"github.com/tnngo/lad/internal/pool"
+	"github.com/tnngo/lad/internal/stacktrace"
 	"github.com/tnngo/lad/ladcore"
 )
 
@@ -37,7 +42,9 @@ func Error(err error) Field {
 // NamedError constructs a field that lazily stores err.Error() under the
 // provided key. Errors which also implement fmt.Formatter (like those produced
 // by github.com/pkg/errors) will also have their verbose representation stored
-// under key+"Verbose". If passed a nil error, the field is a no-op.
+// under key+"Verbose". Fields carried by the error or the errors it wraps
+// (see WrapError) are added next to it. If passed a nil error, the field is a
+// no-op.
 //
 // For the common case in which the key is simply "error", the Error function
 // is shorter and less repetitive.
@@ -48,6 +55,93 @@ func NamedError(key string, err error) Field {
 	return Field{Key: key, Type: ladcore.ErrorType, Interface: err}
 }
 
+// WrapError returns an error that wraps err and carries the provided fields.
+// The returned error's message is err's message, and errors.Is and errors.As
+// see through it to err.
+//
+// When the returned error, or any error wrapping it, is logged with Error or
+// NamedError, the fields are added to the log entry next to the error
+// message. If several wrapped layers carry the same key, the outermost one
+// wins. If passed a nil error, WrapError returns nil.
+//
+//	if err := db.Exec(query); err != nil {
+//		return lad.WrapError(err, lad.String("table", table))
+//	}
+func WrapError(err error, fields ...Field) error {
+	if err == nil {
+		return nil
+	}
+	return &fieldError{err: err, fields: fields}
+}
+
+// NewError returns an error with the given message that carries the provided
+// fields. See WrapError for how the fields are logged.
+func NewError(msg string, fields ...Field) error {
+	return &fieldError{err: errors.New(msg), fields: fields}
+}
+
+type fieldError struct {
+	err    error
+	fields []Field
+}
+
+var _ ladcore.FieldCarrier = (*fieldError)(nil)
+
+func (e *fieldError) Error() string      { return e.err.Error() }
+func (e *fieldError) Unwrap() error      { return e.err }
+func (e *fieldError) LogFields() []Field { return e.fields }
+
+func (e *fieldError) Format(s fmt.State, verb rune) { formatWrapped(s, verb, e.err) }
+
+// WithStack returns an error that wraps err and records the stack of the
+// caller of WithStack. The returned error's message is err's message, and
+// errors.Is and errors.As see through it to err.
+//
+// When the returned error, or any error wrapping it, is logged with Error or
+// NamedError by an encoder whose ErrorStackSuffix is set, the recorded stack
+// is added as an array of {function, file, line} objects under the field's
+// key followed by the suffix (for example, "errorStack"). The suffix is
+// empty by default. Unlike the entry's stacktrace, this points at where the
+// error was created rather than where it was logged. If passed a nil error,
+// WithStack returns nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	stack := stacktrace.Capture(1 /* skip WithStack */, stacktrace.Full)
+	defer stack.Free()
+
+	pcs := make([]uintptr, stack.Count())
+	copy(pcs, stack.PCs())
+	return &stackError{err: err, pcs: pcs}
+}
+
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+var _ ladcore.StackCarrier = (*stackError)(nil)
+
+func (e *stackError) Error() string                 { return e.err.Error() }
+func (e *stackError) Unwrap() error                 { return e.err }
+func (e *stackError) LogStack() []uintptr           { return e.pcs }
+func (e *stackError) Format(s fmt.State, verb rune) { formatWrapped(s, verb, e.err) }
+
+// Formats a transparent wrapper like the error it wraps, so that rich
+// errors keep their verbose representation.
+func formatWrapped(s fmt.State, verb rune, err error) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		fmt.Fprintf(s, "%+v", err)
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	default:
+		_, _ = io.WriteString(s, err.Error())
+	}
+}
+
 type errArray []error
 
 func (errs errArray) MarshalLogArray(arr ladcore.ArrayEncoder) error {
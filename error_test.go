@@ -21,13 +21,17 @@
 package lad
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladtest/observer"
 )
 
 func TestErrorConstructors(t *testing.T) {
@@ -95,6 +99,141 @@ func TestErrorsArraysHandleRichErrors(t *testing.T) {
 	assert.Equal(t, "egad", errMap["error"], "Unexpected standard error string.")
 }
 
+func TestWrapError(t *testing.T) {
+	assert.NoError(t, WrapError(nil, String("k", "v")), "Expected wrapping nil to return nil.")
+
+	base := errors.New("not found")
+	err := WrapError(base, String("table", "users"))
+	assert.Equal(t, "not found", err.Error(), "Unexpected error message.")
+	assert.ErrorIs(t, err, base, "Expected errors.Is to see the wrapped error.")
+	assert.Equal(t, "not found", fmt.Sprintf("%v", err), "Unexpected %v formatting.")
+	assert.Equal(t, "not found", fmt.Sprintf("%+v", err), "Unexpected %+v formatting.")
+	assert.Equal(t, `"not found"`, fmt.Sprintf("%q", err), "Unexpected %q formatting.")
+}
+
+func TestErrorMergesCarriedFields(t *testing.T) {
+	inner := NewError("no rows", String("table", "users"), Int("id", 7))
+	outer := WrapError(
+		fmt.Errorf("load profile: %w", inner),
+		String("table", "profiles"),
+		String("request", "r1"),
+	)
+
+	enc := ladcore.NewMapObjectEncoder()
+	Error(outer).AddTo(enc)
+	assert.Equal(t, map[string]interface{}{
+		"error":   "load profile: no rows",
+		"table":   "profiles",
+		"request": "r1",
+		"id":      int64(7),
+	}, enc.Fields, "Expected fields from every layer, with the outermost key winning.")
+}
+
+func TestWrapErrorLoggedLoosely(t *testing.T) {
+	err := WrapError(errors.New("not found"), String("table", "users"))
+	want := map[string]interface{}{"error": "not found", "table": "users"}
+
+	enc := ladcore.NewMapObjectEncoder()
+	Any("error", err).AddTo(enc)
+	assert.Equal(t, want, enc.Fields, "Expected Any to keep the error message.")
+
+	withSugar(t, DebugLevel, nil, func(logger *SugaredLogger, logs *observer.ObservedLogs) {
+		logger.Errorw("failed", "error", err)
+		require.Equal(t, 1, logs.Len(), "Expected a log entry.")
+		assert.Equal(t, want, logs.AllUntimed()[0].ContextMap(), "Expected Errorw to keep the error message.")
+	})
+}
+
+func TestWrapErrorChainFields(t *testing.T) {
+	cfg := ladcore.EncoderConfig{ErrorFormat: ladcore.ChainErrorFormat}
+	buf, encErr := ladcore.NewJSONEncoder(cfg).EncodeEntry(ladcore.Entry{}, []Field{
+		Error(WrapError(errors.New("not found"), String("table", "users"))),
+	})
+	require.NoError(t, encErr, "Unexpected error encoding entry.")
+	defer buf.Free()
+	assert.Contains(t, buf.String(),
+		`{"type":"*lad.fieldError","message":"not found","fields":{"table":"users"}}`,
+		"Expected the chain to show the carried fields on their link.")
+}
+
+func TestErrorMergesFieldsFromMultiErrors(t *testing.T) {
+	err := errors.Join(
+		NewError("a", String("shard", "a"), Int("attempt", 1)),
+		nil,
+		NewError("b", String("shard", "b"), Bool("retry", true)),
+	)
+
+	buf, encErr := ladcore.NewJSONEncoder(ladcore.EncoderConfig{}).EncodeEntry(ladcore.Entry{}, []Field{Error(err)})
+	require.NoError(t, encErr, "Unexpected error encoding entry.")
+	defer buf.Free()
+	assert.Equal(t,
+		`{"error":"a\nb","shard":"a","attempt":1,"retry":true}`+"\n",
+		buf.String(),
+		"Expected fields from all causes, in order, with the first key winning.",
+	)
+}
+
+func TestErrorsArrayCarriedFields(t *testing.T) {
+	enc := ladcore.NewMapObjectEncoder()
+	Errors("errs", []error{
+		NewError("a", String("shard", "a")),
+		errors.New("b"),
+	}).AddTo(enc)
+	assert.Equal(t, map[string]interface{}{
+		"errs": []interface{}{
+			map[string]interface{}{"error": "a", "shard": "a"},
+			map[string]interface{}{"error": "b"},
+		},
+	}, enc.Fields, "Expected each error to carry its own fields.")
+}
+
+func TestWithStack(t *testing.T) {
+	assert.NoError(t, WithStack(nil), "Expected wrapping nil to return nil.")
+
+	base := errors.New("boom")
+	err := WithStack(base)
+	assert.ErrorIs(t, err, base, "Expected errors.Is to see the wrapped error.")
+	assert.Equal(t, "boom", fmt.Sprintf("%+v", err), "Unexpected verbose formatting.")
+
+	var carrier ladcore.StackCarrier
+	require.ErrorAs(t, err, &carrier, "Expected a StackCarrier.")
+	frame, _ := runtime.CallersFrames(carrier.LogStack()).Next()
+	assert.True(t, strings.HasSuffix(frame.Function, ".TestWithStack"),
+		"Expected the stack to start at the caller of WithStack, got %q.", frame.Function)
+}
+
+func TestErrorWithStackEncoding(t *testing.T) {
+	fields := []Field{Error(fmt.Errorf("handler: %w", WithStack(errors.New("boom"))))}
+
+	cfg := NewProductionEncoderConfig()
+	buf, encErr := ladcore.NewJSONEncoder(cfg).EncodeEntry(ladcore.Entry{}, fields)
+	require.NoError(t, encErr, "Unexpected error encoding entry.")
+	assert.NotContains(t, buf.String(), "errorStack", "Expected error stacks to be opt-in.")
+	buf.Free()
+
+	cfg.ErrorStackSuffix = "Stack"
+	buf, encErr = ladcore.NewJSONEncoder(cfg).EncodeEntry(ladcore.Entry{}, fields)
+	require.NoError(t, encErr, "Unexpected error encoding entry.")
+	defer buf.Free()
+
+	var got struct {
+		Error string `json:"error"`
+		Stack []struct {
+			Function string `json:"function"`
+			File     string `json:"file"`
+			Line     int    `json:"line"`
+		} `json:"errorStack"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Invalid JSON output: %s", buf.String())
+	assert.Equal(t, "handler: boom", got.Error, "Unexpected error message.")
+	require.NotEmpty(t, got.Stack, "Expected a structured stack.")
+	assert.True(t, strings.HasSuffix(got.Stack[0].Function, ".TestErrorWithStackEncoding"),
+		"Unexpected first frame: %+v.", got.Stack[0])
+	assert.True(t, strings.HasSuffix(got.Stack[0].File, "error_test.go"),
+		"Unexpected first frame: %+v.", got.Stack[0])
+	assert.Positive(t, got.Stack[0].Line, "Expected a line number.")
+}
+
 func TestErrArrayBrokenEncoder(t *testing.T) {
 	t.Parallel()
 
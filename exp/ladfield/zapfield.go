@@ -22,10 +22,27 @@
 package ladfield
 
 import (
+	"sort"
+
 	"github.com/tnngo/lad"
 	"github.com/tnngo/lad/ladcore"
 )
 
+// Signed is a constraint for signed integer types.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint for unsigned integer types.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is a constraint for floating-point types.
+type Float interface {
+	~float32 | ~float64
+}
+
 // Str constructs a field with the given string-like key and value.
 func Str[K ~string, V ~string](k K, v V) lad.Field {
 	return lad.String(string(k), string(v))
@@ -44,3 +61,95 @@ func (a stringArray[T]) MarshalLogArray(enc ladcore.ArrayEncoder) error {
 func Strs[K ~string, V ~[]S, S ~string](k K, v V) lad.Field {
 	return lad.Array(string(k), stringArray[S](v))
 }
+
+type intArray[T Signed] []T
+
+func (a intArray[T]) MarshalLogArray(enc ladcore.ArrayEncoder) error {
+	for i := range a {
+		enc.AppendInt64(int64(a[i]))
+	}
+	return nil
+}
+
+// Ints constructs a field that carries a slice of signed integers of any
+// width, including named integer types.
+func Ints[K ~string, V ~[]I, I Signed](k K, v V) lad.Field {
+	return lad.Array(string(k), intArray[I](v))
+}
+
+type uintArray[T Unsigned] []T
+
+func (a uintArray[T]) MarshalLogArray(enc ladcore.ArrayEncoder) error {
+	for i := range a {
+		enc.AppendUint64(uint64(a[i]))
+	}
+	return nil
+}
+
+// Uints constructs a field that carries a slice of unsigned integers of any
+// width, including named integer types.
+func Uints[K ~string, V ~[]U, U Unsigned](k K, v V) lad.Field {
+	return lad.Array(string(k), uintArray[U](v))
+}
+
+type floatArray[T Float] []T
+
+func (a floatArray[T]) MarshalLogArray(enc ladcore.ArrayEncoder) error {
+	for i := range a {
+		enc.AppendFloat64(float64(a[i]))
+	}
+	return nil
+}
+
+// Floats constructs a field that carries a slice of floating-point numbers,
+// including named floating-point types.
+func Floats[K ~string, V ~[]F, F Float](k K, v V) lad.Field {
+	return lad.Array(string(k), floatArray[F](v))
+}
+
+// Objects constructs a field that carries a slice of ObjectMarshalers.
+// Unlike lad.Objects, it accepts string-like keys and named slice types.
+func Objects[K ~string, V ~[]T, T ladcore.ObjectMarshaler](k K, v V) lad.Field {
+	return lad.Objects(string(k), []T(v))
+}
+
+type mapObject[M ~map[MK]V, MK ~string, V any] struct {
+	m     M
+	field func(string, V) lad.Field
+}
+
+func (o mapObject[M, MK, V]) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(o.m))
+	for k := range o.m {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		o.field(k, o.m[MK(k)]).AddTo(enc)
+	}
+	return nil
+}
+
+// Map constructs a field that carries a map with string-like keys as an
+// object. Entries are encoded in order of their keys, using the supplied
+// field constructor for each value. For example,
+//
+//	ladfield.Map("retries", map[string]int{"db": 2, "cache": 0}, lad.Int)
+//
+// logs {"retries": {"cache": 0, "db": 2}}.
+func Map[K ~string, M ~map[MK]V, MK ~string, V any](k K, m M, field func(string, V) lad.Field) lad.Field {
+	return lad.Object(string(k), mapObject[M, MK, V]{m: m, field: field})
+}
+
+// Optional constructs a field from a pointer, using the supplied field
+// constructor for the value it points to. The field is omitted if the
+// pointer is nil. For example,
+//
+//	ladfield.Optional("retry_after", resp.RetryAfter, lad.Duration)
+func Optional[K ~string, V any](k K, v *V, field func(string, V) lad.Field) lad.Field {
+	if v == nil {
+		return lad.Skip()
+	}
+	return field(string(k), *v)
+}
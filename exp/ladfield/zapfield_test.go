@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tnngo/lad"
 	"github.com/tnngo/lad/ladcore"
 )
@@ -33,8 +34,19 @@ type (
 	MyKey    string
 	MyValue  string
 	MyValues []MyValue
+	MyInt    int16
+	MyUint   uint8
+	MyFloat  float32
+	MyUsers  []user
 )
 
+type user struct{ name string }
+
+func (u user) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	return nil
+}
+
 func TestFieldConstructors(t *testing.T) {
 	var (
 		key    = MyKey("test key")
@@ -52,6 +64,12 @@ func TestFieldConstructors(t *testing.T) {
 	}{
 		{"Str", lad.Field{Type: ladcore.StringType, Key: "test key", String: "test value"}, Str(key, value)},
 		{"Strs", lad.Array("test key", stringArray[MyValue]{"test value 1", "test value 2"}), Strs(key, values)},
+		{"Ints", lad.Array("test key", intArray[MyInt]{1, -2}), Ints(key, []MyInt{1, -2})},
+		{"Uints", lad.Array("test key", uintArray[MyUint]{1, 2}), Uints(key, []MyUint{1, 2})},
+		{"Floats", lad.Array("test key", floatArray[MyFloat]{1.5}), Floats(key, []MyFloat{1.5})},
+		{"Objects", lad.Objects("test key", []user{{"a"}}), Objects(key, MyUsers{{"a"}})},
+		{"Optional nil", lad.Skip(), Optional[MyKey, int](key, nil, lad.Int)},
+		{"Optional", lad.Int("test key", 42), Optional(key, &[]int{42}[0], lad.Int)},
 	}
 
 	for _, tt := range tests {
@@ -62,6 +80,41 @@ func TestFieldConstructors(t *testing.T) {
 	}
 }
 
+func TestNumericArrays(t *testing.T) {
+	enc := ladcore.NewMapObjectEncoder()
+	Ints("ints", []int64{-1, 2}).AddTo(enc)
+	Uints("uints", []uintptr{3}).AddTo(enc)
+	Floats("floats", []float64{0.5}).AddTo(enc)
+	Ints("empty", []int8(nil)).AddTo(enc)
+
+	assert.Equal(t, map[string]interface{}{
+		"ints":   []interface{}{int64(-1), int64(2)},
+		"uints":  []interface{}{uint64(3)},
+		"floats": []interface{}{0.5},
+		"empty":  []interface{}{},
+	}, enc.Fields, "Unexpected encoded arrays.")
+}
+
+func TestMap(t *testing.T) {
+	labels := map[MyKey]MyValue{"zone": "b", "app": "web", "env": "prod"}
+	field := Map("labels", labels, func(k string, v MyValue) lad.Field { return Str(k, v) })
+	assertCanBeReused(t, field)
+
+	enc := ladcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	assert.Equal(t, map[string]interface{}{
+		"labels": map[string]interface{}{"app": "web", "env": "prod", "zone": "b"},
+	}, enc.Fields, "Unexpected encoded map.")
+
+	// Keys are encoded in sorted order.
+	buf, err := ladcore.NewJSONEncoder(ladcore.EncoderConfig{}).EncodeEntry(ladcore.Entry{}, []lad.Field{
+		Map("counts", map[string]int{"b": 2, "c": 3, "a": 1}, lad.Int),
+		Map("none", map[string]int(nil), lad.Int),
+	})
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	assert.Equal(t, `{"counts":{"a":1,"b":2,"c":3},"none":{}}`+"\n", buf.String(), "Unexpected JSON output.")
+}
+
 func assertCanBeReused(t testing.TB, field lad.Field) {
 	var wg sync.WaitGroup
 
@@ -18,8 +18,11 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-// package ladslog provides an implementation of slog.Handler which writes to
-// the supplied ladcore.Core.
+// package ladslog forwards to github.com/tnngo/lad/ladslog, where the slog
+// integration now lives.
 //
 // Use of this package requires at least Go 1.21.
+//
+// Deprecated: Use github.com/tnngo/lad/ladslog instead. This package will be
+// removed in a future release.
 package ladslog // import "github.com/tnngo/lad/exp/ladslog"
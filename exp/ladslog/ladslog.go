@@ -0,0 +1,87 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.21
+
+package ladslog
+
+import (
+	"log/slog"
+
+	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladslog"
+)
+
+// Handler implements the slog.Handler by writing to a zap Core.
+//
+// Deprecated: Use [ladslog.Handler] from github.com/tnngo/lad/ladslog.
+type Handler = ladslog.Handler
+
+// A HandlerOption configures a slog Handler.
+//
+// Deprecated: Use [ladslog.HandlerOption] from github.com/tnngo/lad/ladslog.
+type HandlerOption = ladslog.HandlerOption
+
+// NewHandler builds a [Handler] that writes to the supplied [ladcore.Core]
+// with options.
+//
+// Deprecated: Use [ladslog.NewHandler] from github.com/tnngo/lad/ladslog.
+func NewHandler(core ladcore.Core, opts ...HandlerOption) *Handler {
+	return ladslog.NewHandler(core, opts...)
+}
+
+// NewCore builds a [ladcore.Core] that forwards entries to the supplied
+// [slog.Handler].
+//
+// Deprecated: Use [ladslog.NewCore] from github.com/tnngo/lad/ladslog.
+func NewCore(handler slog.Handler) ladcore.Core {
+	return ladslog.NewCore(handler)
+}
+
+// WithName configures the Logger to annotate each message with the logger name.
+//
+// Deprecated: Use [ladslog.WithName] from github.com/tnngo/lad/ladslog.
+func WithName(name string) HandlerOption {
+	return ladslog.WithName(name)
+}
+
+// WithCaller configures the Logger to include the filename and line number
+// of the caller in log messages--if available.
+//
+// Deprecated: Use [ladslog.WithCaller] from github.com/tnngo/lad/ladslog.
+func WithCaller(enabled bool) HandlerOption {
+	return ladslog.WithCaller(enabled)
+}
+
+// WithCallerSkip increases the number of callers skipped by caller annotation
+// (as enabled by the [WithCaller] option).
+//
+// Deprecated: Use [ladslog.WithCallerSkip] from github.com/tnngo/lad/ladslog.
+func WithCallerSkip(skip int) HandlerOption {
+	return ladslog.WithCallerSkip(skip)
+}
+
+// AddStacktraceAt configures the Logger to record a stack trace
+// for all messages at or above a given level.
+//
+// Deprecated: Use [ladslog.AddStacktraceAt] from github.com/tnngo/lad/ladslog.
+func AddStacktraceAt(lvl slog.Level) HandlerOption {
+	return ladslog.AddStacktraceAt(lvl)
+}
@@ -479,7 +479,7 @@ func (f anyFieldC[T]) Any(key string, val any) Field {
 
 // Any takes a key and an arbitrary value and chooses the best way to represent
 // them as a field, falling back to a reflection-based approach only if
-// necessary.
+// necessary. Structs that use `lad` struct tags are encoded with Tagged.
 //
 // Since byte/uint8 and rune/int32 are aliases, Any can't differentiate between
 // them. To minimize surprises, []byte values are treated as binary blobs, byte
@@ -615,7 +615,11 @@ func Any(key string, value interface{}) Field {
 	case fmt.Stringer:
 		c = anyFieldC[fmt.Stringer](Stringer)
 	default:
-		c = anyFieldC[any](Reflect)
+		if hasLadTags(value) {
+			c = anyFieldC[any](Tagged)
+		} else {
+			c = anyFieldC[any](Reflect)
+		}
 	}
 
 	return c.Any(key, value)
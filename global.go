@@ -24,7 +24,6 @@ import (
 	"bytes"
 	"fmt"
 	"log"
-	"os"
 	"sync"
 
 	"github.com/tnngo/lad/ladcore"
@@ -97,8 +96,8 @@ func NewStdLogAt(l *Logger, level ladcore.Level) (*log.Logger, error) {
 // annotations, timestamps, etc., it automatically disables the standard
 // library's annotations and prefixing.
 //
-// It returns a function to restore the original prefix and flags and reset the
-// standard library's output to os.Stderr.
+// It returns a function to restore the original prefix, flags and output. See
+// RedirectStdLogBridge for how stacked redirects are restored.
 func RedirectStdLog(l *Logger) func() {
 	f, err := redirectStdLogAt(l, InfoLevel)
 	if err != nil {
@@ -114,28 +113,24 @@ func RedirectStdLog(l *Logger) func() {
 // handles caller annotations, timestamps, etc., it automatically disables the
 // standard library's annotations and prefixing.
 //
-// It returns a function to restore the original prefix and flags and reset the
-// standard library's output to os.Stderr.
+// It returns a function to restore the original prefix, flags and output. See
+// RedirectStdLogBridge for how stacked redirects are restored.
 func RedirectStdLogAt(l *Logger, level ladcore.Level) (func(), error) {
 	return redirectStdLogAt(l, level)
 }
 
 func redirectStdLogAt(l *Logger, level ladcore.Level) (func(), error) {
-	flags := log.Flags()
-	prefix := log.Prefix()
-	log.SetFlags(0)
-	log.SetPrefix("")
 	logger := l.WithOptions(AddCallerSkip(_stdLogDefaultDepth + _loggerWriterDepth))
 	logFunc, err := levelToFunc(logger, level)
 	if err != nil {
 		return nil, err
 	}
-	log.SetOutput(&loggerWriter{logFunc})
-	return func() {
-		log.SetFlags(flags)
-		log.SetPrefix(prefix)
-		log.SetOutput(os.Stderr)
-	}, nil
+
+	_stdLogRedirects.mu.Lock()
+	defer _stdLogRedirects.mu.Unlock()
+	return _stdLogRedirects.pushLocked(stdLogState{
+		out: &loggerWriter{logFunc},
+	}), nil
 }
 
 func levelToFunc(logger *Logger, lvl ladcore.Level) (func(string, ...Field), error) {
@@ -21,7 +21,12 @@
 // Package color adds coloring functionality for TTY output.
 package color
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
 
 // Foreground colors.
 const (
@@ -35,10 +40,137 @@ const (
 	White
 )
 
+// None leaves text uncolored.
+const None Color = 0
+
+var _colorNames = map[Color]string{
+	None:    "none",
+	Black:   "black",
+	Red:     "red",
+	Green:   "green",
+	Yellow:  "yellow",
+	Blue:    "blue",
+	Magenta: "magenta",
+	Cyan:    "cyan",
+	White:   "white",
+}
+
 // Color represents a text color.
 type Color uint8
 
-// Add adds the coloring to the given string.
+// Add adds the coloring to the given string. None returns s unchanged.
 func (c Color) Add(s string) string {
+	if c == None {
+		return s
+	}
 	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", uint8(c), s)
 }
+
+// String returns the color's name, or its ANSI code if it has none.
+func (c Color) String() string {
+	if name, ok := _colorNames[c]; ok {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// MarshalText marshals the Color to its name or ANSI code.
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText unmarshals a color name ("red", "none", ...) or an ANSI
+// SGR code such as "92" to a Color. An empty string is unmarshaled to None.
+func (c *Color) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	if name == "" {
+		*c = None
+		return nil
+	}
+	for color, n := range _colorNames {
+		if n == name {
+			*c = color
+			return nil
+		}
+	}
+	code, err := strconv.ParseUint(name, 10, 8)
+	if err != nil {
+		return fmt.Errorf("unrecognized color: %q", text)
+	}
+	*c = Color(code)
+	return nil
+}
+
+// Theme assigns colors to the parts of a log entry.
+type Theme struct {
+	Debug  Color `json:"debug" yaml:"debug"`
+	Info   Color `json:"info" yaml:"info"`
+	Warn   Color `json:"warn" yaml:"warn"`
+	Error  Color `json:"error" yaml:"error"`
+	DPanic Color `json:"dpanic" yaml:"dpanic"`
+	Panic  Color `json:"panic" yaml:"panic"`
+	Fatal  Color `json:"fatal" yaml:"fatal"`
+
+	Key    Color `json:"key" yaml:"key"`
+	Name   Color `json:"name" yaml:"name"`
+	Caller Color `json:"caller" yaml:"caller"`
+}
+
+// DefaultTheme returns the theme used when none is configured. Its level
+// colors match those of the color level encoders.
+func DefaultTheme() Theme {
+	return Theme{
+		Debug:  Magenta,
+		Info:   Blue,
+		Warn:   Yellow,
+		Error:  Red,
+		DPanic: Red,
+		Panic:  Red,
+		Fatal:  Red,
+		Key:    Blue,
+		Name:   Magenta,
+		Caller: Cyan,
+	}
+}
+
+// Strip removes ANSI escape sequences from s.
+func Strip(s string) string {
+	i := strings.IndexByte(s, '\x1b')
+	if i < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i >= 0 {
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		if len(s) > 0 && s[0] == '[' {
+			// Skip parameter and intermediate bytes up to the final byte.
+			j := 1
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			s = s[j:]
+		}
+		i = strings.IndexByte(s, '\x1b')
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// Enabled reports whether output should be colored, given whether it's going
+// to a terminal. A non-empty NO_COLOR disables colors, and FORCE_COLOR set to
+// anything but "" or "0" enables them; NO_COLOR wins if both are set.
+// Otherwise colors are enabled for terminals whose TERM isn't "dumb".
+func Enabled(terminal bool) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" {
+		return true
+	}
+	return terminal && os.Getenv("TERM") != "dumb"
+}
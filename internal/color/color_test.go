@@ -24,6 +24,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestColorFormatting(t *testing.T) {
@@ -34,3 +35,77 @@ func TestColorFormatting(t *testing.T) {
 		"Unexpected colored output.",
 	)
 }
+
+func TestNoneAddsNothing(t *testing.T) {
+	assert.Equal(t, "foo", None.Add("foo"), "Expected None to leave text unchanged.")
+}
+
+func TestColorText(t *testing.T) {
+	tests := []struct {
+		text  string
+		color Color
+	}{
+		{"none", None},
+		{"red", Red},
+		{"cyan", Cyan},
+		{"92", Color(92)},
+	}
+	for _, tt := range tests {
+		var c Color
+		require.NoError(t, c.UnmarshalText([]byte(tt.text)), "Unexpected error unmarshaling %q.", tt.text)
+		assert.Equal(t, tt.color, c, "Unexpected color for %q.", tt.text)
+
+		text, err := c.MarshalText()
+		require.NoError(t, err, "Unexpected error marshaling %v.", c)
+		assert.Equal(t, tt.text, string(text), "Unexpected text for %v.", c)
+	}
+
+	var c Color
+	require.NoError(t, c.UnmarshalText([]byte("Blue")), "Expected color names to be case-insensitive.")
+	assert.Equal(t, Blue, c, "Unexpected color.")
+	assert.Error(t, c.UnmarshalText([]byte("mauve")), "Expected an error for an unknown color.")
+	assert.Error(t, c.UnmarshalText([]byte("300")), "Expected an error for an out-of-range code.")
+}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		give string
+		want string
+	}{
+		{"plain", "plain"},
+		{Red.Add("foo"), "foo"},
+		{"a" + Blue.Add("b") + "c" + Green.Add("d"), "abcd"},
+		{"\x1b[1;31mbold\x1b[0m", "bold"},
+		{"trailing\x1b[", "trailing"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Strip(tt.give), "Unexpected result stripping %q.", tt.give)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		desc     string
+		noColor  string
+		force    string
+		term     string
+		terminal bool
+		want     bool
+	}{
+		{desc: "terminal", term: "xterm", terminal: true, want: true},
+		{desc: "not a terminal", term: "xterm", want: false},
+		{desc: "dumb terminal", term: "dumb", terminal: true, want: false},
+		{desc: "NO_COLOR", noColor: "1", term: "xterm", terminal: true, want: false},
+		{desc: "FORCE_COLOR", force: "1", want: true},
+		{desc: "FORCE_COLOR=0", force: "0", term: "xterm", want: false},
+		{desc: "NO_COLOR beats FORCE_COLOR", noColor: "1", force: "1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("FORCE_COLOR", tt.force)
+			t.Setenv("TERM", tt.term)
+			assert.Equal(t, tt.want, Enabled(tt.terminal), "Unexpected result.")
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"strings"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+// LevelFromName maps a level name found in foreign log output, such as lines
+// written through the standard library's logger or a child process, to a
+// lad level. Names are matched case-insensitively, and common aliases such
+// as "warning", "err", "crit" and "trace" are accepted.
+//
+// DPanic, Panic and Fatal are reported as Error, so that foreign output
+// can't panic or terminate the current process.
+func LevelFromName(name string) (ladcore.Level, bool) {
+	// Level names are short. Bail out early so that we don't lowercase
+	// entire lines that happen to contain a colon.
+	if len(name) == 0 || len(name) > len("critical") {
+		return ladcore.InfoLevel, false
+	}
+
+	switch strings.ToLower(name) {
+	case "trace", "debug":
+		return ladcore.DebugLevel, true
+	case "info":
+		return ladcore.InfoLevel, true
+	case "warn", "warning":
+		return ladcore.WarnLevel, true
+	case "err", "error", "crit", "critical", "dpanic", "panic", "fatal":
+		return ladcore.ErrorLevel, true
+	}
+	return ladcore.InfoLevel, false
+}
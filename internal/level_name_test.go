@@ -0,0 +1,55 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tnngo/lad/ladcore"
+)
+
+func TestLevelFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want ladcore.Level
+		ok   bool
+	}{
+		{"trace", ladcore.DebugLevel, true},
+		{"DEBUG", ladcore.DebugLevel, true},
+		{"Info", ladcore.InfoLevel, true},
+		{"warning", ladcore.WarnLevel, true},
+		{"ERR", ladcore.ErrorLevel, true},
+		{"critical", ladcore.ErrorLevel, true},
+		{"dpanic", ladcore.ErrorLevel, true},
+		{"panic", ladcore.ErrorLevel, true},
+		{"FATAL", ladcore.ErrorLevel, true},
+		{"", ladcore.InfoLevel, false},
+		{"notice", ladcore.InfoLevel, false},
+		{"something much longer", ladcore.InfoLevel, false},
+	}
+
+	for _, tt := range tests {
+		lvl, ok := LevelFromName(tt.name)
+		assert.Equal(t, tt.ok, ok, "Unexpected result recognizing %q.", tt.name)
+		assert.Equal(t, tt.want, lvl, "Unexpected level for %q.", tt.name)
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package logparse reads the entries written by ladcore's console and JSON
+// encoders back into entries and fields, so that they can be filtered and
+// encoded again.
+package logparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tnngo/lad/internal/color"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// ErrNotEntry is returned when a line doesn't start an entry. When reading
+// console output, such lines continue the entry before them.
+var ErrNotEntry = errors.New("not a log entry")
+
+// _callerPattern matches the callers written by ladcore's caller encoders.
+var _callerPattern = regexp.MustCompile(`^\S+:\d+$`)
+
+// Record is an entry read from a log.
+type Record struct {
+	ladcore.Entry
+
+	// Fields holds the entry's context in order. Encoding them again
+	// reproduces the original JSON; see Value for their values.
+	Fields []ladcore.Field
+}
+
+// Field returns the field with the given key.
+func (r Record) Field(key string) (ladcore.Field, bool) {
+	for _, f := range r.Fields {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return ladcore.Field{}, false
+}
+
+type part uint8
+
+const (
+	partField part = iota
+	partTime
+	partLevel
+	partName
+	partCaller
+	partFunction
+	partMessage
+	partStack
+)
+
+// Parser parses entries written by encoders using a given EncoderConfig.
+type Parser struct {
+	cfg      ladcore.EncoderConfig
+	time     TimeFormat
+	timeSeps int
+	parts    map[string]part
+}
+
+// NewParser creates a Parser for entries written with cfg, with times in
+// the given format. Like the encoders, the parser expects the parts of an
+// entry whose keys are empty to be omitted; the config's encoder functions
+// are ignored. The console separator defaults to a tab.
+//
+// The console encoder leaves out the logger name when there's none, and
+// doesn't quote the message, so some lines are ambiguous. When there's no
+// caller to mark the end of the logger name, the first element after the
+// level is taken to be the name if more than one remains, and the message
+// extends up to the last element that's a valid JSON object.
+func NewParser(cfg ladcore.EncoderConfig, tf TimeFormat) *Parser {
+	if cfg.ConsoleSeparator == "" {
+		cfg.ConsoleSeparator = "\t"
+	}
+	p := &Parser{
+		cfg:      cfg,
+		time:     tf,
+		timeSeps: tf.separators(cfg.ConsoleSeparator),
+		parts:    make(map[string]part),
+	}
+	for key, part := range map[string]part{
+		cfg.StacktraceKey: partStack,
+		cfg.MessageKey:    partMessage,
+		cfg.FunctionKey:   partFunction,
+		cfg.CallerKey:     partCaller,
+		cfg.NameKey:       partName,
+		cfg.LevelKey:      partLevel,
+		cfg.TimeKey:       partTime,
+	} {
+		if key != "" {
+			p.parts[key] = part
+		}
+	}
+	return p
+}
+
+// EncoderConfig returns a config for encoders that write records the way
+// they were read: it uses the parser's keys, separator and time format, and
+// writes callers in full. Levels are written in lowercase.
+func (p *Parser) EncoderConfig() ladcore.EncoderConfig {
+	cfg := p.cfg
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = ladcore.DefaultLineEnding
+	}
+	cfg.EncodeLevel = ladcore.LowercaseLevelEncoder
+	cfg.EncodeTime = p.time.Encoder()
+	cfg.EncodeDuration = ladcore.StringDurationEncoder
+	cfg.EncodeCaller = ladcore.FullCallerEncoder
+	cfg.EncodeName = ladcore.FullNameEncoder
+	return cfg
+}
+
+// TimeFormat returns the format the parser reads times in.
+func (p *Parser) TimeFormat() TimeFormat {
+	return p.time
+}
+
+// ParseConsole parses the first line of an entry written by the console
+// encoder, without its line ending. Colors are ignored. The entry's stack
+// trace, if any, is on the lines after it; see Reader.
+func (p *Parser) ParseConsole(line string) (Record, error) {
+	var rec Record
+	sep := p.cfg.ConsoleSeparator
+	rest := color.Strip(line)
+
+	if p.cfg.TimeKey != "" {
+		var text string
+		text, rest = cutN(rest, sep, p.timeSeps+1)
+		t, err := p.time.Parse(text)
+		if err != nil {
+			return rec, fmt.Errorf("%w: %v", ErrNotEntry, err)
+		}
+		rec.Time = t
+	}
+	if p.cfg.LevelKey != "" {
+		var text string
+		text, rest = cutN(rest, sep, 1)
+		if text == "" {
+			return rec, fmt.Errorf("%w: missing level", ErrNotEntry)
+		}
+		if err := rec.Level.UnmarshalText([]byte(text)); err != nil {
+			return rec, fmt.Errorf("%w: %v", ErrNotEntry, err)
+		}
+	}
+
+	body, context := p.cutContext(rest)
+	if context != "" {
+		fields, err := parseObject([]byte(context), true)
+		if err != nil {
+			return rec, err
+		}
+		rec.Fields = fields
+	}
+
+	pieces := strings.Split(body, sep)
+	msg := 0
+	if c := p.callerIndex(pieces); c >= 0 {
+		rec.LoggerName = strings.Join(pieces[:c], sep)
+		rec.Caller = parseCaller(pieces[c])
+		msg = c + 1
+		if p.cfg.FunctionKey != "" && msg < len(pieces) {
+			rec.Caller.Function = pieces[msg]
+			msg++
+		}
+	} else if p.cfg.NameKey != "" && len(pieces) > 1 {
+		rec.LoggerName = pieces[0]
+		msg = 1
+	}
+	if p.cfg.MessageKey != "" {
+		rec.Message = strings.Join(pieces[msg:], sep)
+	}
+	return rec, nil
+}
+
+// cutContext splits s before the trailing JSON object written by the
+// console encoder, if there's one.
+func (p *Parser) cutContext(s string) (body, context string) {
+	open := p.cfg.ConsoleSeparator + "{"
+	for i := 0; i < len(s); {
+		j := strings.Index(s[i:], open)
+		if j < 0 {
+			break
+		}
+		start := i + j + len(p.cfg.ConsoleSeparator)
+		if json.Valid([]byte(s[start:])) {
+			return s[:i+j], s[start:]
+		}
+		i = start
+	}
+	return s, ""
+}
+
+// callerIndex returns the index of the first piece that's a caller, or -1.
+// The last piece is always part of the message.
+func (p *Parser) callerIndex(pieces []string) int {
+	if p.cfg.CallerKey == "" {
+		return -1
+	}
+	last := len(pieces)
+	if p.cfg.MessageKey != "" {
+		last--
+	}
+	for i := 0; i < last; i++ {
+		if _callerPattern.MatchString(pieces[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseJSON parses an entry written by the JSON encoder. Values of the
+// entry's keys that don't have the expected type are kept as fields.
+func (p *Parser) ParseJSON(line []byte) (Record, error) {
+	var rec Record
+	fields, err := parseObject(line, false)
+	if err != nil {
+		return rec, err
+	}
+	for _, f := range fields {
+		part := p.parts[f.Key]
+		if part == partTime {
+			if t, ok := p.parseJSONTime(f); ok {
+				rec.Time = t
+				continue
+			}
+		}
+		if part == partField || part == partTime || f.Type != ladcore.StringType {
+			rec.Fields = append(rec.Fields, f)
+			continue
+		}
+
+		s := f.String
+		switch part {
+		case partLevel:
+			if err := rec.Level.UnmarshalText([]byte(s)); err != nil {
+				return rec, err
+			}
+		case partName:
+			rec.LoggerName = s
+		case partCaller:
+			fn := rec.Caller.Function
+			rec.Caller = parseCaller(s)
+			rec.Caller.Function = fn
+		case partFunction:
+			rec.Caller.Function = s
+		case partMessage:
+			rec.Message = s
+		case partStack:
+			rec.Stack = s
+		}
+	}
+	return rec, nil
+}
+
+func (p *Parser) parseJSONTime(f ladcore.Field) (time.Time, bool) {
+	switch v := Value(f).(type) {
+	case string:
+		t, err := p.time.Parse(v)
+		return t, err == nil
+	case json.Number:
+		t, err := p.time.parseEpoch(string(v))
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// parseObject parses a JSON object into fields, in order. See newField for
+// keepCompact.
+func parseObject(data []byte, keepCompact bool) (Object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("%w: expected a JSON object", ErrNotEntry)
+	}
+	var obj Object
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		f, err := newField(tok.(string), raw, keepCompact)
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, f)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON object")
+	}
+	return obj, nil
+}
+
+// parseCaller parses a caller written as file:line.
+func parseCaller(s string) ladcore.EntryCaller {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return ladcore.EntryCaller{Defined: true, File: s}
+	}
+	line, _ := strconv.Atoi(s[i+1:])
+	return ladcore.EntryCaller{Defined: true, File: s[:i], Line: line}
+}
+
+// cutN cuts s after n elements separated by sep.
+func cutN(s, sep string, n int) (before, after string) {
+	end := 0
+	for i := 0; i < n; i++ {
+		j := strings.Index(s[end:], sep)
+		if j < 0 {
+			return s, ""
+		}
+		if i == n-1 {
+			return s[:end+j], s[end+j+len(sep):]
+		}
+		end += j + len(sep)
+	}
+	return "", s
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logparse
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad/ladcore"
+)
+
+func testConfig() ladcore.EncoderConfig {
+	return ladcore.EncoderConfig{
+		TimeKey:       "ts",
+		LevelKey:      "level",
+		NameKey:       "logger",
+		CallerKey:     "caller",
+		MessageKey:    "msg",
+		StacktraceKey: "stacktrace",
+	}
+}
+
+type user struct{ name string }
+
+func (u user) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	return enc.AddArray("roles", ladcore.ArrayMarshalerFunc(func(arr ladcore.ArrayEncoder) error {
+		arr.AppendString("admin")
+		arr.AppendString("dev")
+		return nil
+	}))
+}
+
+func testEntries() []struct {
+	desc   string
+	ent    ladcore.Entry
+	fields []ladcore.Field
+} {
+	ts := time.Date(2024, time.May, 1, 10, 0, 0, 123000000, time.Local)
+	caller := ladcore.EntryCaller{Defined: true, File: "/src/app/server.go", Line: 42}
+	return []struct {
+		desc   string
+		ent    ladcore.Entry
+		fields []ladcore.Field
+	}{
+		{
+			desc: "message only",
+			ent:  ladcore.Entry{Time: ts, Level: ladcore.InfoLevel, Message: "started"},
+		},
+		{
+			desc: "everything",
+			ent: ladcore.Entry{
+				Time:       ts,
+				Level:      ladcore.ErrorLevel,
+				LoggerName: "http.server",
+				Caller:     caller,
+				Message:    "request failed: {bad} input",
+				Stack:      "main.handle\n\t/src/app/server.go:42\nmain.main\n\t/src/app/main.go:10",
+			},
+			fields: []ladcore.Field{
+				{Key: "status", Type: ladcore.Int64Type, Integer: 500},
+				{Key: "path", Type: ladcore.StringType, String: "/users?id=1&x=<y>"},
+				{Key: "user", Type: ladcore.ObjectMarshalerType, Interface: user{"ada"}},
+				{Key: "ok", Type: ladcore.BoolType, Integer: 0},
+				{Key: "mixed", Type: ladcore.ReflectType, Interface: []interface{}{1.5, "x", true, nil, map[string]int{"b": 2}}},
+			},
+		},
+		{
+			desc: "caller without logger name",
+			ent:  ladcore.Entry{Time: ts, Level: ladcore.WarnLevel, Caller: caller, Message: "slow"},
+		},
+		{
+			desc: "logger name without caller",
+			ent:  ladcore.Entry{Time: ts, Level: ladcore.DebugLevel, LoggerName: "db", Message: "query"},
+		},
+		{
+			desc:   "empty message",
+			ent:    ladcore.Entry{Time: ts, Level: ladcore.InfoLevel},
+			fields: []ladcore.Field{{Key: "k", Type: ladcore.StringType, String: "v"}},
+		},
+		{
+			desc: "namespace",
+			ent:  ladcore.Entry{Time: ts, Level: ladcore.InfoLevel, Message: "nested"},
+			fields: []ladcore.Field{
+				{Key: "req", Type: ladcore.NamespaceType},
+				{Key: "id", Type: ladcore.Int64Type, Integer: 7},
+			},
+		},
+	}
+}
+
+func encode(t *testing.T, enc ladcore.Encoder, ent ladcore.Entry, fields []ladcore.Field) string {
+	buf, err := enc.EncodeEntry(ent, fields)
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+	return buf.String()
+}
+
+func readAll(t *testing.T, r *Reader) []Record {
+	var recs []Record
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return recs
+		}
+		require.NoError(t, err, "Unexpected error reading records.")
+		recs = append(recs, rec)
+	}
+}
+
+func TestConsoleRoundTrip(t *testing.T) {
+	formats := []TimeFormat{DefaultTimeFormat, "iso8601", "rfc3339nano", "nanos", "Jan _2 15:04:05.000"}
+	for _, tf := range formats {
+		for _, sep := range []string{"", " | "} {
+			p := NewParser(withSeparator(testConfig(), sep), tf)
+			cfg := p.EncoderConfig()
+			cfg.EncodeLevel = ladcore.CapitalColorLevelEncoder
+			enc := ladcore.NewConsoleEncoder(cfg)
+
+			for _, tt := range testEntries() {
+				want := encode(t, enc, tt.ent, tt.fields)
+				recs := readAll(t, p.NewReader(strings.NewReader(want), ConsoleFormat))
+				require.Len(t, recs, 1, "%s (%q, %q): expected one record from %q.", tt.desc, tf, sep, want)
+
+				got := encode(t, enc, recs[0].Entry, recs[0].Fields)
+				assert.Equal(t, want, got, "%s (%q, %q): round trip changed the entry.", tt.desc, tf, sep)
+			}
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, tf := range []TimeFormat{"epoch", "millis", "nanos", "rfc3339nano", DefaultTimeFormat} {
+		p := NewParser(testConfig(), tf)
+		enc := ladcore.NewJSONEncoder(p.EncoderConfig())
+		for _, tt := range testEntries() {
+			want := encode(t, enc, tt.ent, tt.fields)
+			recs := readAll(t, p.NewReader(strings.NewReader(want), AutoFormat))
+			require.Len(t, recs, 1, "%s (%q): expected one record from %q.", tt.desc, tf, want)
+
+			got := encode(t, enc, recs[0].Entry, recs[0].Fields)
+			assert.Equal(t, want, got, "%s (%q): round trip changed the entry.", tt.desc, tf)
+		}
+	}
+}
+
+func TestConsoleToJSON(t *testing.T) {
+	p := NewParser(testConfig(), DefaultTimeFormat)
+	console := ladcore.NewConsoleEncoder(p.EncoderConfig())
+	json := ladcore.NewJSONEncoder(p.EncoderConfig())
+
+	for _, tt := range testEntries() {
+		recs := readAll(t, p.NewReader(strings.NewReader(encode(t, console, tt.ent, tt.fields)), ConsoleFormat))
+		require.Len(t, recs, 1, "%s: expected one record.", tt.desc)
+		assert.Equal(t,
+			encode(t, json, tt.ent, tt.fields),
+			encode(t, json, recs[0].Entry, recs[0].Fields),
+			"%s: expected the same JSON as encoding the original entry.", tt.desc)
+	}
+}
+
+func TestParseJSONKeepsMistypedKeys(t *testing.T) {
+	p := NewParser(testConfig(), "epoch")
+	rec, err := p.ParseJSON([]byte(`{"level":"warn","msg":7,"stacktrace":{"frames":[]},"k":"v"}`))
+	require.NoError(t, err, "Unexpected error parsing JSON.")
+	assert.Equal(t, ladcore.WarnLevel, rec.Level, "Unexpected level.")
+	assert.Empty(t, rec.Message, "Expected a numeric message to be kept as a field.")
+
+	var keys []string
+	for _, f := range rec.Fields {
+		keys = append(keys, f.Key)
+	}
+	assert.Equal(t, []string{"msg", "stacktrace", "k"}, keys, "Unexpected fields.")
+	f, ok := rec.Field("k")
+	require.True(t, ok, "Expected to find field k.")
+	assert.Equal(t, "v", Value(f), "Unexpected value.")
+}
+
+func TestValue(t *testing.T) {
+	rec, err := NewParser(testConfig(), "epoch").ParseJSON([]byte(`{"a":[1,"x",true,null,{"b":2.5}],"n":-3}`))
+	require.NoError(t, err, "Unexpected error parsing JSON.")
+
+	a, ok := rec.Field("a")
+	require.True(t, ok, "Expected to find field a.")
+	assert.Equal(t, Array{
+		json.Number("1"), "x", true, nil,
+		Object{{Key: "b", Type: ladcore.ReflectType, Interface: json.Number("2.5")}},
+	}, Value(a), "Unexpected array value.")
+
+	n, ok := rec.Field("n")
+	require.True(t, ok, "Expected to find field n.")
+	assert.Equal(t, json.Number("-3"), Value(n), "Unexpected number value.")
+
+	spaced, err := NewParser(testConfig(), "epoch").ParseConsole(`0	info	m	{"o": {"k": [1, 2]}}`)
+	require.NoError(t, err, "Unexpected error parsing console line.")
+	o, ok := spaced.Field("o")
+	require.True(t, ok, "Expected to find field o.")
+	assert.Equal(t, ladcore.ObjectMarshalerType, o.Type, "Expected spaced objects to be parsed.")
+}
+
+func TestReaderErrors(t *testing.T) {
+	p := NewParser(testConfig(), DefaultTimeFormat)
+	input := strings.Join([]string{
+		"orphan line",
+		"2024-05-01 10:00:00.000\tinfo\tfirst",
+		"\tcontinued",
+		"",
+		`{"level":"info","msg":"json"}`,
+		"not json either",
+		`{"level":"info",`,
+		"2024-05-01 10:00:01.000\tbogus\tsecond",
+	}, "\n")
+	r := p.NewReader(strings.NewReader(input), AutoFormat)
+
+	var (
+		msgs   []string
+		errors []int
+	)
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if lineErr, ok := err.(*LineError); ok {
+			errors = append(errors, lineErr.Line)
+			continue
+		}
+		require.NoError(t, err, "Unexpected error.")
+		msgs = append(msgs, rec.Message+"|"+rec.Stack)
+	}
+	assert.Equal(t, []string{"first|\tcontinued", "json|"}, msgs, "Unexpected records.")
+	assert.Equal(t, []int{1, 6, 7, 8}, errors, "Expected errors for unparseable lines.")
+}
+
+// stutteringReader returns its chunks one at a time, with errWait after
+// each. Empty chunks stand for an extra errWait.
+type stutteringReader struct {
+	chunks []string
+	wait   bool
+}
+
+var errWait = errors.New("wait")
+
+func (r *stutteringReader) Read(p []byte) (int, error) {
+	if r.wait {
+		r.wait = false
+		return 0, errWait
+	}
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	if r.chunks[0] == "" {
+		r.chunks = r.chunks[1:]
+		return 0, errWait
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	r.wait = true
+	return n, nil
+}
+
+// readStuttering reads records until the end of the input, recording
+// errWait as "wait" and records as "message|stack".
+func readStuttering(t *testing.T, r *Reader) []string {
+	t.Helper()
+
+	var got []string
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errWait) {
+			got = append(got, "wait")
+			continue
+		}
+		require.NoError(t, err, "Unexpected error.")
+		got = append(got, rec.Message+"|"+rec.Stack)
+	}
+	return got
+}
+
+func TestReaderResumesAfterErrors(t *testing.T) {
+	p := NewParser(testConfig(), DefaultTimeFormat)
+	r := p.NewReader(&stutteringReader{chunks: []string{
+		"2024-05-01 10:00:00.000\tinfo\tfirst\n",
+		"\tstack\n2024-05-01 10:00:01.000\tin",
+		"fo\tsecond\n",
+	}}, ConsoleFormat)
+	assert.Equal(t, []string{"wait", "wait", "first|\tstack", "wait", "second|"}, readStuttering(t, r),
+		"Expected console records to wait for their stack traces, and cut lines to be joined.")
+
+	r = p.NewReader(&stutteringReader{chunks: []string{
+		"2024-05-01 10:00:00.000\tinfo\tfirst\n",
+		"",
+		"2024-05-01 10:00:01.000\tinfo\tsecond\n",
+	}}, ConsoleFormat)
+	assert.Equal(t, []string{"wait", "first|", "wait", "wait", "second|"}, readStuttering(t, r),
+		"Expected console records to be returned after an error with no new input.")
+
+	r = p.NewReader(&stutteringReader{chunks: []string{`{"msg":"json"}` + "\n"}}, AutoFormat)
+	rec, err := r.Next()
+	require.NoError(t, err, "Unexpected error.")
+	assert.Equal(t, "json", rec.Message, "Expected JSON records to be returned before errors.")
+	_, err = r.Next()
+	assert.ErrorIs(t, err, errWait, "Expected the read error after the record.")
+
+	r = p.NewReader(&stutteringReader{chunks: []string{"2024-05-01 10:00:00.000\tinfo\tlast\n"}}, ConsoleFormat)
+	_, err = r.Next()
+	assert.ErrorIs(t, err, errWait, "Expected the console record to be held back.")
+	rec, ok := r.Flush()
+	require.True(t, ok, "Expected Flush to return the held record.")
+	assert.Equal(t, "last", rec.Message, "Unexpected flushed record.")
+	_, ok = r.Flush()
+	assert.False(t, ok, "Expected nothing left to flush.")
+}
+
+func TestTimeFormat(t *testing.T) {
+	ts := time.Date(2024, time.May, 1, 10, 0, 0, 123456000, time.UTC)
+	for _, tf := range []TimeFormat{"epoch", "millis", "nanos", "iso8601", "rfc3339nano", time.RFC1123} {
+		got, err := tf.Parse(tf.Format(ts))
+		require.NoError(t, err, "Unexpected error parsing %q time.", tf)
+		want := ts
+		if tf == time.RFC1123 {
+			want = ts.Truncate(time.Second)
+		} else if tf == "iso8601" {
+			want = ts.Truncate(time.Millisecond)
+		}
+		assert.True(t, want.Equal(got), "%q: expected %v, got %v.", tf, want, got)
+	}
+
+	_, err := TimeFormat("epoch").Parse("yesterday")
+	assert.Error(t, err, "Expected an error for a malformed epoch time.")
+}
+
+func withSeparator(cfg ladcore.EncoderConfig, sep string) ladcore.EncoderConfig {
+	cfg.ConsoleSeparator = sep
+	return cfg
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logparse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format selects how a Reader parses lines.
+type Format uint8
+
+const (
+	// AutoFormat parses lines starting with "{" as JSON and anything else
+	// as console output.
+	AutoFormat Format = iota
+	// ConsoleFormat parses lines written by the console encoder.
+	ConsoleFormat
+	// JSONFormat parses lines written by the JSON encoder.
+	JSONFormat
+)
+
+// String returns the name used for the format on the command line.
+func (f Format) String() string {
+	switch f {
+	case AutoFormat:
+		return "auto"
+	case ConsoleFormat:
+		return "console"
+	case JSONFormat:
+		return "json"
+	default:
+		return fmt.Sprintf("Format(%d)", uint8(f))
+	}
+}
+
+// Set sets the format for the flag.Value interface.
+func (f *Format) Set(s string) error {
+	switch s {
+	case "auto":
+		*f = AutoFormat
+	case "console":
+		*f = ConsoleFormat
+	case "json":
+		*f = JSONFormat
+	default:
+		return fmt.Errorf("unrecognized format: %q", s)
+	}
+	return nil
+}
+
+// LineError reports a line that couldn't be parsed.
+type LineError struct {
+	Line int    // 1-based line number
+	Text string // the line, without its line ending
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads records from a log, one line at a time.
+//
+// The console encoder writes an entry's stack trace on the lines after it,
+// so lines that don't start an entry are added to the stack trace of the
+// console entry before them. Records are therefore returned once the line
+// after them has been read, or the input ends. Blank lines are skipped.
+type Reader struct {
+	p      *Parser
+	format Format
+	r      *bufio.Reader
+	line   int
+
+	partial string  // start of a line cut short by a read error
+	pending *Record // last record read, waiting for continuation lines
+	console bool    // whether pending was read from console output
+	grew    bool    // whether any input arrived since the last read error
+	queued  error   // error to return after pending
+	eof     bool
+}
+
+// NewReader creates a Reader parsing lines from r in the given format.
+func (p *Parser) NewReader(r io.Reader, f Format) *Reader {
+	return &Reader{p: p, format: f, r: bufio.NewReader(r)}
+}
+
+// Next returns the next record. A line that can't be parsed is reported with
+// a *LineError, after which reading can continue. Next returns io.EOF at the
+// end of the input.
+//
+// If the underlying reader fails with another error, Next returns it. Reading
+// can resume after that, for example once a file being followed has grown.
+// Records read before the error are returned first, except that a console
+// record is held back through one error, since the rest of its stack trace
+// may still be on its way. If no lines arrive before the next error, it's
+// returned then. Callers giving up on the input in between should collect it
+// with Flush.
+func (r *Reader) Next() (Record, error) {
+	for {
+		if err := r.queued; err != nil {
+			r.queued = nil
+			return Record{}, err
+		}
+		if r.eof {
+			if rec, ok := r.Flush(); ok {
+				return rec, nil
+			}
+			return Record{}, io.EOF
+		}
+
+		text, err := r.r.ReadString('\n')
+		r.grew = r.grew || text != ""
+		text, r.partial = r.partial+text, ""
+		if err != nil && !errors.Is(err, io.EOF) {
+			// The rest of the line may come with a later read.
+			r.partial = text
+			if r.pending != nil && r.console && r.grew {
+				r.grew = false
+				return Record{}, err
+			}
+			r.grew = false
+			if rec, ok := r.Flush(); ok {
+				r.queued = err
+				return rec, nil
+			}
+			return Record{}, err
+		}
+		r.eof = err != nil
+		if text == "" {
+			continue
+		}
+		r.line++
+		text = strings.TrimRight(text, "\r\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		rec, console, err := r.parse(text)
+		if err == nil {
+			prev, ok := r.Flush()
+			r.pending, r.console = &rec, console
+			if ok {
+				return prev, nil
+			}
+			continue
+		}
+		if errors.Is(err, ErrNotEntry) && console && r.pending != nil && r.console {
+			if r.pending.Stack != "" {
+				r.pending.Stack += "\n"
+			}
+			r.pending.Stack += text
+			continue
+		}
+
+		lineErr := &LineError{Line: r.line, Text: text, Err: err}
+		if prev, ok := r.Flush(); ok {
+			r.queued = lineErr
+			return prev, nil
+		}
+		return Record{}, lineErr
+	}
+}
+
+// Flush returns the record waiting for continuation lines, if any, without
+// waiting for the rest of the input.
+func (r *Reader) Flush() (Record, bool) {
+	if r.pending == nil {
+		return Record{}, false
+	}
+	rec := *r.pending
+	r.pending = nil
+	return rec, true
+}
+
+// parse parses a line, reporting whether it was parsed as console output.
+func (r *Reader) parse(text string) (Record, bool, error) {
+	switch r.format {
+	case ConsoleFormat:
+		rec, err := r.p.ParseConsole(text)
+		return rec, true, err
+	case JSONFormat:
+		rec, err := r.p.ParseJSON([]byte(text))
+		return rec, false, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		rec, err := r.p.ParseJSON([]byte(text))
+		return rec, false, err
+	}
+	rec, err := r.p.ParseConsole(text)
+	return rec, true, err
+}
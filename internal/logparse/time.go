@@ -0,0 +1,137 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logparse
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+// TimeFormat describes how entry times are written. It's either the name of
+// one of ladcore's time encoders (epoch, millis, nanos, iso8601, rfc3339 or
+// rfc3339nano) or a layout for time.Format, as used with
+// ladcore.TimeEncoderOfLayout.
+type TimeFormat string
+
+// DefaultTimeFormat is the layout of the times written by ladglobal's
+// console and file loggers.
+const DefaultTimeFormat TimeFormat = "2006-01-02 15:04:05.000"
+
+const _iso8601Layout = "2006-01-02T15:04:05.000Z0700"
+
+// Encoder returns the ladcore.TimeEncoder that writes times in this format.
+func (f TimeFormat) Encoder() ladcore.TimeEncoder {
+	switch f {
+	case "epoch":
+		return ladcore.EpochTimeEncoder
+	case "millis":
+		return ladcore.EpochMillisTimeEncoder
+	case "nanos":
+		return ladcore.EpochNanosTimeEncoder
+	case "iso8601":
+		return ladcore.ISO8601TimeEncoder
+	case "rfc3339":
+		return ladcore.RFC3339TimeEncoder
+	case "rfc3339nano":
+		return ladcore.RFC3339NanoTimeEncoder
+	default:
+		return ladcore.TimeEncoderOfLayout(string(f))
+	}
+}
+
+// Parse parses a time written in this format. Times written by layouts
+// without a zone are interpreted in the local time zone, as that's the zone
+// the encoders format them in.
+func (f TimeFormat) Parse(s string) (time.Time, error) {
+	switch f {
+	case "epoch", "millis", "nanos":
+		return f.parseEpoch(s)
+	case "iso8601":
+		return time.ParseInLocation(_iso8601Layout, s, time.Local)
+	case "rfc3339":
+		return time.Parse(time.RFC3339, s)
+	case "rfc3339nano":
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		return time.ParseInLocation(string(f), s, time.Local)
+	}
+}
+
+// Format formats t as the encoder for this format would. Epoch times are
+// written as plain decimal numbers.
+func (f TimeFormat) Format(t time.Time) string {
+	switch f {
+	case "epoch":
+		return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', -1, 64)
+	case "millis":
+		return strconv.FormatFloat(float64(t.UnixNano())/1e6, 'f', -1, 64)
+	case "nanos":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	case "iso8601":
+		return t.Format(_iso8601Layout)
+	case "rfc3339":
+		return t.Format(time.RFC3339)
+	case "rfc3339nano":
+		return t.Format(time.RFC3339Nano)
+	default:
+		return t.Format(string(f))
+	}
+}
+
+// parseEpoch parses a number of seconds, milliseconds or nanoseconds since
+// the Unix epoch, depending on the format. Other formats use seconds.
+// Fractional times are rounded to microseconds, which is about as precise as
+// the floating-point numbers written by the epoch encoders are.
+func (f TimeFormat) parseEpoch(s string) (time.Time, error) {
+	if f == "nanos" {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(0, n), nil
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s time %q", f, s)
+	}
+	switch f {
+	case "nanos":
+		n /= 1e9
+	case "millis":
+		n /= 1e3
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e6))*1e3), nil
+}
+
+// separators returns how many times sep appears in times written in this
+// format.
+func (f TimeFormat) separators(sep string) int {
+	switch f {
+	case "epoch", "millis", "nanos", "iso8601", "rfc3339", "rfc3339nano":
+		return 0
+	}
+	ref := time.Date(2006, time.November, 12, 15, 4, 5, 123456789, time.UTC)
+	return strings.Count(ref.Format(string(f)), sep)
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+// Object is a JSON object read from a log. Its members are fields like the
+// ones in a Record, so logging it writes the same object again.
+type Object []ladcore.Field
+
+// MarshalLogObject implements ladcore.ObjectMarshaler.
+func (o Object) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	for _, f := range o {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// Array is a JSON array read from a log. Its elements are values as
+// returned by Value, except that compact objects and arrays read from
+// console output are kept as json.RawMessage.
+type Array []interface{}
+
+// MarshalLogArray implements ladcore.ArrayMarshaler.
+func (a Array) MarshalLogArray(enc ladcore.ArrayEncoder) error {
+	for _, v := range a {
+		var err error
+		switch v := v.(type) {
+		case string:
+			enc.AppendString(v)
+		case bool:
+			enc.AppendBool(v)
+		case Object:
+			err = enc.AppendObject(v)
+		case Array:
+			err = enc.AppendArray(v)
+		case nil:
+			err = enc.AppendReflected(nil)
+		default:
+			err = enc.AppendReflected(v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Value returns the value of a field read from a log: a string, bool,
+// json.Number, Object, Array, or nil for JSON null.
+func Value(f ladcore.Field) interface{} {
+	switch f.Type {
+	case ladcore.StringType:
+		return f.String
+	case ladcore.BoolType:
+		return f.Integer == 1
+	}
+	if raw, ok := f.Interface.(json.RawMessage); ok {
+		v, err := parseValue(raw, false)
+		if err != nil {
+			return nil
+		}
+		return v
+	}
+	return f.Interface
+}
+
+// isCompact reports whether raw has no whitespace outside of strings.
+func isCompact(raw json.RawMessage) bool {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return false
+	}
+	return buf.Len() == len(raw)
+}
+
+// Text returns the text of a value returned by Value: strings as they are,
+// and anything else as compact JSON.
+func Text(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	var b bytes.Buffer
+	appendJSON(&b, v)
+	return b.String()
+}
+
+func appendJSON(b *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case Object:
+		b.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			appendJSON(b, f.Key)
+			b.WriteByte(':')
+			appendJSON(b, Value(f))
+		}
+		b.WriteByte('}')
+	case Array:
+		b.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			appendJSON(b, elem)
+		}
+		b.WriteByte(']')
+	default:
+		enc := json.NewEncoder(b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			b.WriteString("null")
+			return
+		}
+		b.Truncate(b.Len() - 1) // drop Encode's newline
+	}
+}
+
+// newField creates a field holding a JSON value.
+//
+// The console encoder writes objects and arrays from marshalers with spaces
+// after commas and colons, but reflected values without. With keepCompact,
+// compact objects and arrays are kept as they are, so that they're written
+// the same way again.
+func newField(key string, raw json.RawMessage, keepCompact bool) (ladcore.Field, error) {
+	if keepCompact && len(raw) > 0 && (raw[0] == '{' || raw[0] == '[') && isCompact(raw) {
+		return ladcore.Field{Key: key, Type: ladcore.ReflectType, Interface: raw}, nil
+	}
+	v, err := parseValue(raw, keepCompact)
+	if err != nil {
+		return ladcore.Field{}, err
+	}
+	switch v := v.(type) {
+	case string:
+		return ladcore.Field{Key: key, Type: ladcore.StringType, String: v}, nil
+	case bool:
+		var n int64
+		if v {
+			n = 1
+		}
+		return ladcore.Field{Key: key, Type: ladcore.BoolType, Integer: n}, nil
+	case Object:
+		return ladcore.Field{Key: key, Type: ladcore.ObjectMarshalerType, Interface: v}, nil
+	case Array:
+		return ladcore.Field{Key: key, Type: ladcore.ArrayMarshalerType, Interface: v}, nil
+	default:
+		return ladcore.Field{Key: key, Type: ladcore.ReflectType, Interface: v}, nil
+	}
+}
+
+// parseValue parses a JSON value, keeping numbers as written and the
+// members of objects in order. See newField for keepCompact.
+func parseValue(raw json.RawMessage, keepCompact bool) (interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: empty JSON value", ErrNotEntry)
+	}
+	switch raw[0] {
+	case '{':
+		return parseObject(raw, keepCompact)
+	case '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return nil, err
+		}
+		arr := make(Array, 0, len(elems))
+		for _, elem := range elems {
+			if keepCompact && len(elem) > 0 && (elem[0] == '{' || elem[0] == '[') && isCompact(elem) {
+				arr = append(arr, elem)
+				continue
+			}
+			v, err := parseValue(elem, keepCompact)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
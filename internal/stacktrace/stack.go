@@ -23,7 +23,9 @@
 package stacktrace
 
 import (
+	"bytes"
 	"runtime"
+	"strconv"
 
 	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/internal/bufferpool"
@@ -122,6 +124,12 @@ func (st *Stack) Count() int {
 	return len(st.pcs)
 }
 
+// PCs returns the program counters of the frames in this stacktrace.
+// The returned slice is only valid until Free is called.
+func (st *Stack) PCs() []uintptr {
+	return st.pcs
+}
+
 // Next returns the next frame in the stack trace,
 // and a boolean indicating whether there are more after it.
 func (st *Stack) Next() (_ runtime.Frame, more bool) {
@@ -179,3 +187,33 @@ func (sf *Formatter) FormatFrame(frame runtime.Frame) {
 	sf.b.AppendByte(':')
 	sf.b.AppendInt(int64(frame.Line))
 }
+
+// GoroutineID returns the ID of the calling goroutine as reported in the
+// header of runtime.Stack ("goroutine 18 [running]:"), or zero if it can't
+// be determined.
+func GoroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// TakeAll returns the stack traces of all goroutines, in the format used by
+// runtime.Stack.
+func TakeAll() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true /* all */)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
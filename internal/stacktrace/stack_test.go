@@ -22,6 +22,8 @@ package stacktrace
 
 import (
 	"bytes"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -41,6 +43,45 @@ func TestTake(t *testing.T) {
 	)
 }
 
+func TestCapturePCs(t *testing.T) {
+	stack := Capture(0, Full)
+	defer stack.Free()
+
+	pcs := stack.PCs()
+	require.Len(t, pcs, stack.Count(), "Expected a program counter per frame.")
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	assert.True(t,
+		strings.HasSuffix(frame.Function, "/internal/stacktrace.TestCapturePCs"),
+		"Expected the first program counter to identify the test, got %q.", frame.Function,
+	)
+}
+
+func TestGoroutineID(t *testing.T) {
+	var buf [64]byte
+	header := string(buf[:runtime.Stack(buf[:], false)])
+	id := GoroutineID()
+	assert.Positive(t, id, "Expected a goroutine ID.")
+	assert.True(t,
+		strings.HasPrefix(header, "goroutine "+strconv.FormatInt(id, 10)+" "),
+		"Expected ID %d to match the runtime.Stack header %q.", id, header,
+	)
+
+	other := make(chan int64)
+	go func() { other <- GoroutineID() }()
+	assert.NotEqual(t, id, <-other, "Expected distinct goroutines to have distinct IDs.")
+}
+
+func TestTakeAll(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	go func() { <-block }()
+
+	dump := TakeAll()
+	assert.True(t, strings.HasPrefix(dump, "goroutine "), "Expected runtime.Stack format.")
+	assert.Contains(t, dump, "stacktrace.TestTakeAll(", "Expected the calling goroutine.")
+	assert.Contains(t, dump, "stacktrace.TestTakeAll.func1(", "Expected other goroutines.")
+}
+
 func TestTakeWithSkip(t *testing.T) {
 	trace := Take(1)
 	lines := strings.Split(trace, "\n")
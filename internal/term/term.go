@@ -0,0 +1,48 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package term queries the terminals that logs are written to.
+package term
+
+import "io"
+
+// fder is implemented by *os.File.
+type fder interface {
+	Fd() uintptr
+}
+
+// IsTerminal reports whether w is a file attached to a terminal. Unlike
+// checking for a character device, this rules out files such as /dev/null.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(fder)
+	return ok && isTerminal(f.Fd())
+}
+
+// Width returns the width, in columns, of the terminal w writes to. It
+// reports false if w isn't a file, isn't a terminal, or its size can't be
+// determined on this platform.
+func Width(w io.Writer) (int, bool) {
+	f, ok := w.(fder)
+	if !ok {
+		return 0, false
+	}
+	cols, ok := width(f.Fd())
+	return cols, ok && cols > 0
+}
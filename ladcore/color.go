@@ -0,0 +1,278 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladcore
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tnngo/lad/internal/color"
+	"github.com/tnngo/lad/internal/term"
+)
+
+// ColorTheme assigns ANSI colors to the levels, field keys, logger names and
+// callers written by the console and pretty encoders. In configuration
+// files, each color is a name (black, red, green, yellow, blue, magenta,
+// cyan, white or none) or an ANSI SGR code such as 92.
+type ColorTheme = color.Theme
+
+// DefaultColorTheme returns the theme used when EncoderConfig.ColorTheme is
+// nil.
+func DefaultColorTheme() ColorTheme {
+	return color.DefaultTheme()
+}
+
+// ColorMode selects when the console and pretty encoders color their output.
+//
+// Cores built with NewCore tell the encoder whether its WriteSyncer is a
+// terminal. Files, pipes and buffers aren't, nor is anything lad can't see
+// through, such as an io.MultiWriter. A non-empty NO_COLOR environment
+// variable overrides that detection and disables colors, and FORCE_COLOR set
+// to anything but "0" enables them.
+type ColorMode uint8
+
+const (
+	// DefaultColorMode leaves coloring to the EncodeLevel function: the
+	// console encoder adds no colors of its own, and the pretty encoder
+	// behaves as in AutoColorMode. The escapes written by color level
+	// encoders such as CapitalColorLevelEncoder are stripped when the output
+	// doesn't support colors.
+	DefaultColorMode ColorMode = iota
+	// AutoColorMode colors levels, keys, logger names and callers with the
+	// ColorTheme when the output supports colors, and strips all colors
+	// otherwise. Encoders not used through NewCore can't tell, and color.
+	AutoColorMode
+	// AlwaysColorMode colors output with the ColorTheme unconditionally.
+	AlwaysColorMode
+	// NeverColorMode never colors output, stripping the escapes written by
+	// color level encoders.
+	NeverColorMode
+)
+
+// String returns the name used for the mode in configuration files.
+func (m ColorMode) String() string {
+	switch m {
+	case DefaultColorMode:
+		return "default"
+	case AutoColorMode:
+		return "auto"
+	case AlwaysColorMode:
+		return "always"
+	case NeverColorMode:
+		return "never"
+	default:
+		return fmt.Sprintf("ColorMode(%d)", uint8(m))
+	}
+}
+
+// MarshalText marshals the ColorMode to text.
+func (m ColorMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText unmarshals text to a ColorMode. "auto", "always" and
+// "never" are unmarshaled to the matching modes, and "default" or an empty
+// string to DefaultColorMode.
+func (m *ColorMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "default", "":
+		*m = DefaultColorMode
+	case "auto":
+		*m = AutoColorMode
+	case "always":
+		*m = AlwaysColorMode
+	case "never":
+		*m = NeverColorMode
+	default:
+		return fmt.Errorf("unrecognized color mode: %q", text)
+	}
+	return nil
+}
+
+// colorEncoder is implemented by encoders whose colors depend on whether
+// their output supports them. NewCore uses it to pass that on.
+type colorEncoder interface {
+	withColorSupport(supported bool) Encoder
+}
+
+// colorSupported reports whether ws should receive colored output.
+func colorSupported(ws WriteSyncer) bool {
+	return color.Enabled(writesToTerminal(ws))
+}
+
+// outputs returns the writers that w ends up writing to, looking through
+// the WriteSyncers built by this package. Other wrappers can expose the
+// writer they wrap with an Unwrap() io.Writer method.
+func outputs(w io.Writer) []io.Writer {
+	switch w := w.(type) {
+	case *lockedWriteSyncer:
+		return outputs(w.ws)
+	case writerWrapper:
+		return outputs(w.Writer)
+	case *BufferedWriteSyncer:
+		return outputs(w.WS)
+	case multiWriteSyncer:
+		var out []io.Writer
+		for _, ws := range w {
+			out = append(out, outputs(ws)...)
+		}
+		return out
+	case interface{ Unwrap() io.Writer }:
+		return outputs(w.Unwrap())
+	}
+	return []io.Writer{w}
+}
+
+// _isTerminal reports whether a single writer is a terminal. Tests replace it
+// to treat in-memory writers as terminals.
+var _isTerminal = term.IsTerminal
+
+// writesToTerminal reports whether w is a terminal. Writers duplicating
+// their output are terminals only if all of their destinations are.
+func writesToTerminal(w io.Writer) bool {
+	outs := outputs(w)
+	for _, out := range outs {
+		if !_isTerminal(out) {
+			return false
+		}
+	}
+	return len(outs) > 0
+}
+
+// widthEncoder is implemented by encoders that fit their output to the
+// width of a terminal. NewCore uses it to pass that width on.
+type widthEncoder interface {
+	withTerminalWidth(cols int) Encoder
+}
+
+// terminalWidth returns the width of the narrowest terminal w writes to.
+// It reports false unless all of w's destinations are terminals.
+func terminalWidth(w io.Writer) (int, bool) {
+	outs := outputs(w)
+	cols := 0
+	for _, out := range outs {
+		n, ok := term.Width(out)
+		if !ok {
+			return 0, false
+		}
+		if cols == 0 || n < cols {
+			cols = n
+		}
+	}
+	return cols, len(outs) > 0
+}
+
+// colorizer applies an EncoderConfig's color settings.
+type colorizer struct {
+	mode  ColorMode
+	theme ColorTheme
+	// themedByDefault makes DefaultColorMode behave like AutoColorMode.
+	themedByDefault bool
+	// support is 1 or -1 once NewCore has reported whether the output
+	// supports colors, and 0 if it's unknown.
+	support int8
+}
+
+func newColorizer(cfg *EncoderConfig, themedByDefault bool) colorizer {
+	c := colorizer{
+		mode:            cfg.ColorMode,
+		theme:           DefaultColorTheme(),
+		themedByDefault: themedByDefault,
+	}
+	if cfg.ColorTheme != nil {
+		c.theme = *cfg.ColorTheme
+	}
+	return c
+}
+
+func (c colorizer) withSupport(supported bool) colorizer {
+	c.support = -1
+	if supported {
+		c.support = 1
+	}
+	return c
+}
+
+// themed reports whether output is colored with the theme.
+func (c colorizer) themed() bool {
+	switch c.mode {
+	case AlwaysColorMode:
+		return true
+	case NeverColorMode:
+		return false
+	case AutoColorMode:
+		return c.support >= 0
+	default:
+		return c.themedByDefault && c.support >= 0
+	}
+}
+
+// strips reports whether colors written by EncodeLevel are removed.
+func (c colorizer) strips() bool {
+	switch c.mode {
+	case AlwaysColorMode:
+		return false
+	case NeverColorMode:
+		return true
+	default:
+		return c.support < 0
+	}
+}
+
+// level colors the text written for l by EncodeLevel.
+func (c colorizer) level(l Level, text string) string {
+	if c.themed() {
+		return c.levelColor(l).Add(color.Strip(text))
+	}
+	if c.strips() {
+		return color.Strip(text)
+	}
+	return text
+}
+
+func (c colorizer) levelColor(l Level) color.Color {
+	switch l {
+	case DebugLevel:
+		return c.theme.Debug
+	case InfoLevel:
+		return c.theme.Info
+	case WarnLevel:
+		return c.theme.Warn
+	case ErrorLevel:
+		return c.theme.Error
+	case DPanicLevel:
+		return c.theme.DPanic
+	case PanicLevel:
+		return c.theme.Panic
+	case FatalLevel:
+		return c.theme.Fatal
+	default:
+		return color.Red
+	}
+}
+
+// paint colors s with col if output is themed.
+func (c colorizer) paint(col color.Color, s string) string {
+	if s == "" || !c.themed() {
+		return s
+	}
+	return col.Add(s)
+}
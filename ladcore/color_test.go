@@ -0,0 +1,233 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladcore
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorModeText(t *testing.T) {
+	for _, mode := range []ColorMode{DefaultColorMode, AutoColorMode, AlwaysColorMode, NeverColorMode} {
+		text, err := mode.MarshalText()
+		require.NoError(t, err, "Unexpected error marshaling %v.", mode)
+
+		var got ColorMode
+		require.NoError(t, got.UnmarshalText(text), "Unexpected error unmarshaling %q.", text)
+		assert.Equal(t, mode, got, "Expected %q to round-trip.", text)
+	}
+
+	var mode ColorMode
+	require.NoError(t, mode.UnmarshalText(nil), "Unexpected error unmarshaling an empty string.")
+	assert.Equal(t, DefaultColorMode, mode, "Expected an empty string to select the default mode.")
+	assert.Error(t, mode.UnmarshalText([]byte("sometimes")), "Expected an error for an unknown mode.")
+}
+
+// fakeTerminal is a WriteSyncer that stubTerminals treats as a terminal.
+type fakeTerminal struct{ bytes.Buffer }
+
+func (*fakeTerminal) Sync() error { return nil }
+
+// stubTerminals makes writesToTerminal report fakeTerminals as terminals for
+// the duration of the test.
+func stubTerminals(t *testing.T) {
+	prev := _isTerminal
+	_isTerminal = func(w io.Writer) bool {
+		_, ok := w.(*fakeTerminal)
+		return ok
+	}
+	t.Cleanup(func() { _isTerminal = prev })
+}
+
+// unwrappingWriter hides the writer it wraps behind Unwrap.
+type unwrappingWriter struct{ io.Writer }
+
+func (w unwrappingWriter) Unwrap() io.Writer { return w.Writer }
+func (unwrappingWriter) Sync() error         { return nil }
+
+func TestWritesToTerminal(t *testing.T) {
+	stubTerminals(t)
+	term := &fakeTerminal{}
+	tests := []struct {
+		desc string
+		ws   WriteSyncer
+		want bool
+	}{
+		{"terminal", term, true},
+		{"buffer", AddSync(&bytes.Buffer{}), false},
+		{"locked", Lock(term), true},
+		{"wrapped writer", AddSync(struct{ io.Writer }{term}), false},
+		{"buffered", &BufferedWriteSyncer{WS: term}, true},
+		{"all terminals", NewMultiWriteSyncer(term, Lock(term)), true},
+		{"some terminals", NewMultiWriteSyncer(term, AddSync(&bytes.Buffer{})), false},
+		{"Unwrap", unwrappingWriter{term}, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, writesToTerminal(tt.ws), "%s: unexpected result.", tt.desc)
+	}
+}
+
+func TestConsoleEncoderColors(t *testing.T) {
+	stubTerminals(t)
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	theme := DefaultColorTheme()
+	theme.Info = 92
+	theme.Name = 0
+
+	tests := []struct {
+		desc     string
+		mode     ColorMode
+		terminal bool
+		want     string
+	}{
+		{
+			desc: "default mode on a file strips level colors",
+			mode: DefaultColorMode,
+			want: "INFO\tdb\tfile.go:1\tmsg\n",
+		},
+		{
+			desc:     "default mode on a terminal keeps level colors",
+			mode:     DefaultColorMode,
+			terminal: true,
+			want:     "\x1b[34mINFO\x1b[0m\tdb\tfile.go:1\tmsg\n",
+		},
+		{
+			desc: "auto mode on a file",
+			mode: AutoColorMode,
+			want: "INFO\tdb\tfile.go:1\tmsg\n",
+		},
+		{
+			desc:     "auto mode on a terminal uses the theme",
+			mode:     AutoColorMode,
+			terminal: true,
+			want:     "\x1b[92mINFO\x1b[0m\tdb\t\x1b[36mfile.go:1\x1b[0m\tmsg\n",
+		},
+		{
+			desc: "always",
+			mode: AlwaysColorMode,
+			want: "\x1b[92mINFO\x1b[0m\tdb\t\x1b[36mfile.go:1\x1b[0m\tmsg\n",
+		},
+		{
+			desc:     "never",
+			mode:     NeverColorMode,
+			terminal: true,
+			want:     "INFO\tdb\tfile.go:1\tmsg\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := NewConsoleEncoder(EncoderConfig{
+				MessageKey:   "M",
+				LevelKey:     "L",
+				NameKey:      "N",
+				CallerKey:    "C",
+				EncodeLevel:  CapitalColorLevelEncoder,
+				EncodeCaller: ShortCallerEncoder,
+				ColorMode:    tt.mode,
+				ColorTheme:   &theme,
+			})
+
+			var out interface {
+				WriteSyncer
+				String() string
+			} = &fakeTerminal{}
+			if !tt.terminal {
+				out = &bufferWriteSyncer{}
+			}
+			core := NewCore(enc, out, DebugLevel).With([]Field{})
+			require.NoError(t, core.Write(Entry{
+				Level:      InfoLevel,
+				LoggerName: "db",
+				Message:    "msg",
+				Caller:     EntryCaller{Defined: true, File: "file.go", Line: 1},
+			}, nil), "Unexpected error writing entry.")
+			assert.Equal(t, tt.want, out.String(), "Unexpected output.")
+		})
+	}
+}
+
+func TestColorEnvironmentOverrides(t *testing.T) {
+	stubTerminals(t)
+	enc := NewConsoleEncoder(EncoderConfig{
+		MessageKey:  "M",
+		LevelKey:    "L",
+		EncodeLevel: CapitalColorLevelEncoder,
+		ColorMode:   AutoColorMode,
+	})
+
+	t.Run("FORCE_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "")
+		t.Setenv("FORCE_COLOR", "1")
+		out := &bufferWriteSyncer{}
+		require.NoError(t, NewCore(enc, out, DebugLevel).Write(Entry{Level: WarnLevel, Message: "msg"}, nil),
+			"Unexpected error writing entry.")
+		assert.Equal(t, "\x1b[33mWARN\x1b[0m\tmsg\n", out.String(), "Expected colors on a file.")
+	})
+
+	t.Run("NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		out := &fakeTerminal{}
+		require.NoError(t, NewCore(enc, out, DebugLevel).Write(Entry{Level: WarnLevel, Message: "msg"}, nil),
+			"Unexpected error writing entry.")
+		assert.Equal(t, "WARN\tmsg\n", out.String(), "Expected no colors on a terminal.")
+	})
+}
+
+func TestPrettyEncoderColorSupport(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+
+	enc := NewPrettyEncoder(EncoderConfig{MessageKey: "M", LevelKey: "L", EncodeLevel: CapitalLevelEncoder}, PrettyWidth(0))
+	out := &bufferWriteSyncer{}
+	require.NoError(t, NewCore(enc, out, DebugLevel).Write(Entry{Level: InfoLevel, Message: "msg"}, []Field{
+		{Key: "k", Type: StringType, String: "v"},
+	}), "Unexpected error writing entry.")
+	assert.Equal(t, "INFO    msg  k=v\n", out.String(), "Expected no colors when writing to a buffer.")
+}
+
+func TestPrettyEncoderTerminalWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	cfg := EncoderConfig{MessageKey: "M"}
+
+	enc := NewPrettyEncoder(cfg).(*prettyEncoder)
+	assert.Zero(t, enc.width, "Expected no wrapping without a terminal or COLUMNS.")
+	assert.Equal(t, 40, enc.withTerminalWidth(40).(*prettyEncoder).width, "Expected the terminal's width.")
+
+	fixed := NewPrettyEncoder(cfg, PrettyWidth(0)).(*prettyEncoder)
+	assert.Zero(t, fixed.withTerminalWidth(40).(*prettyEncoder).width, "Expected PrettyWidth to take precedence.")
+
+	t.Setenv("COLUMNS", "100")
+	assert.Equal(t, 100, NewPrettyEncoder(cfg).(*prettyEncoder).width, "Expected COLUMNS to be used.")
+
+	_, ok := terminalWidth(Lock(&bufferWriteSyncer{}))
+	assert.False(t, ok, "Expected buffers not to have a terminal width.")
+}
+
+type bufferWriteSyncer struct{ bytes.Buffer }
+
+func (*bufferWriteSyncer) Sync() error { return nil }
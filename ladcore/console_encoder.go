@@ -23,6 +23,7 @@ package ladcore
 import (
 	"fmt"
 
+	"github.com/tnngo/lad/internal/color"
 	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/internal/bufferpool"
 	"go.uber.org/zap/internal/pool"
@@ -45,6 +46,8 @@ func putSliceEncoder(e *sliceArrayEncoder) {
 
 type consoleEncoder struct {
 	*jsonEncoder
+
+	colors colorizer
 }
 
 // NewConsoleEncoder creates an encoder whose output is designed for human -
@@ -60,11 +63,22 @@ func NewConsoleEncoder(cfg EncoderConfig) Encoder {
 		// Use a default delimiter of '\t' for backwards compatibility
 		cfg.ConsoleSeparator = "\t"
 	}
-	return consoleEncoder{newJSONEncoder(cfg, true)}
+	return consoleEncoder{
+		jsonEncoder: newJSONEncoder(cfg, true),
+		colors:      newColorizer(&cfg, false),
+	}
 }
 
 func (c consoleEncoder) Clone() Encoder {
-	return consoleEncoder{c.jsonEncoder.Clone().(*jsonEncoder)}
+	return consoleEncoder{
+		jsonEncoder: c.jsonEncoder.Clone().(*jsonEncoder),
+		colors:      c.colors,
+	}
+}
+
+func (c consoleEncoder) withColorSupport(supported bool) Encoder {
+	c.colors = c.colors.withSupport(supported)
+	return c
 }
 
 func (c consoleEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
@@ -81,7 +95,13 @@ func (c consoleEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 		c.EncodeTime(ent.Time, arr)
 	}
 	if c.LevelKey != "" && c.EncodeLevel != nil {
+		start := len(arr.elems)
 		c.EncodeLevel(ent.Level, arr)
+		if c.colors.themed() || c.colors.strips() {
+			for i := start; i < len(arr.elems); i++ {
+				arr.elems[i] = c.colors.level(ent.Level, fmt.Sprint(arr.elems[i]))
+			}
+		}
 	}
 	if ent.LoggerName != "" && c.NameKey != "" {
 		nameEncoder := c.EncodeName
@@ -91,11 +111,15 @@ func (c consoleEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 			nameEncoder = FullNameEncoder
 		}
 
+		start := len(arr.elems)
 		nameEncoder(ent.LoggerName, arr)
+		c.paintElems(arr, start, c.colors.theme.Name)
 	}
 	if ent.Caller.Defined {
 		if c.CallerKey != "" && c.EncodeCaller != nil {
+			start := len(arr.elems)
 			c.EncodeCaller(ent.Caller, arr)
+			c.paintElems(arr, start, c.colors.theme.Caller)
 		}
 		if c.FunctionKey != "" {
 			arr.AppendString(ent.Caller.Function)
@@ -140,6 +164,9 @@ func (c consoleEncoder) writeContext(line *buffer.Buffer, extra []Field) {
 
 	addFields(context, extra)
 	context.closeOpenNamespaces()
+	if context.tidiesFields() {
+		context.tidyFields(0, func(int) bool { return true })
+	}
 	if context.buf.Len() == 0 {
 		return
 	}
@@ -150,6 +177,16 @@ func (c consoleEncoder) writeContext(line *buffer.Buffer, extra []Field) {
 	line.AppendByte('}')
 }
 
+// paintElems colors the elements appended to arr since start.
+func (c consoleEncoder) paintElems(arr *sliceArrayEncoder, start int, col color.Color) {
+	if !c.colors.themed() {
+		return
+	}
+	for i := start; i < len(arr.elems); i++ {
+		arr.elems[i] = c.colors.paint(col, fmt.Sprint(arr.elems[i]))
+	}
+}
+
 func (c consoleEncoder) addSeparatorIfNecessary(line *buffer.Buffer) {
 	if line.Len() > 0 {
 		line.AppendString(c.ConsoleSeparator)
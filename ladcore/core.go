@@ -54,8 +54,19 @@ func (nopCore) Check(_ Entry, ce *CheckedEntry) *CheckedEntry { return ce }
 func (nopCore) Write(Entry, []Field) error                    { return nil }
 func (nopCore) Sync() error                                   { return nil }
 
-// NewCore creates a Core that writes logs to a WriteSyncer.
+// NewCore creates a Core that writes logs to a WriteSyncer. Encoders that
+// color their output, like the console encoder, learn whether ws supports
+// colors (see ColorMode), and the pretty encoder learns the width of the
+// terminal ws writes to.
 func NewCore(enc Encoder, ws WriteSyncer, enab LevelEnabler) Core {
+	if ce, ok := enc.(colorEncoder); ok {
+		enc = ce.withColorSupport(colorSupported(ws))
+	}
+	if we, ok := enc.(widthEncoder); ok {
+		if cols, ok := terminalWidth(ws); ok {
+			enc = we.withTerminalWidth(cols)
+		}
+	}
 	return &ioCore{
 		LevelEnabler: enab,
 		enc:          enc,
@@ -22,6 +22,7 @@ package ladcore
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"time"
 
@@ -92,6 +93,157 @@ func (e *LevelEncoder) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// ErrorFormat selects how encoders render error fields.
+type ErrorFormat uint8
+
+const (
+	// LegacyErrorFormat renders the error message along with the
+	// ${key}Verbose and ${key}Causes fields.
+	LegacyErrorFormat ErrorFormat = iota
+	// ChainErrorFormat renders the error message along with a ${key}Chain
+	// array describing every error reachable through Unwrap (or Cause). Each
+	// link records the error's type and message, plus a "fields" object if
+	// it implements ObjectMarshaler. Errors wrapping several errors add a
+	// "causes" array with one chain per wrapped error. Cycles are cut short
+	// and at most 32 links are rendered per error.
+	ChainErrorFormat
+)
+
+// String returns the name used for the format in configuration files.
+func (f ErrorFormat) String() string {
+	switch f {
+	case LegacyErrorFormat:
+		return "legacy"
+	case ChainErrorFormat:
+		return "chain"
+	default:
+		return fmt.Sprintf("ErrorFormat(%d)", uint8(f))
+	}
+}
+
+// MarshalText marshals the ErrorFormat to text.
+func (f ErrorFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText unmarshals text to an ErrorFormat. "chain" is unmarshaled to
+// ChainErrorFormat, and "legacy" or an empty string to LegacyErrorFormat.
+func (f *ErrorFormat) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "legacy", "":
+		*f = LegacyErrorFormat
+	case "chain":
+		*f = ChainErrorFormat
+	default:
+		return fmt.Errorf("unrecognized error format: %q", text)
+	}
+	return nil
+}
+
+// StacktraceFormat selects how the JSON encoder renders an entry's stack
+// trace.
+type StacktraceFormat uint8
+
+const (
+	// StringStacktraceFormat renders the stack trace as a single string
+	// with a function line and an indented file:line line per frame.
+	StringStacktraceFormat StacktraceFormat = iota
+	// FramesStacktraceFormat renders the stack trace as an object holding a
+	// "frames" array of {function, file, line} objects, along with the
+	// "goroutine" ID if the Logger recorded one (see lad.StacktraceGoroutine).
+	FramesStacktraceFormat
+)
+
+// String returns the name used for the format in configuration files.
+func (f StacktraceFormat) String() string {
+	switch f {
+	case StringStacktraceFormat:
+		return "string"
+	case FramesStacktraceFormat:
+		return "frames"
+	default:
+		return fmt.Sprintf("StacktraceFormat(%d)", uint8(f))
+	}
+}
+
+// MarshalText marshals the StacktraceFormat to text.
+func (f StacktraceFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText unmarshals text to a StacktraceFormat. "frames" is
+// unmarshaled to FramesStacktraceFormat, and "string" or an empty string to
+// StringStacktraceFormat.
+func (f *StacktraceFormat) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "string", "":
+		*f = StringStacktraceFormat
+	case "frames":
+		*f = FramesStacktraceFormat
+	default:
+		return fmt.Errorf("unrecognized stacktrace format: %q", text)
+	}
+	return nil
+}
+
+// DuplicateKeys selects how the JSON encoder handles fields sharing a key,
+// including fields added with With. The keys the encoder writes for the
+// entry itself, such as the message key, always take precedence over fields;
+// duplicates are looked for in nested objects too.
+type DuplicateKeys uint8
+
+const (
+	// KeepAllDuplicateKeys writes every field, even if that repeats keys.
+	KeepAllDuplicateKeys DuplicateKeys = iota
+	// LastWinsDuplicateKeys drops all but the last field with a key.
+	LastWinsDuplicateKeys
+	// FirstWinsDuplicateKeys drops all but the first field with a key.
+	FirstWinsDuplicateKeys
+	// SuffixRenameDuplicateKeys renames repeated keys by appending "_1",
+	// "_2" and so on, until the key is unique.
+	SuffixRenameDuplicateKeys
+)
+
+// String returns the name used for the policy in configuration files.
+func (d DuplicateKeys) String() string {
+	switch d {
+	case KeepAllDuplicateKeys:
+		return "keep-all"
+	case LastWinsDuplicateKeys:
+		return "last-wins"
+	case FirstWinsDuplicateKeys:
+		return "first-wins"
+	case SuffixRenameDuplicateKeys:
+		return "suffix-rename"
+	default:
+		return fmt.Sprintf("DuplicateKeys(%d)", uint8(d))
+	}
+}
+
+// MarshalText marshals the DuplicateKeys policy to text.
+func (d DuplicateKeys) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText unmarshals text to a DuplicateKeys policy. "last-wins",
+// "first-wins" and "suffix-rename" are unmarshaled to the matching
+// policies, and "keep-all" or an empty string to KeepAllDuplicateKeys.
+func (d *DuplicateKeys) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "keep-all", "":
+		*d = KeepAllDuplicateKeys
+	case "last-wins":
+		*d = LastWinsDuplicateKeys
+	case "first-wins":
+		*d = FirstWinsDuplicateKeys
+	case "suffix-rename":
+		*d = SuffixRenameDuplicateKeys
+	default:
+		return fmt.Errorf("unrecognized duplicate key policy: %q", text)
+	}
+	return nil
+}
+
 // A TimeEncoder serializes a time.Time to a primitive type.
 //
 // This function must make exactly one call
@@ -331,15 +483,18 @@ func (e *NameEncoder) UnmarshalText(text []byte) error {
 type EncoderConfig struct {
 	// Set the keys used for each log entry. If any key is empty, that portion
 	// of the entry is omitted.
-	MessageKey     string `json:"messageKey" yaml:"messageKey"`
-	LevelKey       string `json:"levelKey" yaml:"levelKey"`
-	TimeKey        string `json:"timeKey" yaml:"timeKey"`
-	NameKey        string `json:"nameKey" yaml:"nameKey"`
-	CallerKey      string `json:"callerKey" yaml:"callerKey"`
-	FunctionKey    string `json:"functionKey" yaml:"functionKey"`
-	StacktraceKey  string `json:"stacktraceKey" yaml:"stacktraceKey"`
-	SkipLineEnding bool   `json:"skipLineEnding" yaml:"skipLineEnding"`
-	LineEnding     string `json:"lineEnding" yaml:"lineEnding"`
+	MessageKey    string `json:"messageKey" yaml:"messageKey"`
+	LevelKey      string `json:"levelKey" yaml:"levelKey"`
+	TimeKey       string `json:"timeKey" yaml:"timeKey"`
+	NameKey       string `json:"nameKey" yaml:"nameKey"`
+	CallerKey     string `json:"callerKey" yaml:"callerKey"`
+	FunctionKey   string `json:"functionKey" yaml:"functionKey"`
+	StacktraceKey string `json:"stacktraceKey" yaml:"stacktraceKey"`
+	// Configures how the JSON encoder renders an entry's stack trace. The
+	// zero value keeps the formatted string.
+	StacktraceFormat StacktraceFormat `json:"stacktraceFormat" yaml:"stacktraceFormat"`
+	SkipLineEnding   bool             `json:"skipLineEnding" yaml:"skipLineEnding"`
+	LineEnding       string           `json:"lineEnding" yaml:"lineEnding"`
 	// Configure the primitive representations of common complex types. For
 	// example, some users may want all time.Times serialized as floating-point
 	// seconds since epoch, while others may prefer ISO8601 strings.
@@ -356,6 +511,25 @@ type EncoderConfig struct {
 	// Configures the field separator used by the console encoder. Defaults
 	// to tab.
 	ConsoleSeparator string `json:"consoleSeparator" yaml:"consoleSeparator"`
+	// Configures when the console and pretty encoders color their output,
+	// and with which colors. A nil ColorTheme uses DefaultColorTheme.
+	ColorMode  ColorMode   `json:"colorMode" yaml:"colorMode"`
+	ColorTheme *ColorTheme `json:"colorTheme" yaml:"colorTheme"`
+	// Configures how error fields are rendered. The zero value keeps the
+	// legacy ${key}Verbose and ${key}Causes fields.
+	ErrorFormat ErrorFormat `json:"errorFormat" yaml:"errorFormat"`
+	// ErrorStackSuffix is appended to an error field's key to name the field
+	// holding the stack captured when the error was created (for example,
+	// "Stack" yields "errorStack"). If empty, the default, those stacks are
+	// omitted. Errors from github.com/pkg/errors already include their stack
+	// in ${key}Verbose, so they're best logged with ChainErrorFormat when
+	// this is set.
+	ErrorStackSuffix string `json:"errorStackSuffix" yaml:"errorStackSuffix"`
+	// Configures how the JSON encoder, and the console encoder's context,
+	// handle fields with the same key, and whether fields are sorted by key.
+	// Sorting keeps the entry's own keys first and the stack trace last.
+	DuplicateKeys DuplicateKeys `json:"duplicateKeys" yaml:"duplicateKeys"`
+	SortKeys      bool          `json:"sortKeys" yaml:"sortKeys"`
 }
 
 // ObjectEncoder is a strongly-typed, encoding-agnostic interface for adding a
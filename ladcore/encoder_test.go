@@ -584,6 +584,89 @@ func TestLevelEncoders(t *testing.T) {
 	}
 }
 
+func TestErrorFormatText(t *testing.T) {
+	tests := []struct {
+		yamlDoc  string
+		expected ErrorFormat
+	}{
+		{"errorFormat: legacy", LegacyErrorFormat},
+		{"errorFormat: ''", LegacyErrorFormat},
+		{"errorFormat: chain", ChainErrorFormat},
+	}
+
+	for _, tt := range tests {
+		var cfg EncoderConfig
+		require.NoError(t, yaml.Unmarshal([]byte(tt.yamlDoc), &cfg), "Unexpected error unmarshaling %q.", tt.yamlDoc)
+		assert.Equal(t, tt.expected, cfg.ErrorFormat, "Unexpected format for %q.", tt.yamlDoc)
+
+		text, err := tt.expected.MarshalText()
+		require.NoError(t, err, "Unexpected error marshaling %v.", tt.expected)
+		var f ErrorFormat
+		require.NoError(t, f.UnmarshalText(text), "Unexpected error round-tripping %q.", text)
+		assert.Equal(t, tt.expected, f, "Unexpected round-tripped format.")
+	}
+
+	var f ErrorFormat
+	assert.ErrorContains(t, f.UnmarshalText([]byte("pretty")), `"pretty"`, "Expected unknown formats to fail.")
+	assert.Equal(t, "ErrorFormat(9)", ErrorFormat(9).String(), "Unexpected string for unknown format.")
+}
+
+func TestStacktraceFormatText(t *testing.T) {
+	tests := []struct {
+		yamlDoc  string
+		expected StacktraceFormat
+	}{
+		{"stacktraceFormat: string", StringStacktraceFormat},
+		{"stacktraceFormat: ''", StringStacktraceFormat},
+		{"stacktraceFormat: frames", FramesStacktraceFormat},
+	}
+
+	for _, tt := range tests {
+		var cfg EncoderConfig
+		require.NoError(t, yaml.Unmarshal([]byte(tt.yamlDoc), &cfg), "Unexpected error unmarshaling %q.", tt.yamlDoc)
+		assert.Equal(t, tt.expected, cfg.StacktraceFormat, "Unexpected format for %q.", tt.yamlDoc)
+
+		text, err := tt.expected.MarshalText()
+		require.NoError(t, err, "Unexpected error marshaling %v.", tt.expected)
+		var f StacktraceFormat
+		require.NoError(t, f.UnmarshalText(text), "Unexpected error round-tripping %q.", text)
+		assert.Equal(t, tt.expected, f, "Unexpected round-tripped format.")
+	}
+
+	var f StacktraceFormat
+	assert.ErrorContains(t, f.UnmarshalText([]byte("pretty")), `"pretty"`, "Expected unknown formats to fail.")
+	assert.Equal(t, "StacktraceFormat(9)", StacktraceFormat(9).String(), "Unexpected string for unknown format.")
+}
+
+func TestDuplicateKeysText(t *testing.T) {
+	tests := []struct {
+		yamlDoc  string
+		expected DuplicateKeys
+	}{
+		{"duplicateKeys: keep-all", KeepAllDuplicateKeys},
+		{"duplicateKeys: ''", KeepAllDuplicateKeys},
+		{"duplicateKeys: last-wins", LastWinsDuplicateKeys},
+		{"duplicateKeys: first-wins", FirstWinsDuplicateKeys},
+		{"duplicateKeys: suffix-rename", SuffixRenameDuplicateKeys},
+	}
+
+	for _, tt := range tests {
+		var cfg EncoderConfig
+		require.NoError(t, yaml.Unmarshal([]byte(tt.yamlDoc), &cfg), "Unexpected error unmarshaling %q.", tt.yamlDoc)
+		assert.Equal(t, tt.expected, cfg.DuplicateKeys, "Unexpected policy for %q.", tt.yamlDoc)
+
+		text, err := tt.expected.MarshalText()
+		require.NoError(t, err, "Unexpected error marshaling %v.", tt.expected)
+		var d DuplicateKeys
+		require.NoError(t, d.UnmarshalText(text), "Unexpected error round-tripping %q.", text)
+		assert.Equal(t, tt.expected, d, "Unexpected round-tripped policy.")
+	}
+
+	var d DuplicateKeys
+	assert.ErrorContains(t, d.UnmarshalText([]byte("merge")), `"merge"`, "Expected unknown policies to fail.")
+	assert.Equal(t, "DuplicateKeys(9)", DuplicateKeys(9).String(), "Unexpected string for unknown policy.")
+}
+
 func TestTimeEncoders(t *testing.T) {
 	moment := time.Unix(100, 50005000).UTC()
 	tests := []struct {
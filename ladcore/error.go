@@ -23,6 +23,7 @@ package ladcore
 import (
 	"fmt"
 	"reflect"
+	"sync"
 
 	"go.uber.org/zap/internal/pool"
 )
@@ -44,7 +45,20 @@ import (
 //	    ...
 //	  ],
 //	}
-func encodeError(key string, err error, enc ObjectEncoder) (retErr error) {
+//
+// Encoders configured with ChainErrorFormat replace the verbose and causes
+// fields with a ${key}Chain array. See ChainErrorFormat for details.
+//
+// Fields carried by the error or any error it wraps (see FieldCarrier) are
+// added alongside the message. If the encoder's ErrorStackSuffix is set, so
+// is the stack captured when the error was created (see StackCarrier).
+func encodeError(key string, err error, enc ObjectEncoder) error {
+	return encodeErrorValue(key, err, enc, true /* topLevel */)
+}
+
+// Errors nested in another error's causes are encoded with topLevel unset:
+// their fields and stacks were already added for the top-level error.
+func encodeErrorValue(key string, err error, enc ObjectEncoder, topLevel bool) (retErr error) {
 	// Try to capture panics (from nil references or otherwise) when calling
 	// the Error() method
 	defer func() {
@@ -64,6 +78,27 @@ func encodeError(key string, err error, enc ObjectEncoder) (retErr error) {
 	basic := err.Error()
 	enc.AddString(key, basic)
 
+	var cfg *EncoderConfig
+	if c, ok := enc.(errorEncoderConfig); ok {
+		cfg = c.errorConfig()
+	}
+
+	if topLevel {
+		addErrorFields(err, enc)
+
+		if cfg != nil && cfg.ErrorStackSuffix != "" {
+			if pcs := findErrorStack(err); len(pcs) > 0 {
+				if err := enc.AddArray(key+cfg.ErrorStackSuffix, stackFrames(pcs)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if cfg != nil && cfg.ErrorFormat == ChainErrorFormat {
+		return enc.AddArray(key+"Chain", errChain{w: &chainWalker{}, err: err})
+	}
+
 	switch e := err.(type) {
 	case errorGroup:
 		return enc.AddArray(key+"Causes", errArray(e.Errors()))
@@ -84,6 +119,334 @@ type errorGroup interface {
 	Errors() []error
 }
 
+// errorEncoderConfig is implemented by encoders whose EncoderConfig controls
+// how errors are rendered. Encoders that don't implement it use
+// LegacyErrorFormat and omit error stacks.
+type errorEncoderConfig interface {
+	errorConfig() *EncoderConfig
+}
+
+type causer interface {
+	Cause() error
+}
+
+// FieldCarrier is implemented by errors that carry structured fields. When
+// an error is logged, the fields of every FieldCarrier in its chain are added
+// to the log entry next to the error message.
+//
+// Layers are visited outermost first, following Unwrap and Cause methods and
+// the errors wrapped by multi-errors in order. If several layers carry fields
+// with the same key, the first one visited wins, so context added closer to
+// the log call takes precedence. Namespace fields are ignored.
+type FieldCarrier interface {
+	LogFields() []Field
+}
+
+// Adds the fields carried by err and the errors it wraps to enc, skipping
+// keys that were already added.
+func addErrorFields(err error, enc ObjectEncoder) {
+	var added []string // allocated only if some layer carries fields
+	walkErrors(err, func(err error) {
+		c, ok := err.(FieldCarrier)
+		if !ok {
+			return
+		}
+		for _, f := range c.LogFields() {
+			if f.Type == NamespaceType || containsString(added, f.Key) {
+				continue
+			}
+			added = append(added, f.Key)
+			f.AddTo(enc)
+		}
+	})
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StackCarrier is implemented by errors that record the stack where they
+// were created. LogStack returns program counters as reported by
+// runtime.Callers.
+//
+// Errors from github.com/pkg/errors, which have a StackTrace method
+// returning a slice of program counters and implement fmt.Formatter, are
+// recognized as well.
+//
+// When an error is logged, the stack recorded deepest in its chain, which is
+// closest to where the error originated, is added as an array of
+// {function, file, line} objects.
+type StackCarrier interface {
+	LogStack() []uintptr
+}
+
+// Returns the stack recorded deepest in err's chain, if any.
+func findErrorStack(err error) (pcs []uintptr) {
+	walkErrors(err, func(err error) {
+		if s := errorStack(err); len(s) > 0 {
+			pcs = s
+		}
+	})
+	return pcs
+}
+
+func errorStack(err error) []uintptr {
+	if c, ok := err.(StackCarrier); ok {
+		return c.LogStack()
+	}
+	// Errors from github.com/pkg/errors that carry a stack implement
+	// fmt.Formatter, so this spares other errors any reflection.
+	if _, ok := err.(fmt.Formatter); ok {
+		return pkgErrorsStack(err)
+	}
+	return nil
+}
+
+// _pkgErrorsStackMethods caches, for each error type, the index of its
+// StackTrace method, or -1 if it has none.
+var _pkgErrorsStackMethods sync.Map // map[reflect.Type]int
+
+// Calls the StackTrace method of errors from github.com/pkg/errors, which
+// returns a []Frame where Frame is a uintptr. Its signature can't be named
+// without depending on that package, so it's matched with reflection.
+func pkgErrorsStack(err error) []uintptr {
+	t := reflect.TypeOf(err)
+	idx, ok := _pkgErrorsStackMethods.Load(t)
+	if !ok {
+		idx = -1
+		if m, ok := t.MethodByName("StackTrace"); ok {
+			// The method's type includes the receiver.
+			if mt := m.Type; mt.NumIn() == 1 && mt.NumOut() == 1 &&
+				mt.Out(0).Kind() == reflect.Slice && mt.Out(0).Elem().Kind() == reflect.Uintptr {
+				idx = m.Index
+			}
+		}
+		_pkgErrorsStackMethods.Store(t, idx)
+	}
+	if idx.(int) < 0 {
+		return nil
+	}
+
+	frames := reflect.ValueOf(err).Method(idx.(int)).Call(nil)[0]
+	pcs := make([]uintptr, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs
+}
+
+// Calls fn for err and each error it wraps, depth-first and outermost
+// first, visiting at most _maxErrorChainLinks errors.
+func walkErrors(err error, fn func(error)) {
+	w := errWalker{fn: fn}
+	w.walk(err)
+}
+
+type errWalker struct {
+	visited int
+	fn      func(error)
+}
+
+func (w *errWalker) walk(err error) {
+	for err != nil && w.visited < _maxErrorChainLinks {
+		w.visited++
+		w.fn(err)
+
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			w.walkAll(e.Unwrap())
+			return
+		case errorGroup:
+			w.walkAll(e.Errors())
+			return
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case causer:
+			err = e.Cause()
+		default:
+			return
+		}
+	}
+}
+
+func (w *errWalker) walkAll(errs []error) {
+	for _, err := range errs {
+		w.walk(err)
+	}
+}
+
+// _maxErrorChainLinks bounds the number of links rendered or visited for a
+// single error so that pathological chains can't produce unbounded output.
+const _maxErrorChainLinks = 32
+
+// chainWalker holds the state shared by every branch of an error chain: the
+// number of links rendered so far and the errors on the path from the
+// outermost error to the link being rendered.
+type chainWalker struct {
+	links int
+	path  []error
+}
+
+// onPath reports whether err encloses the link being rendered, meaning
+// that rendering it again would loop. Errors shared by sibling branches of
+// a multi-error aren't cycles. Only pointer errors are tracked: comparing
+// other errors panics if they hold uncomparable values, even when their type
+// is comparable. The link limit still bounds any other loops.
+func (w *chainWalker) onPath(err error) bool {
+	if reflect.TypeOf(err).Kind() != reflect.Ptr {
+		return false
+	}
+	for _, e := range w.path {
+		if e == err {
+			return true
+		}
+	}
+	return false
+}
+
+// Encodes err and the errors it wraps as an array of links, following
+// single-error Unwrap and Cause methods until the chain ends or branches.
+//
+//	[
+//	  {"type": "*fmt.wrapError", "message": "open config: file does not exist"},
+//	  {"type": "*fs.PathError", "message": "open config: file does not exist"},
+//	  {"type": "*errors.errorString", "message": "file does not exist"}
+//	]
+//
+// Errors that carry fields (see FieldCarrier) or implement ObjectMarshaler
+// add a "fields" object to their link,
+// and errors that wrap several errors add a "causes" array holding one chain
+// per wrapped error. Links that wrap themselves are replaced with a marker
+// carrying "cycle": true, and a final {"truncated": true} marker is added
+// once the link limit is reached.
+type errChain struct {
+	w   *chainWalker
+	err error
+}
+
+func (c errChain) MarshalLogArray(arr ArrayEncoder) error {
+	// Branches are rendered from within the link that wraps them, so the
+	// links of this chain leave the path once it's rendered.
+	depth := len(c.w.path)
+	defer func() { c.w.path = c.w.path[:depth] }()
+
+	for err := c.err; err != nil; {
+		if c.w.links >= _maxErrorChainLinks {
+			return arr.AppendObject(errChainMarker{key: "truncated"})
+		}
+		if c.w.onPath(err) {
+			return arr.AppendObject(errChainMarker{key: "cycle", err: err})
+		}
+		c.w.links++
+		c.w.path = append(c.w.path, err)
+
+		link := errChainLink{w: c.w, err: err}
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			link.causes = e.Unwrap()
+		case errorGroup:
+			link.causes = e.Errors()
+		}
+		if err := arr.AppendObject(link); err != nil {
+			return err
+		}
+		if link.causes != nil {
+			return nil
+		}
+
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case causer:
+			err = e.Cause()
+		default:
+			err = nil
+		}
+	}
+	return nil
+}
+
+// Encodes a single link of an error chain.
+type errChainLink struct {
+	w      *chainWalker
+	err    error
+	causes []error
+}
+
+func (l errChainLink) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("type", reflect.TypeOf(l.err).String())
+	enc.AddString("message", l.err.Error())
+	var fields ObjectMarshaler
+	switch e := l.err.(type) {
+	case FieldCarrier:
+		if fs := e.LogFields(); len(fs) > 0 {
+			fields = carriedFields(fs)
+		}
+	case ObjectMarshaler:
+		fields = e
+	}
+	if fields != nil {
+		if err := enc.AddObject("fields", fields); err != nil {
+			return err
+		}
+	}
+	if l.causes != nil {
+		return enc.AddArray("causes", errChainCauses{w: l.w, errs: l.causes})
+	}
+	return nil
+}
+
+// Encodes the fields carried by a single error, skipping namespaces as
+// addErrorFields does.
+type carriedFields []Field
+
+func (fs carriedFields) MarshalLogObject(enc ObjectEncoder) error {
+	for _, f := range fs {
+		if f.Type != NamespaceType {
+			f.AddTo(enc)
+		}
+	}
+	return nil
+}
+
+// Encodes the errors wrapped by a multi-error as an array of chains.
+type errChainCauses struct {
+	w    *chainWalker
+	errs []error
+}
+
+func (c errChainCauses) MarshalLogArray(arr ArrayEncoder) error {
+	for _, err := range c.errs {
+		if err == nil {
+			continue
+		}
+		if err := arr.AppendArray(errChain{w: c.w, err: err}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marks where an error chain was cut short, either because a link wraps
+// itself or because the link limit was reached.
+type errChainMarker struct {
+	key string
+	err error
+}
+
+func (m errChainMarker) MarshalLogObject(enc ObjectEncoder) error {
+	if m.err != nil {
+		enc.AddString("type", reflect.TypeOf(m.err).String())
+	}
+	enc.AddBool(m.key, true)
+	return nil
+}
+
 // Note that errArray and errArrayElem are very similar to the version
 // implemented in the top-level error.go file. We can't re-use this because
 // that would require exporting errArray as part of the zapcore API.
@@ -127,7 +490,8 @@ func (e *errArrayElem) MarshalLogArray(arr ArrayEncoder) error {
 }
 
 func (e *errArrayElem) MarshalLogObject(enc ObjectEncoder) error {
-	return encodeError("error", e.err, enc)
+	// The fields carried by causes were already merged at the top level.
+	return encodeErrorValue("error", e.err, enc, false /* topLevel */)
 }
 
 func (e *errArrayElem) Free() {
@@ -21,12 +21,16 @@
 package ladcore_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"go.uber.org/multierr"
 	//revive:disable:dot-imports
@@ -168,6 +172,374 @@ func TestErrorEncoding(t *testing.T) {
 	}
 }
 
+type errQuota struct {
+	user  string
+	limit int
+	cause error
+}
+
+func (e *errQuota) Error() string { return "quota exceeded: " + e.cause.Error() }
+func (e *errQuota) Unwrap() error { return e.cause }
+
+func (e *errQuota) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("user", e.user)
+	enc.AddInt("limit", e.limit)
+	return nil
+}
+
+// errLoop wraps whatever next points at, which may be itself.
+type errLoop struct{ next error }
+
+func (e *errLoop) Error() string { return "loop" }
+func (e *errLoop) Unwrap() error { return e.next }
+
+type errCause struct{ cause error }
+
+func (e errCause) Error() string { return "caused: " + e.cause.Error() }
+func (e errCause) Cause() error  { return e.cause }
+
+// errValues holds an uncomparable value and wraps an errValues holding
+// the rest of it.
+type errValues struct{ v interface{} }
+
+func (e errValues) Error() string { return "values" }
+
+func (e errValues) Unwrap() error {
+	if vs, ok := e.v.([]int); ok && len(vs) > 1 {
+		return errValues{vs[1:]}
+	}
+	return nil
+}
+
+func TestErrorChainEncoding(t *testing.T) {
+	loop := &errLoop{}
+	loop.next = fmt.Errorf("again: %w", loop)
+
+	var deep error = errors.New("bottom")
+	for i := 0; i < 40; i++ {
+		deep = fmt.Errorf("w%d: %w", i, deep)
+	}
+
+	link := func(typ, msg string) map[string]any {
+		return map[string]any{"type": typ, "message": msg}
+	}
+
+	tests := []struct {
+		desc string
+		err  error
+		want map[string]any
+	}{
+		{
+			desc: "single error",
+			err:  errors.New("egad"),
+			want: map[string]any{
+				"error":      "egad",
+				"errorChain": []any{link("*errors.errorString", "egad")},
+			},
+		},
+		{
+			desc: "wrapped with fields",
+			err: fmt.Errorf("upload: %w", &errQuota{
+				user:  "ada",
+				limit: 5,
+				cause: errTooFewUsers(1),
+			}),
+			want: map[string]any{
+				"error": "upload: quota exceeded: 1 too few users",
+				"errorChain": []any{
+					link("*fmt.wrapError", "upload: quota exceeded: 1 too few users"),
+					map[string]any{
+						"type":    "*ladcore_test.errQuota",
+						"message": "quota exceeded: 1 too few users",
+						"fields":  map[string]any{"user": "ada", "limit": float64(5)},
+					},
+					link("ladcore_test.errTooFewUsers", "1 too few users"),
+				},
+			},
+		},
+		{
+			desc: "causer",
+			err:  errCause{errors.New("root")},
+			want: map[string]any{
+				"error": "caused: root",
+				"errorChain": []any{
+					link("ladcore_test.errCause", "caused: root"),
+					link("*errors.errorString", "root"),
+				},
+			},
+		},
+		{
+			desc: "multiple wrapped errors",
+			err: fmt.Errorf("both: %w; %w",
+				fmt.Errorf("left: %w", errors.New("a")),
+				errors.New("b"),
+			),
+			want: map[string]any{
+				"error": "both: left: a; b",
+				"errorChain": []any{
+					map[string]any{
+						"type":    "*fmt.wrapErrors",
+						"message": "both: left: a; b",
+						"causes": []any{
+							[]any{
+								link("*fmt.wrapError", "left: a"),
+								link("*errors.errorString", "a"),
+							},
+							[]any{link("*errors.errorString", "b")},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "error group",
+			err:  customMultierr{},
+			want: map[string]any{
+				"error": "great sadness",
+				"errorChain": []any{
+					map[string]any{
+						"type":    "ladcore_test.customMultierr",
+						"message": "great sadness",
+						"causes": []any{
+							[]any{link("*errors.errorString", "foo")},
+							[]any{map[string]any{
+								"type":    "*multierr.multiError",
+								"message": "bar; baz",
+								"causes": []any{
+									[]any{link("*errors.errorString", "bar")},
+									[]any{link("*errors.errorString", "baz")},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "sentinel shared between branches",
+			err:  errors.Join(fmt.Errorf("a: %w", io.EOF), fmt.Errorf("b: %w", io.EOF)),
+			want: map[string]any{
+				"error": "a: EOF\nb: EOF",
+				"errorChain": []any{
+					map[string]any{
+						"type":    "*errors.joinError",
+						"message": "a: EOF\nb: EOF",
+						"causes": []any{
+							[]any{link("*fmt.wrapError", "a: EOF"), link("*errors.errorString", "EOF")},
+							[]any{link("*fmt.wrapError", "b: EOF"), link("*errors.errorString", "EOF")},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "uncomparable values",
+			err:  errValues{[]int{1, 2}},
+			want: map[string]any{
+				"error": "values",
+				"errorChain": []any{
+					link("ladcore_test.errValues", "values"),
+					link("ladcore_test.errValues", "values"),
+				},
+			},
+		},
+		{
+			desc: "cycle",
+			err:  loop,
+			want: map[string]any{
+				"error": "loop",
+				"errorChain": []any{
+					link("*ladcore_test.errLoop", "loop"),
+					link("*fmt.wrapError", "again: loop"),
+					map[string]any{"type": "*ladcore_test.errLoop", "cycle": true},
+				},
+			},
+		},
+	}
+
+	enc := NewJSONEncoder(EncoderConfig{ErrorFormat: ChainErrorFormat})
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			buf, err := enc.EncodeEntry(Entry{}, []Field{{Key: "error", Type: ErrorType, Interface: tt.err}})
+			require.NoError(t, err, "Unexpected error encoding entry.")
+			defer buf.Free()
+
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Invalid JSON output: %s", buf.String())
+			assert.Equal(t, tt.want, got, "Unexpected chain encoding.")
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		buf, err := enc.EncodeEntry(Entry{}, []Field{{Key: "error", Type: ErrorType, Interface: deep}})
+		require.NoError(t, err, "Unexpected error encoding entry.")
+		defer buf.Free()
+
+		var got struct {
+			Chain []map[string]any `json:"errorChain"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Invalid JSON output: %s", buf.String())
+		require.Len(t, got.Chain, 33, "Expected 32 links and a truncation marker.")
+		assert.Equal(t, map[string]any{"truncated": true}, got.Chain[32], "Expected a truncation marker.")
+	})
+}
+
+func TestErrorFormatLegacyByDefault(t *testing.T) {
+	err := fmt.Errorf("upload: %w", errors.New("egad"))
+	buf, encErr := NewJSONEncoder(EncoderConfig{}).EncodeEntry(Entry{}, []Field{{Key: "error", Type: ErrorType, Interface: err}})
+	require.NoError(t, encErr, "Unexpected error encoding entry.")
+	defer buf.Free()
+	assert.Equal(t, `{"error":"upload: egad"}`+"\n", buf.String(), "Unexpected legacy encoding.")
+}
+
+type errWithFields struct {
+	msg    string
+	fields []Field
+}
+
+func (e errWithFields) Error() string      { return e.msg }
+func (e errWithFields) LogFields() []Field { return e.fields }
+
+func TestErrorCarriedFields(t *testing.T) {
+	err := multierr.Combine(
+		errWithFields{"foo", []Field{{Key: "shard", Type: StringType, String: "a"}}},
+		fmt.Errorf("wrapped: %w", errWithFields{"bar", []Field{
+			{Key: "shard", Type: StringType, String: "b"},
+			{Key: "ns", Type: NamespaceType},
+			{Key: "attempt", Type: Int64Type, Integer: 2},
+		}}),
+	)
+
+	enc := NewMapObjectEncoder()
+	Field{Key: "err", Type: ErrorType, Interface: err}.AddTo(enc)
+	assert.Equal(t, map[string]any{
+		"err":     "foo; wrapped: bar",
+		"shard":   "a",
+		"attempt": int64(2),
+		"errCauses": []any{
+			map[string]any{"error": "foo"},
+			map[string]any{"error": "wrapped: bar"},
+		},
+	}, enc.Fields, "Expected carried fields merged once at the top level.")
+}
+
+type errWithStack struct{ pcs []uintptr }
+
+func (e errWithStack) Error() string       { return "stacked" }
+func (e errWithStack) LogStack() []uintptr { return e.pcs }
+
+// pkgErrStack mirrors the StackTrace and Format methods of errors from
+// github.com/pkg/errors.
+type (
+	pkgFrame      uintptr
+	pkgStackTrace []pkgFrame
+	pkgErrStack   struct{ stack pkgStackTrace }
+)
+
+func (e pkgErrStack) Error() string             { return "pkg" }
+func (e pkgErrStack) StackTrace() pkgStackTrace { return e.stack }
+
+func (e pkgErrStack) Format(s fmt.State, _ rune) { io.WriteString(s, e.Error()) }
+
+// encodeWithErrorStacks encodes fields with the JSON encoder, recording
+// error stacks under ${key}Stack.
+func encodeWithErrorStacks(t *testing.T, fields ...Field) map[string]any {
+	buf, err := NewJSONEncoder(EncoderConfig{ErrorStackSuffix: "Stack"}).EncodeEntry(Entry{}, fields)
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Invalid JSON output: %s", buf.String())
+	return got
+}
+
+//go:noinline
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(2, pcs)]
+}
+
+func TestErrorStackEncoding(t *testing.T) {
+	pcs := callers()
+	pkgStack := make(pkgStackTrace, len(pcs))
+	for i, pc := range pcs {
+		pkgStack[i] = pkgFrame(pc)
+	}
+
+	tests := []struct {
+		desc string
+		err  error
+	}{
+		{"StackCarrier", errWithStack{pcs}},
+		{"wrapped StackCarrier", fmt.Errorf("outer: %w", errWithStack{pcs})},
+		{"pkg/errors StackTrace", pkgErrStack{pkgStack}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := encodeWithErrorStacks(t, Field{Key: "err", Type: ErrorType, Interface: tt.err})
+
+			frames, ok := got["errStack"].([]any)
+			require.True(t, ok, "Expected errStack to be an array, got %#v.", got)
+			require.NotEmpty(t, frames, "Expected at least one frame.")
+			for _, f := range frames {
+				frame := f.(map[string]any)
+				assert.NotEqual(t, "runtime.goexit", frame["function"], "Unexpected runtime frame.")
+			}
+		})
+	}
+
+	t.Run("frame contents", func(t *testing.T) {
+		err := fmt.Errorf("outer: %w", errWithStack{pcs})
+		got := encodeWithErrorStacks(t,
+			Field{Key: "err", Type: ErrorType, Interface: errWithStack{pcs[1:]}},
+			Field{Key: "wrapped", Type: ErrorType, Interface: err},
+		)
+
+		frame := got["wrappedStack"].([]any)[0].(map[string]any)
+		assert.True(t, strings.HasSuffix(frame["function"].(string), "ladcore_test.TestErrorStackEncoding"),
+			"Unexpected function in first frame: %v.", frame["function"])
+		assert.True(t, strings.HasSuffix(frame["file"].(string), "error_test.go"),
+			"Unexpected file in first frame: %v.", frame["file"])
+		assert.Positive(t, frame["line"], "Expected a line number.")
+
+		first := got["errStack"].([]any)[0].(map[string]any)
+		assert.True(t, strings.HasPrefix(first["function"].(string), "testing."),
+			"Expected the truncated stack to start in the test runner, got %v.", first["function"])
+	})
+
+	t.Run("opt-in", func(t *testing.T) {
+		enc := NewMapObjectEncoder()
+		Field{Key: "err", Type: ErrorType, Interface: errWithStack{pcs}}.AddTo(enc)
+		assert.Equal(t, map[string]any{"err": "stacked"}, enc.Fields,
+			"Expected encoders without an ErrorStackSuffix to omit stacks.")
+	})
+
+	t.Run("suffix", func(t *testing.T) {
+		err := errWithStack{pcs}
+		for _, tt := range []struct {
+			suffix string
+			key    string
+		}{
+			{"", ""},
+			{"Origin", "errorOrigin"},
+		} {
+			buf, encErr := NewJSONEncoder(EncoderConfig{ErrorStackSuffix: tt.suffix}).EncodeEntry(
+				Entry{}, []Field{{Key: "error", Type: ErrorType, Interface: err}})
+			require.NoError(t, encErr, "Unexpected error encoding entry.")
+
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &got), "Invalid JSON output.")
+			buf.Free()
+			if tt.key == "" {
+				assert.Equal(t, map[string]any{"error": "stacked"}, got, "Expected stacks to be omitted without a suffix.")
+			} else {
+				assert.Contains(t, got, tt.key, "Expected stack under the configured suffix.")
+			}
+		}
+	})
+}
+
 func TestRichErrorSupport(t *testing.T) {
 	f := Field{
 		Type:      ErrorType,
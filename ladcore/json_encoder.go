@@ -62,18 +62,22 @@ type jsonEncoder struct {
 	reflectEnc ReflectedEncoder
 }
 
+func (enc *jsonEncoder) errorConfig() *EncoderConfig {
+	return enc.EncoderConfig
+}
+
 // NewJSONEncoder creates a fast, low-allocation JSON encoder. The encoder
 // appropriately escapes all field keys and values.
 //
-// Note that the encoder doesn't deduplicate keys, so it's possible to produce
-// a message like
+// Note that by default the encoder doesn't deduplicate keys, so it's possible
+// to produce a message like
 //
 //	{"foo":"bar","foo":"baz"}
 //
 // This is permitted by the JSON specification, but not encouraged. Many
 // libraries will ignore duplicate key-value pairs (typically keeping the last
 // pair) when unmarshaling, but users should attempt to avoid adding duplicate
-// keys.
+// keys, or set the EncoderConfig's DuplicateKeys policy.
 func NewJSONEncoder(cfg EncoderConfig) Encoder {
 	return newJSONEncoder(cfg, false)
 }
@@ -413,14 +417,27 @@ func (enc *jsonEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer,
 		final.addKey(enc.MessageKey)
 		final.AppendString(ent.Message)
 	}
+	fieldsStart := final.buf.Len()
 	if enc.buf.Len() > 0 {
 		final.addElementSeparator()
 		final.buf.Write(enc.buf.Bytes())
 	}
 	addFields(final, fields)
 	final.closeOpenNamespaces()
+	fieldsEnd := final.buf.Len()
 	if ent.Stack != "" && final.StacktraceKey != "" {
-		final.AddString(final.StacktraceKey, ent.Stack)
+		if final.StacktraceFormat == FramesStacktraceFormat {
+			// formattedStack never returns an error.
+			_ = final.AddObject(final.StacktraceKey, formattedStack(ent.Stack))
+		} else {
+			final.AddString(final.StacktraceKey, ent.Stack)
+		}
+	}
+	if final.tidiesFields() {
+		// Offsets are relative to the object's body, after the '{'.
+		final.tidyFields(1, func(offset int) bool {
+			return offset+1 >= fieldsStart && offset+1 < fieldsEnd
+		})
 	}
 	final.buf.AppendByte('}')
 	final.buf.AppendString(final.LineEnding)
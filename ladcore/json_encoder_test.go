@@ -150,6 +150,54 @@ func TestJSONEncodeEntry(t *testing.T) {
 	}
 }
 
+func TestJSONStacktraceFormat(t *testing.T) {
+	tests := []struct {
+		desc     string
+		stack    string
+		format   ladcore.StacktraceFormat
+		expected string
+	}{
+		{
+			desc:     "string",
+			stack:    "main.main\n\t/src/main.go:3",
+			expected: `{"stacktrace":"main.main\n\t/src/main.go:3"}`,
+		},
+		{
+			desc:     "frames",
+			stack:    "main.run\n\t/src/main.go:10\nmain.main\n\t/src/main.go:3",
+			format:   ladcore.FramesStacktraceFormat,
+			expected: `{"stacktrace":{"frames":[{"function":"main.run","file":"/src/main.go","line":10},{"function":"main.main","file":"/src/main.go","line":3}]}}`,
+		},
+		{
+			desc:     "frames with goroutine",
+			stack:    "goroutine 7 [running]:\nmain.main\n\t/src/main.go:3",
+			format:   ladcore.FramesStacktraceFormat,
+			expected: `{"stacktrace":{"goroutine":7,"frames":[{"function":"main.main","file":"/src/main.go","line":3}]}}`,
+		},
+		{
+			desc:     "frames without locations",
+			stack:    "main.main\nmain.other\n\tno-line",
+			format:   ladcore.FramesStacktraceFormat,
+			expected: `{"stacktrace":{"frames":[{"function":"main.main","file":"","line":0},{"function":"main.other","file":"no-line","line":0}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			enc := ladcore.NewJSONEncoder(ladcore.EncoderConfig{
+				StacktraceKey:    "stacktrace",
+				StacktraceFormat: tt.format,
+				SkipLineEnding:   true,
+			})
+			buf, err := enc.EncodeEntry(ladcore.Entry{Stack: tt.stack}, nil)
+			if assert.NoError(t, err, "Unexpected JSON encoding error.") {
+				assert.JSONEq(t, tt.expected, buf.String(), "Incorrect encoded JSON entry.")
+				buf.Free()
+			}
+		})
+	}
+}
+
 func TestNoEncodeLevelSupplied(t *testing.T) {
 	enc := ladcore.NewJSONEncoder(ladcore.EncoderConfig{
 		MessageKey:     "M",
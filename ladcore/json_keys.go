@@ -0,0 +1,334 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladcore
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/tnngo/lad/internal/bufferpool"
+)
+
+// jsonMember is a member of a JSON object written by the encoder.
+type jsonMember struct {
+	raw    []byte // "key":value, as written
+	keyLen int    // length of the quoted key
+	valAt  int    // offset of the value in raw
+	offset int    // offset of raw in the object's body
+	fixed  bool   // written for the entry itself rather than by a field
+	key    []byte // key to write, if renamed
+}
+
+func (m *jsonMember) quotedKey() []byte {
+	if m.key != nil {
+		return m.key
+	}
+	return m.raw[:m.keyLen]
+}
+
+// tidiesFields reports whether fields have to be rewritten after encoding.
+func (enc *jsonEncoder) tidiesFields() bool {
+	return enc.DuplicateKeys != KeepAllDuplicateKeys || enc.SortKeys
+}
+
+// tidyFields applies the DuplicateKeys policy and SortKeys to the body of
+// the object that starts at offset from in enc.buf. isField reports whether
+// the member at the given offset in the body was written by a field; other
+// members are left in place. If the body can't be parsed, which only
+// happens if a marshaler wrote invalid JSON, it's left alone.
+func (enc *jsonEncoder) tidyFields(from int, isField func(offset int) bool) {
+	members, ok := splitJSONMembers(enc.buf.Bytes()[from:])
+	if !ok {
+		return
+	}
+	for i := range members {
+		members[i].fixed = !isField(members[i].offset)
+	}
+
+	tidy := bufferpool.Get()
+	tidy.Write(enc.buf.Bytes()[:from])
+	enc.writeMembers(tidy, members)
+	enc.buf.Free()
+	enc.buf = tidy
+}
+
+// writeMembers writes the members of an object, separated by commas, after
+// deduplicating and sorting those that aren't fixed.
+func (enc *jsonEncoder) writeMembers(buf bufferWriter, members []jsonMember) {
+	taken := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		if m.fixed {
+			taken[string(m.quotedKey())] = struct{}{}
+		}
+	}
+
+	var last map[string]int
+	if enc.DuplicateKeys == LastWinsDuplicateKeys {
+		last = make(map[string]int, len(members))
+		for i, m := range members {
+			last[string(m.quotedKey())] = i
+		}
+	}
+
+	kept := members[:0]
+	for i, m := range members {
+		if m.fixed {
+			kept = append(kept, m)
+			continue
+		}
+		key := string(m.quotedKey())
+		_, dup := taken[key]
+		switch enc.DuplicateKeys {
+		case LastWinsDuplicateKeys:
+			if dup || last[key] != i {
+				continue
+			}
+		case FirstWinsDuplicateKeys:
+			if dup {
+				continue
+			}
+		case SuffixRenameDuplicateKeys:
+			if dup {
+				m.key = uniqueJSONKey(m.quotedKey(), taken)
+				key = string(m.key)
+			}
+		}
+		taken[key] = struct{}{}
+		kept = append(kept, m)
+	}
+
+	if enc.SortKeys {
+		// Fields are contiguous, between the entry's own members.
+		start := 0
+		for start < len(kept) && kept[start].fixed {
+			start++
+		}
+		end := start
+		for end < len(kept) && !kept[end].fixed {
+			end++
+		}
+		fields := kept[start:end]
+		sort.SliceStable(fields, func(i, j int) bool {
+			return bytes.Compare(fields[i].quotedKey(), fields[j].quotedKey()) < 0
+		})
+	}
+
+	for i := range kept {
+		m := &kept[i]
+		if i > 0 {
+			enc.writeSeparator(buf)
+		}
+		buf.Write(m.quotedKey())
+		buf.Write(m.raw[m.keyLen:m.valAt])
+		if m.fixed {
+			buf.Write(m.raw[m.valAt:])
+		} else {
+			enc.writeTidyValue(buf, m.raw[m.valAt:])
+		}
+	}
+}
+
+// writeTidyValue writes a JSON value, tidying the objects in it.
+func (enc *jsonEncoder) writeTidyValue(buf bufferWriter, val []byte) {
+	switch val[0] {
+	case '{':
+		if members, ok := splitJSONMembers(val[1 : len(val)-1]); ok {
+			buf.WriteByte('{')
+			enc.writeMembers(buf, members)
+			buf.WriteByte('}')
+			return
+		}
+	case '[':
+		if elems, ok := splitJSONElements(val[1 : len(val)-1]); ok {
+			buf.WriteByte('[')
+			for i, elem := range elems {
+				if i > 0 {
+					enc.writeSeparator(buf)
+				}
+				enc.writeTidyValue(buf, elem)
+			}
+			buf.WriteByte(']')
+			return
+		}
+	}
+	buf.Write(val)
+}
+
+func (enc *jsonEncoder) writeSeparator(buf bufferWriter) {
+	buf.WriteByte(',')
+	if enc.spaced {
+		buf.WriteByte(' ')
+	}
+}
+
+// bufferWriter is implemented by *buffer.Buffer.
+type bufferWriter interface {
+	Write([]byte) (int, error)
+	WriteByte(byte) error
+}
+
+// uniqueJSONKey returns the quoted key with the first "_N" suffix that
+// isn't taken.
+func uniqueJSONKey(quoted []byte, taken map[string]struct{}) []byte {
+	base := quoted[:len(quoted)-1]
+	for n := 1; ; n++ {
+		key := make([]byte, 0, len(quoted)+3)
+		key = append(key, base...)
+		key = append(key, '_')
+		key = strconv.AppendInt(key, int64(n), 10)
+		key = append(key, '"')
+		if _, ok := taken[string(key)]; !ok {
+			return key
+		}
+	}
+}
+
+// splitJSONMembers splits the body of a JSON object, without its braces,
+// into members. It reports false if the body isn't well-formed.
+func splitJSONMembers(body []byte) ([]jsonMember, bool) {
+	var members []jsonMember
+	i := skipJSONSpace(body, 0)
+	for i < len(body) {
+		if len(members) > 0 {
+			if body[i] != ',' {
+				return nil, false
+			}
+			i = skipJSONSpace(body, i+1)
+		}
+		start := i
+		keyLen := scanJSONValue(body[i:])
+		if keyLen < 0 || body[i] != '"' {
+			return nil, false
+		}
+		i = skipJSONSpace(body, i+keyLen)
+		if i >= len(body) || body[i] != ':' {
+			return nil, false
+		}
+		i = skipJSONSpace(body, i+1)
+		valAt := i
+		n := scanJSONValue(body[i:])
+		if n < 0 {
+			return nil, false
+		}
+		i += n
+		members = append(members, jsonMember{
+			raw:    body[start:i],
+			keyLen: keyLen,
+			valAt:  valAt - start,
+			offset: start,
+		})
+		i = skipJSONSpace(body, i)
+	}
+	return members, true
+}
+
+// splitJSONElements splits the body of a JSON array, without its brackets,
+// into values. It reports false if the body isn't well-formed.
+func splitJSONElements(body []byte) ([][]byte, bool) {
+	var elems [][]byte
+	i := skipJSONSpace(body, 0)
+	for i < len(body) {
+		if len(elems) > 0 {
+			if body[i] != ',' {
+				return nil, false
+			}
+			i = skipJSONSpace(body, i+1)
+		}
+		n := scanJSONValue(body[i:])
+		if n < 0 {
+			return nil, false
+		}
+		elems = append(elems, body[i:i+n])
+		i = skipJSONSpace(body, i+n)
+	}
+	return elems, true
+}
+
+// scanJSONValue returns the length of the JSON value at the start of b, or
+// -1 if there's none. It only checks the value's structure, not its
+// contents.
+func scanJSONValue(b []byte) int {
+	if len(b) == 0 {
+		return -1
+	}
+	switch b[0] {
+	case '"':
+		for i := 1; i < len(b); i++ {
+			switch b[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return -1
+	case '{', '[':
+		depth := 0
+		for i := 0; i < len(b); i++ {
+			switch b[i] {
+			case '"':
+				n := scanJSONValue(b[i:])
+				if n < 0 {
+					return -1
+				}
+				i += n - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	default:
+		i := 0
+		for i < len(b) && !isJSONDelim(b[i]) {
+			i++
+		}
+		if i == 0 {
+			return -1
+		}
+		return i
+	}
+}
+
+func isJSONDelim(c byte) bool {
+	switch c {
+	case ',', ':', '}', ']', ' ', '\t', '\n', '\r':
+		return true
+	}
+	return false
+}
+
+func skipJSONSpace(b []byte, i int) int {
+	for i < len(b) {
+		switch b[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
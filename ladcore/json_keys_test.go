@@ -0,0 +1,152 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladcore_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+)
+
+func TestJSONEncoderDuplicateKeys(t *testing.T) {
+	with := []ladcore.Field{lad.String("user", "a"), lad.Int("n", 1)}
+	fields := []ladcore.Field{lad.String("user", "b"), lad.String("msg", "shadow"), lad.Int("n", 2)}
+
+	tests := []struct {
+		policy   ladcore.DuplicateKeys
+		sort     bool
+		expected string
+	}{
+		{
+			policy:   ladcore.KeepAllDuplicateKeys,
+			expected: `{"msg":"hi","user":"a","n":1,"user":"b","msg":"shadow","n":2}`,
+		},
+		{
+			policy:   ladcore.LastWinsDuplicateKeys,
+			expected: `{"msg":"hi","user":"b","n":2}`,
+		},
+		{
+			policy:   ladcore.FirstWinsDuplicateKeys,
+			expected: `{"msg":"hi","user":"a","n":1}`,
+		},
+		{
+			policy:   ladcore.SuffixRenameDuplicateKeys,
+			expected: `{"msg":"hi","user":"a","n":1,"user_1":"b","msg_1":"shadow","n_1":2}`,
+		},
+		{
+			policy:   ladcore.SuffixRenameDuplicateKeys,
+			sort:     true,
+			expected: `{"msg":"hi","msg_1":"shadow","n":1,"n_1":2,"user":"a","user_1":"b"}`,
+		},
+		{
+			policy:   ladcore.KeepAllDuplicateKeys,
+			sort:     true,
+			expected: `{"msg":"hi","msg":"shadow","n":1,"n":2,"user":"a","user":"b"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		enc := ladcore.NewJSONEncoder(ladcore.EncoderConfig{
+			MessageKey:    "msg",
+			DuplicateKeys: tt.policy,
+			SortKeys:      tt.sort,
+		})
+		for _, f := range with {
+			f.AddTo(enc)
+		}
+		buf, err := enc.EncodeEntry(ladcore.Entry{Message: "hi"}, fields)
+		require.NoError(t, err, "Unexpected error encoding entry.")
+		assert.Equal(t, tt.expected+"\n", buf.String(), "Unexpected output with %v, sorted: %v.", tt.policy, tt.sort)
+		buf.Free()
+	}
+}
+
+func TestJSONEncoderDuplicateKeysNested(t *testing.T) {
+	enc := ladcore.NewJSONEncoder(ladcore.EncoderConfig{
+		MessageKey:    "msg",
+		DuplicateKeys: ladcore.LastWinsDuplicateKeys,
+		SortKeys:      true,
+	})
+	lad.Namespace("req").AddTo(enc)
+	lad.String("id", "1").AddTo(enc)
+
+	obj := ladcore.ObjectMarshalerFunc(func(oe ladcore.ObjectEncoder) error {
+		oe.AddString("z", "first")
+		oe.AddString("a", "a")
+		oe.AddString("z", "second")
+		return nil
+	})
+	buf, err := enc.EncodeEntry(ladcore.Entry{Message: "hi"}, []ladcore.Field{
+		lad.String("id", "2"),
+		lad.Object("obj", obj),
+		lad.Objects("list", []ladcore.ObjectMarshaler{obj}),
+		lad.String("escaped\"key", "v, \"quoted\" {not an object}"),
+	})
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+	assert.Equal(t,
+		`{"msg":"hi","req":{"escaped\"key":"v, \"quoted\" {not an object}","id":"2","list":[{"a":"a","z":"second"}],"obj":{"a":"a","z":"second"}}}`+"\n",
+		buf.String(),
+		"Expected the policy to apply inside namespaces and nested objects.",
+	)
+}
+
+func TestJSONEncoderSortKeysKeepsEntryKeys(t *testing.T) {
+	enc := ladcore.NewJSONEncoder(ladcore.EncoderConfig{
+		MessageKey:    "msg",
+		LevelKey:      "level",
+		StacktraceKey: "stacktrace",
+		EncodeLevel:   ladcore.LowercaseLevelEncoder,
+		DuplicateKeys: ladcore.FirstWinsDuplicateKeys,
+		SortKeys:      true,
+	})
+	buf, err := enc.EncodeEntry(ladcore.Entry{
+		Level:   ladcore.WarnLevel,
+		Message: "hi",
+		Stack:   "trace",
+	}, []ladcore.Field{lad.Int("b", 1), lad.Int("a", 2), lad.String("stacktrace", "field")})
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+	assert.Equal(t,
+		`{"level":"warn","msg":"hi","a":2,"b":1,"stacktrace":"trace"}`+"\n",
+		buf.String(),
+		"Expected entry keys to keep their positions and win over fields.",
+	)
+}
+
+func TestConsoleEncoderDuplicateKeys(t *testing.T) {
+	enc := ladcore.NewConsoleEncoder(ladcore.EncoderConfig{
+		MessageKey:    "msg",
+		DuplicateKeys: ladcore.LastWinsDuplicateKeys,
+		SortKeys:      true,
+	})
+	lad.String("user", "a").AddTo(enc)
+	buf, err := enc.EncodeEntry(ladcore.Entry{Message: "hi"}, []ladcore.Field{
+		lad.String("user", "b"),
+		lad.Int("attempt", 3),
+	})
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+	assert.Equal(t, "hi\t{\"attempt\": 3, \"user\": \"b\"}\n", buf.String(), "Unexpected console context.")
+}
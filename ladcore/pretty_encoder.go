@@ -0,0 +1,577 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tnngo/lad/buffer"
+	"github.com/tnngo/lad/internal/bufferpool"
+)
+
+const (
+	// _prettyLevelWidth fits the longest built-in level name, "DPANIC".
+	_prettyLevelWidth = 6
+	// _prettyIndent indents continuation lines and blocks under an entry.
+	_prettyIndent = 4
+)
+
+// A PrettyOption configures the encoder built by NewPrettyEncoder.
+type PrettyOption interface {
+	apply(*prettyEncoder)
+}
+
+// prettyOptionFunc wraps a func so it satisfies the PrettyOption interface.
+type prettyOptionFunc func(*prettyEncoder)
+
+func (f prettyOptionFunc) apply(enc *prettyEncoder) {
+	f(enc)
+}
+
+// PrettyWidth sets the width, in columns, at which inline fields wrap onto
+// continuation lines. A width of zero or less disables wrapping.
+//
+// By default, cores built with NewCore use the width of the terminal they
+// write to. Otherwise, the width is read from the COLUMNS environment
+// variable, and wrapping is disabled if it isn't set.
+func PrettyWidth(n int) PrettyOption {
+	return prettyOptionFunc(func(enc *prettyEncoder) {
+		enc.width = n
+		enc.widthSet = true
+	})
+}
+
+// PrettyNameWidth sets the minimum width of the logger name column.
+// Defaults to 12.
+func PrettyNameWidth(n int) PrettyOption {
+	return prettyOptionFunc(func(enc *prettyEncoder) {
+		enc.nameWidth = n
+	})
+}
+
+type prettyEncoder struct {
+	prettyBuilder
+
+	context    *prettyValue
+	namespaces int // namespaces opened on context
+
+	width     int
+	widthSet  bool // whether width was set with PrettyWidth
+	nameWidth int
+	colors    colorizer
+}
+
+// NewPrettyEncoder creates an encoder whose output is designed for people
+// reading logs in a terminal during development. Each entry starts with a
+// line holding the time, the level and logger name padded into columns, the
+// caller and the message, followed by fields as key=value pairs:
+//
+//	2024-05-01T10:00:00.000Z  INFO    http          server.go:42  request served  status=200 path=/users
+//
+// Strings are quoted when they contain spaces or special characters. Nested
+// objects, arrays that don't fit inline, and multi-line values such as
+// errorVerbose are written under the line with YAML-like indentation, and so
+// is the entry's stack trace:
+//
+//	2024-05-01T10:00:00.000Z  ERROR   db            store.go:17   query failed  table=users
+//	    user:
+//	      name: ada
+//	      roles: [admin, dev]
+//	    errorVerbose: |
+//	      connection reset
+//	      main.query
+//	    main.query
+//	        /src/store.go:17
+//
+// Levels, keys, logger names and callers are colored with the EncoderConfig's
+// ColorTheme when the output is a terminal; see ColorMode. Inline fields wrap
+// at the terminal's width; see PrettyWidth.
+//
+// Like the console encoder, it omits any element whose key is set to the
+// empty string in the encoder configuration. It isn't meant for output that
+// machines have to parse; use the JSON encoder for that.
+func NewPrettyEncoder(cfg EncoderConfig, opts ...PrettyOption) Encoder {
+	if cfg.ConsoleSeparator == "" {
+		cfg.ConsoleSeparator = "  "
+	}
+	if cfg.SkipLineEnding {
+		cfg.LineEnding = ""
+	} else if cfg.LineEnding == "" {
+		cfg.LineEnding = DefaultLineEnding
+	}
+
+	context := &prettyValue{kind: prettyObject}
+	enc := &prettyEncoder{
+		prettyBuilder: prettyBuilder{cfg: &cfg, cur: context},
+		context:       context,
+		nameWidth:     12,
+		colors:        newColorizer(&cfg, true),
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil {
+		enc.width = cols
+	}
+	for _, opt := range opts {
+		opt.apply(enc)
+	}
+	return enc
+}
+
+func (enc *prettyEncoder) OpenNamespace(key string) {
+	enc.prettyBuilder.OpenNamespace(key)
+	enc.namespaces++
+}
+
+func (enc *prettyEncoder) Clone() Encoder {
+	return enc.clone()
+}
+
+func (enc *prettyEncoder) clone() *prettyEncoder {
+	clone := *enc
+	clone.context = enc.context.clone()
+	// Open namespaces are always the last child of their parent.
+	clone.cur = clone.context
+	for i := 0; i < enc.namespaces; i++ {
+		clone.cur = clone.cur.children[len(clone.cur.children)-1]
+	}
+	return &clone
+}
+
+func (enc *prettyEncoder) EncodeEntry(ent Entry, fields []Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	addFields(final, fields)
+
+	line := bufferpool.Get()
+	w := prettyWriter{buf: line, width: enc.width}
+
+	arr := getSliceEncoder()
+	if enc.cfg.TimeKey != "" && enc.cfg.EncodeTime != nil && !ent.Time.IsZero() {
+		enc.cfg.EncodeTime(ent.Time, arr)
+		w.column(fmt.Sprint(arr.elems...), 0, enc.cfg.ConsoleSeparator)
+		arr.elems = arr.elems[:0]
+	}
+	if enc.cfg.LevelKey != "" && enc.cfg.EncodeLevel != nil {
+		enc.cfg.EncodeLevel(ent.Level, arr)
+		w.column(enc.colors.level(ent.Level, fmt.Sprint(arr.elems...)), _prettyLevelWidth, enc.cfg.ConsoleSeparator)
+		arr.elems = arr.elems[:0]
+	}
+	if ent.LoggerName != "" && enc.cfg.NameKey != "" {
+		nameEncoder := enc.cfg.EncodeName
+		if nameEncoder == nil {
+			nameEncoder = FullNameEncoder
+		}
+		nameEncoder(ent.LoggerName, arr)
+		w.column(enc.colors.paint(enc.colors.theme.Name, fmt.Sprint(arr.elems...)), enc.nameWidth, enc.cfg.ConsoleSeparator)
+		arr.elems = arr.elems[:0]
+	}
+	if ent.Caller.Defined {
+		if enc.cfg.CallerKey != "" && enc.cfg.EncodeCaller != nil {
+			enc.cfg.EncodeCaller(ent.Caller, arr)
+			w.column(enc.colors.paint(enc.colors.theme.Caller, fmt.Sprint(arr.elems...)), 0, enc.cfg.ConsoleSeparator)
+			arr.elems = arr.elems[:0]
+		}
+		if enc.cfg.FunctionKey != "" {
+			w.column(ent.Caller.Function, 0, enc.cfg.ConsoleSeparator)
+		}
+	}
+	putSliceEncoder(arr)
+
+	if enc.cfg.MessageKey != "" {
+		w.column(ent.Message, 0, enc.cfg.ConsoleSeparator)
+	}
+
+	// Single-line fields go on the entry's line; everything else goes in
+	// blocks underneath it.
+	var blocks []*prettyValue
+	sep := enc.cfg.ConsoleSeparator
+	for _, v := range final.context.children {
+		text, ok := v.inline()
+		if !ok {
+			blocks = append(blocks, v)
+			continue
+		}
+		w.field(enc.colors.paint(enc.colors.theme.Key, v.key)+"="+text, sep)
+		sep = " "
+	}
+	for _, v := range blocks {
+		enc.writeBlock(line, v, _prettyIndent, false)
+	}
+
+	// As with the other encoders, an empty StacktraceKey omits stack traces.
+	if ent.Stack != "" && enc.cfg.StacktraceKey != "" {
+		for _, l := range strings.Split(ent.Stack, "\n") {
+			line.AppendByte('\n')
+			line.AppendString(strings.Repeat(" ", _prettyIndent))
+			line.AppendString(l)
+		}
+	}
+
+	line.AppendString(enc.cfg.LineEnding)
+	return line, nil
+}
+
+// writeBlock writes v on its own line at the given indentation, followed by
+// its children or the lines of a multi-line string, indented further.
+func (enc *prettyEncoder) writeBlock(line *buffer.Buffer, v *prettyValue, indent int, inArray bool) {
+	line.AppendByte('\n')
+	line.AppendString(strings.Repeat(" ", indent))
+	if inArray {
+		line.AppendByte('-')
+	} else {
+		line.AppendString(enc.colors.paint(enc.colors.theme.Key, v.key))
+		line.AppendByte(':')
+	}
+
+	if text, ok := v.inline(); ok {
+		line.AppendByte(' ')
+		line.AppendString(text)
+		return
+	}
+
+	switch v.kind {
+	case prettyObject, prettyArray:
+		for _, child := range v.children {
+			enc.writeBlock(line, child, indent+2, v.kind == prettyArray)
+		}
+	default:
+		line.AppendString(" |")
+		for _, l := range strings.Split(strings.TrimRight(v.text, "\n"), "\n") {
+			line.AppendByte('\n')
+			line.AppendString(strings.Repeat(" ", indent+2))
+			line.AppendString(l)
+		}
+	}
+}
+
+func (enc *prettyEncoder) withColorSupport(supported bool) Encoder {
+	clone := enc.clone()
+	clone.colors = clone.colors.withSupport(supported)
+	return clone
+}
+
+func (enc *prettyEncoder) withTerminalWidth(cols int) Encoder {
+	if enc.widthSet {
+		return enc
+	}
+	clone := enc.clone()
+	clone.width = cols
+	return clone
+}
+
+// prettyWriter writes the first line of an entry, tracking the visible
+// width of the current line to pad columns and wrap fields.
+type prettyWriter struct {
+	buf   *buffer.Buffer
+	width int
+	col   int // visible width of the current line
+}
+
+// column writes s, padded to at least width columns, after sep.
+func (w *prettyWriter) column(s string, width int, sep string) {
+	if w.buf.Len() > 0 {
+		w.write(sep)
+	}
+	w.write(s)
+	if n := width - visibleWidth(s); n > 0 {
+		w.write(strings.Repeat(" ", n))
+	}
+}
+
+// field writes s after sep, moving to an indented continuation line first
+// if s would overflow the line.
+func (w *prettyWriter) field(s string, sep string) {
+	n := visibleWidth(s)
+	if w.width > 0 && w.col > _prettyIndent && w.col+len(sep)+n > w.width {
+		w.buf.AppendByte('\n')
+		w.col = 0
+		w.write(strings.Repeat(" ", _prettyIndent))
+	} else if w.buf.Len() > 0 {
+		w.write(sep)
+	}
+	w.write(s)
+}
+
+func (w *prettyWriter) write(s string) {
+	w.buf.AppendString(s)
+	w.col += visibleWidth(s)
+}
+
+// visibleWidth returns the number of runes in s that a terminal displays,
+// skipping ANSI escape sequences.
+func visibleWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+			i++ // final byte
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+type prettyKind uint8
+
+const (
+	prettyRaw    prettyKind = iota // numbers, booleans, JSON
+	prettyString                   // quoted if needed
+	prettyObject
+	prettyArray
+)
+
+// prettyValue is a node in the tree of fields that the pretty encoder builds
+// before rendering an entry.
+type prettyValue struct {
+	key      string
+	kind     prettyKind
+	text     string
+	children []*prettyValue
+}
+
+func (v *prettyValue) clone() *prettyValue {
+	c := *v
+	if v.children != nil {
+		c.children = make([]*prettyValue, len(v.children))
+		for i, child := range v.children {
+			c.children[i] = child.clone()
+		}
+	}
+	return &c
+}
+
+// inline returns the single-line rendering of v, if it has one: scalars
+// without newlines, empty containers, and arrays of such scalars.
+func (v *prettyValue) inline() (string, bool) {
+	switch v.kind {
+	case prettyRaw:
+		return v.text, !strings.Contains(v.text, "\n")
+	case prettyString:
+		if strings.Contains(v.text, "\n") {
+			return "", false
+		}
+		return quotePretty(v.text), true
+	case prettyObject:
+		return "{}", len(v.children) == 0
+	}
+
+	elems := make([]string, len(v.children))
+	for i, child := range v.children {
+		if child.kind == prettyObject || child.kind == prettyArray {
+			return "", false
+		}
+		text, ok := child.inline()
+		if !ok {
+			return "", false
+		}
+		elems[i] = text
+	}
+	return "[" + strings.Join(elems, ", ") + "]", true
+}
+
+// quotePretty quotes s if it's empty or contains characters that would make
+// a key=value pair ambiguous.
+func quotePretty(s string) string {
+	if s == "" || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == ',' || r == '[' || r == ']' || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// prettyBuilder implements ObjectEncoder and ArrayEncoder by adding values
+// to a prettyValue. Array elements have empty keys.
+type prettyBuilder struct {
+	cfg *EncoderConfig
+	cur *prettyValue
+}
+
+var (
+	_ ObjectEncoder = (*prettyBuilder)(nil)
+	_ ArrayEncoder  = (*prettyBuilder)(nil)
+)
+
+func (b *prettyBuilder) add(key string, kind prettyKind, text string) {
+	b.cur.children = append(b.cur.children, &prettyValue{key: key, kind: kind, text: text})
+}
+
+func (b *prettyBuilder) addObject(key string, obj ObjectMarshaler) error {
+	v := &prettyValue{key: key, kind: prettyObject}
+	b.cur.children = append(b.cur.children, v)
+	return obj.MarshalLogObject(&prettyBuilder{cfg: b.cfg, cur: v})
+}
+
+func (b *prettyBuilder) addArray(key string, arr ArrayMarshaler) error {
+	v := &prettyValue{key: key, kind: prettyArray}
+	b.cur.children = append(b.cur.children, v)
+	return arr.MarshalLogArray(&prettyBuilder{cfg: b.cfg, cur: v})
+}
+
+func (b *prettyBuilder) addReflected(key string, obj interface{}) error {
+	var buf bytes.Buffer
+	var enc ReflectedEncoder
+	if b.cfg.NewReflectedEncoder != nil {
+		enc = b.cfg.NewReflectedEncoder(&buf)
+	} else {
+		jsonEnc := json.NewEncoder(&buf)
+		jsonEnc.SetEscapeHTML(false)
+		enc = jsonEnc
+	}
+	if err := enc.Encode(obj); err != nil {
+		return err
+	}
+	b.add(key, prettyRaw, strings.TrimSuffix(buf.String(), "\n"))
+	return nil
+}
+
+// addEncoded adds the single value that encode appends, falling back to
+// fallback if it appends nothing.
+func (b *prettyBuilder) addEncoded(key string, encode func(PrimitiveArrayEncoder), fallback string) {
+	tmp := &prettyValue{kind: prettyArray}
+	encode(&prettyBuilder{cfg: b.cfg, cur: tmp})
+	if len(tmp.children) == 0 {
+		b.add(key, prettyRaw, fallback)
+		return
+	}
+	v := tmp.children[0]
+	v.key = key
+	b.cur.children = append(b.cur.children, v)
+}
+
+func (b *prettyBuilder) addDuration(key string, d time.Duration) {
+	if b.cfg.EncodeDuration == nil {
+		b.add(key, prettyRaw, strconv.FormatInt(int64(d), 10))
+		return
+	}
+	b.addEncoded(key, func(arr PrimitiveArrayEncoder) { b.cfg.EncodeDuration(d, arr) }, strconv.FormatInt(int64(d), 10))
+}
+
+func (b *prettyBuilder) addTime(key string, t time.Time) {
+	if b.cfg.EncodeTime == nil {
+		b.add(key, prettyRaw, strconv.FormatInt(t.UnixNano(), 10))
+		return
+	}
+	b.addEncoded(key, func(arr PrimitiveArrayEncoder) { b.cfg.EncodeTime(t, arr) }, strconv.FormatInt(t.UnixNano(), 10))
+}
+
+func (b *prettyBuilder) addFloat(key string, f float64, bitSize int) {
+	var text string
+	switch {
+	case math.IsNaN(f):
+		text = "NaN"
+	case math.IsInf(f, 1):
+		text = "+Inf"
+	case math.IsInf(f, -1):
+		text = "-Inf"
+	default:
+		text = strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	b.add(key, prettyRaw, text)
+}
+
+func (b *prettyBuilder) addComplex(key string, c complex128, bitSize int) {
+	text := strconv.FormatComplex(c, 'g', -1, bitSize)
+	b.add(key, prettyRaw, text[1:len(text)-1]) // trim parentheses
+}
+
+func (b *prettyBuilder) OpenNamespace(key string) {
+	v := &prettyValue{key: key, kind: prettyObject}
+	b.cur.children = append(b.cur.children, v)
+	b.cur = v
+}
+
+func (b *prettyBuilder) AddArray(key string, arr ArrayMarshaler) error {
+	return b.addArray(key, arr)
+}
+
+func (b *prettyBuilder) AddObject(key string, obj ObjectMarshaler) error {
+	return b.addObject(key, obj)
+}
+
+func (b *prettyBuilder) AddReflected(key string, obj interface{}) error {
+	return b.addReflected(key, obj)
+}
+
+func (b *prettyBuilder) AddBinary(k string, v []byte) {
+	b.add(k, prettyString, base64.StdEncoding.EncodeToString(v))
+}
+
+func (b *prettyBuilder) AddByteString(k string, v []byte)      { b.add(k, prettyString, string(v)) }
+func (b *prettyBuilder) AddBool(k string, v bool)              { b.add(k, prettyRaw, strconv.FormatBool(v)) }
+func (b *prettyBuilder) AddComplex128(k string, v complex128)  { b.addComplex(k, v, 128) }
+func (b *prettyBuilder) AddComplex64(k string, v complex64)    { b.addComplex(k, complex128(v), 64) }
+func (b *prettyBuilder) AddDuration(k string, v time.Duration) { b.addDuration(k, v) }
+func (b *prettyBuilder) AddFloat64(k string, v float64)        { b.addFloat(k, v, 64) }
+func (b *prettyBuilder) AddFloat32(k string, v float32)        { b.addFloat(k, float64(v), 32) }
+func (b *prettyBuilder) AddInt(k string, v int)                { b.AddInt64(k, int64(v)) }
+func (b *prettyBuilder) AddInt64(k string, v int64)            { b.add(k, prettyRaw, strconv.FormatInt(v, 10)) }
+func (b *prettyBuilder) AddInt32(k string, v int32)            { b.AddInt64(k, int64(v)) }
+func (b *prettyBuilder) AddInt16(k string, v int16)            { b.AddInt64(k, int64(v)) }
+func (b *prettyBuilder) AddInt8(k string, v int8)              { b.AddInt64(k, int64(v)) }
+func (b *prettyBuilder) AddString(k, v string)                 { b.add(k, prettyString, v) }
+func (b *prettyBuilder) AddTime(k string, v time.Time)         { b.addTime(k, v) }
+func (b *prettyBuilder) AddUint(k string, v uint)              { b.AddUint64(k, uint64(v)) }
+func (b *prettyBuilder) AddUint64(k string, v uint64)          { b.add(k, prettyRaw, strconv.FormatUint(v, 10)) }
+func (b *prettyBuilder) AddUint32(k string, v uint32)          { b.AddUint64(k, uint64(v)) }
+func (b *prettyBuilder) AddUint16(k string, v uint16)          { b.AddUint64(k, uint64(v)) }
+func (b *prettyBuilder) AddUint8(k string, v uint8)            { b.AddUint64(k, uint64(v)) }
+func (b *prettyBuilder) AddUintptr(k string, v uintptr)        { b.AddUint64(k, uint64(v)) }
+
+func (b *prettyBuilder) AppendArray(arr ArrayMarshaler) error   { return b.addArray("", arr) }
+func (b *prettyBuilder) AppendObject(obj ObjectMarshaler) error { return b.addObject("", obj) }
+func (b *prettyBuilder) AppendReflected(obj interface{}) error  { return b.addReflected("", obj) }
+func (b *prettyBuilder) AppendBool(v bool)                      { b.AddBool("", v) }
+func (b *prettyBuilder) AppendByteString(v []byte)              { b.AddByteString("", v) }
+func (b *prettyBuilder) AppendComplex128(v complex128)          { b.AddComplex128("", v) }
+func (b *prettyBuilder) AppendComplex64(v complex64)            { b.AddComplex64("", v) }
+func (b *prettyBuilder) AppendDuration(v time.Duration)         { b.addDuration("", v) }
+func (b *prettyBuilder) AppendFloat64(v float64)                { b.AddFloat64("", v) }
+func (b *prettyBuilder) AppendFloat32(v float32)                { b.AddFloat32("", v) }
+func (b *prettyBuilder) AppendInt(v int)                        { b.AddInt64("", int64(v)) }
+func (b *prettyBuilder) AppendInt64(v int64)                    { b.AddInt64("", v) }
+func (b *prettyBuilder) AppendInt32(v int32)                    { b.AddInt64("", int64(v)) }
+func (b *prettyBuilder) AppendInt16(v int16)                    { b.AddInt64("", int64(v)) }
+func (b *prettyBuilder) AppendInt8(v int8)                      { b.AddInt64("", int64(v)) }
+func (b *prettyBuilder) AppendString(v string)                  { b.AddString("", v) }
+func (b *prettyBuilder) AppendTime(v time.Time)                 { b.addTime("", v) }
+func (b *prettyBuilder) AppendUint(v uint)                      { b.AddUint64("", uint64(v)) }
+func (b *prettyBuilder) AppendUint64(v uint64)                  { b.AddUint64("", v) }
+func (b *prettyBuilder) AppendUint32(v uint32)                  { b.AddUint64("", uint64(v)) }
+func (b *prettyBuilder) AppendUint16(v uint16)                  { b.AddUint64("", uint64(v)) }
+func (b *prettyBuilder) AppendUint8(v uint8)                    { b.AddUint64("", uint64(v)) }
+func (b *prettyBuilder) AppendUintptr(v uintptr)                { b.AddUint64("", uint64(v)) }
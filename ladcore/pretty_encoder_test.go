@@ -0,0 +1,198 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladcore_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	//revive:disable:dot-imports
+	. "github.com/tnngo/lad/ladcore"
+)
+
+type prettyUser struct {
+	name  string
+	roles []string
+}
+
+func (u prettyUser) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	return enc.AddArray("roles", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+		for _, r := range u.roles {
+			arr.AppendString(r)
+		}
+		return nil
+	}))
+}
+
+func testPrettyConfig() EncoderConfig {
+	return EncoderConfig{
+		MessageKey:     "M",
+		LevelKey:       "L",
+		TimeKey:        "T",
+		NameKey:        "N",
+		CallerKey:      "C",
+		StacktraceKey:  "S",
+		EncodeLevel:    CapitalLevelEncoder,
+		EncodeTime:     ISO8601TimeEncoder,
+		EncodeDuration: StringDurationEncoder,
+		EncodeCaller:   ShortCallerEncoder,
+		ColorMode:      NeverColorMode,
+	}
+}
+
+func encodePretty(t *testing.T, enc Encoder, ent Entry, fields ...Field) string {
+	buf, err := enc.EncodeEntry(ent, fields)
+	require.NoError(t, err, "Unexpected error encoding entry.")
+	defer buf.Free()
+	return buf.String()
+}
+
+func TestPrettyEncodeEntry(t *testing.T) {
+	enc := NewPrettyEncoder(testPrettyConfig(), PrettyWidth(0))
+
+	tests := []struct {
+		desc     string
+		ent      Entry
+		fields   []Field
+		expected string
+	}{
+		{
+			desc: "columns and inline fields",
+			ent:  testEntry,
+			fields: []Field{
+				{Key: "status", Type: Int64Type, Integer: 200},
+				{Key: "path", Type: StringType, String: "/users"},
+				{Key: "query", Type: StringType, String: "a b"},
+				{Key: "empty", Type: StringType, String: ""},
+				{Key: "took", Type: DurationType, Integer: int64(time.Second)},
+				{Key: "ok", Type: BoolType, Integer: 1},
+			},
+			expected: "1970-01-01T00:00:00.000Z  INFO    main          foo.go:42  hello  " +
+				`status=200 path=/users query="a b" empty="" took=1s ok=true` + "\n" +
+				"    fake-stack\n",
+		},
+		{
+			desc:     "level padding without name or caller",
+			ent:      Entry{Level: ErrorLevel, Message: "oops"},
+			expected: "ERROR   oops\n",
+		},
+		{
+			desc: "nested values in blocks",
+			ent:  Entry{Level: WarnLevel, Message: "nested"},
+			fields: []Field{
+				{Key: "user", Type: ObjectMarshalerType, Interface: prettyUser{"ada", []string{"admin", "dev"}}},
+				{Key: "k", Type: StringType, String: "v"},
+				{Key: "users", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+					return arr.AppendObject(prettyUser{name: "bob"})
+				})},
+				{Key: "errorVerbose", Type: StringType, String: "boom\nmain.run\n\t/src/main.go:3\n"},
+				{Key: "none", Type: ArrayMarshalerType, Interface: ArrayMarshalerFunc(func(ArrayEncoder) error { return nil })},
+			},
+			expected: "WARN    nested  k=v none=[]\n" +
+				"    user:\n" +
+				"      name: ada\n" +
+				"      roles: [admin, dev]\n" +
+				"    users:\n" +
+				"      -\n" +
+				"        name: bob\n" +
+				"        roles: []\n" +
+				"    errorVerbose: |\n" +
+				"      boom\n" +
+				"      main.run\n" +
+				"      \t/src/main.go:3\n",
+		},
+		{
+			desc: "reflected and numeric values",
+			ent:  Entry{Level: DebugLevel, Message: "m"},
+			fields: []Field{
+				{Key: "r", Type: ReflectType, Interface: map[string]int{"a": 1}},
+				{Key: "f", Type: Float64Type, Integer: 4609434218613702656}, // 1.5
+				{Key: "c", Type: Complex128Type, Interface: complex(1, -2)},
+				{Key: "b", Type: BinaryType, Interface: []byte("hi")},
+			},
+			expected: `DEBUG   m  r={"a":1} f=1.5 c=1-2i b="aGk="` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, encodePretty(t, enc, tt.ent, tt.fields...), "Unexpected pretty output.")
+		})
+	}
+}
+
+func TestPrettyEncoderContext(t *testing.T) {
+	enc := NewPrettyEncoder(EncoderConfig{MessageKey: "M", ColorMode: NeverColorMode}, PrettyWidth(0))
+	enc.AddString("app", "web")
+	enc.OpenNamespace("req")
+	enc.AddInt("id", 1)
+
+	child := enc.Clone()
+	child.AddString("user", "ada")
+
+	assert.Equal(t, "m  app=web\n    req:\n      id: 1\n      extra: true\n",
+		encodePretty(t, enc, Entry{Message: "m"}, boolField("extra")),
+		"Unexpected output for the parent encoder.")
+	assert.Equal(t, "m  app=web\n    req:\n      id: 1\n      user: ada\n",
+		encodePretty(t, child, Entry{Message: "m"}),
+		"Expected clones to keep their own fields inside the open namespace.")
+	assert.Equal(t, "m  app=web\n    req:\n      id: 1\n",
+		encodePretty(t, enc, Entry{Message: "m"}),
+		"Expected encoding an entry not to change the encoder's context.")
+}
+
+func TestPrettyEncoderWidth(t *testing.T) {
+	enc := NewPrettyEncoder(EncoderConfig{MessageKey: "M", LevelKey: "L", EncodeLevel: CapitalColorLevelEncoder}, PrettyWidth(30))
+	out := encodePretty(t, enc, Entry{Level: InfoLevel, Message: "hello"},
+		strField("first", "aaaa"),
+		strField("second", "bbbb"),
+		strField("third", "cccc"),
+	)
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 2, "Expected fields to wrap onto a continuation line: %q.", out)
+	assert.True(t, strings.HasPrefix(lines[1], "    "), "Expected continuation lines to be indented.")
+	for _, l := range lines {
+		assert.LessOrEqual(t, utf8.RuneCountInString(_ansiEscape.ReplaceAllString(l, "")), 30, "Expected line %q to fit the width.", l)
+	}
+	assert.Contains(t, out, "\x1b[34mfirst\x1b[0m=aaaa", "Expected colored keys.")
+}
+
+func TestPrettyEncoderErrors(t *testing.T) {
+	enc := NewPrettyEncoder(EncoderConfig{MessageKey: "M", ColorMode: NeverColorMode}, PrettyWidth(0))
+	err := errors.New("boom")
+	assert.Equal(t, "m  error=boom\n",
+		encodePretty(t, enc, Entry{Message: "m"}, Field{Key: "error", Type: ErrorType, Interface: err}),
+		"Unexpected error rendering.")
+}
+
+func strField(k, v string) Field { return Field{Key: k, Type: StringType, String: v} }
+func boolField(k string) Field   { return Field{Key: k, Type: BoolType, Integer: 1} }
+
+var _ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
@@ -0,0 +1,113 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladcore
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Encodes program counters as an array of {function, file, line} objects,
+// minus the runtime.main and runtime.goexit frames that end every stack.
+type stackFrames []uintptr
+
+func (pcs stackFrames) MarshalLogArray(arr ArrayEncoder) error {
+	frames := runtime.CallersFrames(pcs)
+	for more := true; more; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		switch frame.Function {
+		case "", "runtime.main", "runtime.goexit":
+			continue
+		}
+		if err := arr.AppendObject(stackFrame(frame)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type stackFrame runtime.Frame
+
+func (f stackFrame) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("function", f.Function)
+	enc.AddString("file", f.File)
+	enc.AddInt("line", f.Line)
+	return nil
+}
+
+// Encodes a stack trace as formatted by the Logger, one "function" line and
+// one "\tfile:line" line per frame, optionally preceded by a
+// "goroutine N [running]:" line, as
+//
+//	{"goroutine": N, "frames": [{"function": ..., "file": ..., "line": ...}]}
+//
+// The goroutine is omitted if the stack trace doesn't name one.
+type formattedStack string
+
+func (s formattedStack) MarshalLogObject(enc ObjectEncoder) error {
+	frames := string(s)
+	if strings.HasPrefix(frames, "goroutine ") {
+		var header string
+		header, frames = cutLine(frames)
+		header = strings.TrimPrefix(header, "goroutine ")
+		if i := strings.IndexByte(header, ' '); i >= 0 {
+			header = header[:i]
+		}
+		if id, err := strconv.ParseInt(header, 10, 64); err == nil {
+			enc.AddInt64("goroutine", id)
+		}
+	}
+	return enc.AddArray("frames", formattedFrames(frames))
+}
+
+// Encodes the frames of a formatted stack trace as an array of
+// {function, file, line} objects.
+type formattedFrames string
+
+func (s formattedFrames) MarshalLogArray(arr ArrayEncoder) error {
+	for rest := string(s); rest != ""; {
+		var fn, loc string
+		fn, rest = cutLine(rest)
+		if strings.HasPrefix(rest, "\t") {
+			loc, rest = cutLine(rest[1:])
+		}
+
+		frame := runtime.Frame{Function: fn, File: loc}
+		if i := strings.LastIndexByte(loc, ':'); i >= 0 {
+			if line, err := strconv.Atoi(loc[i+1:]); err == nil {
+				frame.File, frame.Line = loc[:i], line
+			}
+		}
+		if err := arr.AppendObject(stackFrame(frame)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cutLine(s string) (line, rest string) {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
@@ -24,7 +24,7 @@ type Console struct {
 }
 
 func (c *Console) mode() ladcore.Core {
-	write := ladcore.AddSync(io.MultiWriter(os.Stdout))
+	write := ladcore.AddSync(os.Stdout)
 	config := lad.NewProductionEncoderConfig()
 	config.EncodeTime = func(t time.Time, pae ladcore.PrimitiveArrayEncoder) {
 		if c.TimeFormat == "" {
@@ -34,6 +34,7 @@ func (c *Console) mode() ladcore.Core {
 		}
 	}
 
+	// NewCore strips the colors unless stdout is a terminal.
 	config.EncodeLevel = ladcore.CapitalColorLevelEncoder
 	return ladcore.NewCore(
 		ladcore.NewConsoleEncoder(config),
@@ -0,0 +1,397 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package interceptor provides gRPC server and client interceptors that log
+// every call with lad.
+//
+// Each finished call is logged once, with its method, peer, status code,
+// duration and message sizes, at a level chosen from its status code:
+//
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(interceptor.UnaryServerInterceptor(logger)),
+//		grpc.ChainStreamInterceptor(interceptor.StreamServerInterceptor(logger)),
+//	)
+//
+// Server interceptors also place a request-scoped Logger, annotated with the
+// method and peer, in the handler's context. Retrieve it with FromContext.
+//
+// This package lives in its own module so that lad doesn't depend on
+// grpc-go.
+package interceptor // import "github.com/tnngo/lad/ladgrpc/interceptor"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tnngo/lad"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Call kinds reported in the grpc.kind field.
+const (
+	KindUnary        = "unary"
+	KindClientStream = "client_stream"
+	KindServerStream = "server_stream"
+	KindBidiStream   = "bidi_stream"
+)
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that logs
+// every finished unary call to logger.
+func UnaryServerInterceptor(logger *lad.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(true /* serverSide */, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		callLogger := logger.With(callFields(info.FullMethod, KindUnary, serverPeer(ctx))...)
+		if o.injectLogger {
+			ctx = NewContext(ctx, callLogger)
+		}
+
+		resp, err := handler(ctx, req)
+		if o.skipped(info.FullMethod) {
+			return resp, err
+		}
+
+		fields := o.finishFields(start, err)
+		fields = append(fields, sizeField("grpc.request.size", req), sizeField("grpc.response.size", resp))
+		if o.logPayloads {
+			fields = append(fields, o.payloadField("grpc.request", info.FullMethod, req))
+			if err == nil {
+				fields = append(fields, o.payloadField("grpc.response", info.FullMethod, resp))
+			}
+		}
+		o.log(callLogger, err, "finished unary call", fields)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor that logs
+// every finished streaming call to logger.
+func StreamServerInterceptor(logger *lad.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(true /* serverSide */, opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		kind := streamKind(info.IsClientStream, info.IsServerStream)
+		callLogger := logger.With(callFields(info.FullMethod, kind, serverPeer(ss.Context()))...)
+
+		ctx := ss.Context()
+		if o.injectLogger {
+			ctx = NewContext(ctx, callLogger)
+		}
+		wrapped := &serverStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			stats:        o.newStreamStats(callLogger, info.FullMethod),
+		}
+
+		err := handler(srv, wrapped)
+		if o.skipped(info.FullMethod) {
+			return err
+		}
+
+		fields := o.finishFields(start, err)
+		fields = append(fields, wrapped.stats.fields()...)
+		o.log(callLogger, err, "finished streaming call", fields)
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that logs
+// every finished unary call to logger.
+func UnaryClientInterceptor(logger *lad.Logger, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(false /* serverSide */, opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		start := time.Now()
+		var p peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(callOpts, grpc.Peer(&p))...)
+		if o.skipped(method) {
+			return err
+		}
+
+		callLogger := logger.With(callFields(method, KindUnary, peerAddr(&p))...)
+		fields := o.finishFields(start, err)
+		fields = append(fields, lad.String("grpc.target", cc.Target()))
+		fields = append(fields, sizeField("grpc.request.size", req))
+		if err == nil {
+			fields = append(fields, sizeField("grpc.response.size", reply))
+		}
+		if o.logPayloads {
+			fields = append(fields, o.payloadField("grpc.request", method, req))
+			if err == nil {
+				fields = append(fields, o.payloadField("grpc.response", method, reply))
+			}
+		}
+		o.log(callLogger, err, "finished client unary call", fields)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor that logs
+// every finished streaming call to logger.
+//
+// A client stream is considered finished when RecvMsg returns an error,
+// including io.EOF, so calls are only logged if the stream is read until
+// it ends.
+func StreamClientInterceptor(logger *lad.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(false /* serverSide */, opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		kind := streamKind(desc.ClientStreams, desc.ServerStreams)
+		p := &peer.Peer{}
+		cs, err := streamer(ctx, desc, cc, method, append(callOpts, grpc.Peer(p))...)
+
+		finish := func(callLogger *lad.Logger, stats *streamStats, err error) {
+			if o.skipped(method) {
+				return
+			}
+			fields := o.finishFields(start, err)
+			fields = append(fields, lad.String("grpc.target", cc.Target()))
+			if stats != nil {
+				fields = append(fields, stats.fields()...)
+			}
+			o.log(callLogger, err, "finished client streaming call", fields)
+		}
+
+		if err != nil {
+			finish(logger.With(callFields(method, kind, "")...), nil, err)
+			return nil, err
+		}
+		callLogger := logger.With(callFields(method, kind, "")...)
+		return &clientStream{
+			ClientStream: cs,
+			stats:        o.newStreamStats(callLogger, method),
+			finish: func(stats *streamStats, err error) {
+				finish(callLogger.With(lad.String("peer.address", peerAddr(p))), stats, err)
+			},
+		}, nil
+	}
+}
+
+func (o *options) skipped(fullMethod string) bool {
+	return o.skip != nil && o.skip(fullMethod)
+}
+
+// finishFields returns the fields common to every finished call.
+func (o *options) finishFields(start time.Time, err error) []lad.Field {
+	fields := make([]lad.Field, 0, 8)
+	fields = append(fields,
+		lad.String("grpc.code", status.Code(err).String()),
+		lad.Duration("grpc.duration", time.Since(start)),
+	)
+	if err != nil {
+		fields = append(fields, lad.Error(err))
+	}
+	return fields
+}
+
+func (o *options) log(logger *lad.Logger, err error, msg string, fields []lad.Field) {
+	if ce := logger.Check(o.levelFunc(status.Code(err)), msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+func (o *options) payloadField(key, fullMethod string, msg interface{}) lad.Field {
+	if o.redactPayload != nil {
+		msg = o.redactPayload(fullMethod, msg)
+	}
+	if msg == nil {
+		return lad.Skip()
+	}
+	return lad.Any(key, msg)
+}
+
+// callFields describes a call. Empty values are left out.
+func callFields(fullMethod, kind, peerAddress string) []lad.Field {
+	service, method := splitMethod(fullMethod)
+	fields := []lad.Field{
+		lad.String("grpc.service", service),
+		lad.String("grpc.method", method),
+		lad.String("grpc.kind", kind),
+	}
+	if peerAddress != "" {
+		fields = append(fields, lad.String("peer.address", peerAddress))
+	}
+	return fields
+}
+
+// splitMethod splits "/package.Service/Method" into its service and method.
+func splitMethod(fullMethod string) (service, method string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndexByte(fullMethod, '/'); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+func streamKind(clientStreams, serverStreams bool) string {
+	switch {
+	case clientStreams && serverStreams:
+		return KindBidiStream
+	case clientStreams:
+		return KindClientStream
+	case serverStreams:
+		return KindServerStream
+	default:
+		return KindUnary
+	}
+}
+
+func serverPeer(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return peerAddr(p)
+	}
+	return ""
+}
+
+func peerAddr(p *peer.Peer) string {
+	if p == nil || p.Addr == nil {
+		return ""
+	}
+	return p.Addr.String()
+}
+
+// sizeField reports the encoded size of protobuf messages, and is skipped
+// for anything else.
+func sizeField(key string, msg interface{}) lad.Field {
+	if m, ok := msg.(proto.Message); ok && m != nil {
+		return lad.Int(key, proto.Size(m))
+	}
+	return lad.Skip()
+}
+
+// streamStats counts the messages flowing through a stream. Payloads are
+// logged at DebugLevel as they pass, if enabled.
+type streamStats struct {
+	opts       *options
+	logger     *lad.Logger
+	fullMethod string
+
+	mu           sync.Mutex
+	sent, recv   int
+	sentB, recvB int
+}
+
+func (o *options) newStreamStats(logger *lad.Logger, fullMethod string) *streamStats {
+	return &streamStats{opts: o, logger: logger, fullMethod: fullMethod}
+}
+
+func (s *streamStats) record(msg interface{}, sent bool) {
+	size := 0
+	if m, ok := msg.(proto.Message); ok && m != nil {
+		size = proto.Size(m)
+	}
+
+	s.mu.Lock()
+	if sent {
+		s.sent++
+		s.sentB += size
+	} else {
+		s.recv++
+		s.recvB += size
+	}
+	s.mu.Unlock()
+
+	if s.opts.logPayloads && !s.opts.skipped(s.fullMethod) {
+		text := "received stream message"
+		if sent {
+			text = "sent stream message"
+		}
+		s.logger.Debug(text, s.opts.payloadField("grpc.message", s.fullMethod, msg))
+	}
+}
+
+func (s *streamStats) fields() []lad.Field {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []lad.Field{
+		lad.Int("grpc.msgs.sent", s.sent),
+		lad.Int("grpc.msgs.received", s.recv),
+		lad.Int("grpc.bytes.sent", s.sentB),
+		lad.Int("grpc.bytes.received", s.recvB),
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+
+	ctx   context.Context
+	stats *streamStats
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.stats.record(m, true /* sent */)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.stats.record(m, false /* sent */)
+	}
+	return err
+}
+
+type clientStream struct {
+	grpc.ClientStream
+
+	stats  *streamStats
+	finish func(*streamStats, error)
+	once   sync.Once
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.stats.record(m, true /* sent */)
+	} else if !errors.Is(err, io.EOF) {
+		s.done(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.stats.record(m, false /* sent */)
+	case errors.Is(err, io.EOF):
+		s.done(nil)
+	default:
+		s.done(err)
+	}
+	return err
+}
+
+func (s *clientStream) done(err error) {
+	s.once.Do(func() { s.finish(s.stats, err) })
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladtest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testServer struct {
+	testpb.UnimplementedTestServiceServer
+
+	// sawContextLogger records whether handlers found a request-scoped
+	// logger in their context.
+	sawContextLogger chan bool
+}
+
+func (s *testServer) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	s.sawContextLogger <- FromContext(ctx) != lad.L()
+	if req.ResponseStatus != nil {
+		return nil, status.Error(codes.Code(req.ResponseStatus.Code), req.ResponseStatus.Message)
+	}
+	return &testpb.SimpleResponse{Username: "jane"}, nil
+}
+
+func (s *testServer) FullDuplexCall(stream testpb.TestService_FullDuplexCallServer) error {
+	s.sawContextLogger <- FromContext(stream.Context()) != lad.L()
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: req.Payload}); err != nil {
+			return err
+		}
+	}
+}
+
+type testEnv struct {
+	client     testpb.TestServiceClient
+	server     *testServer
+	serverLogs *observer.ObservedLogs
+	clientLogs *observer.ObservedLogs
+}
+
+func newTestEnv(t *testing.T, serverOpts, clientOpts []Option) *testEnv {
+	serverCore, serverLogs := observer.New(ladcore.DebugLevel)
+	clientCore, clientLogs := observer.New(ladcore.DebugLevel)
+	serverLogger, clientLogger := lad.New(serverCore), lad.New(clientCore)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := &testServer{sawContextLogger: make(chan bool, 1)}
+	gs := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(serverLogger, serverOpts...)),
+		grpc.StreamInterceptor(StreamServerInterceptor(serverLogger, serverOpts...)),
+	)
+	testpb.RegisterTestServiceServer(gs, srv)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(clientLogger, clientOpts...)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(clientLogger, clientOpts...)),
+	)
+	require.NoError(t, err, "Failed to dial test server.")
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &testEnv{
+		client:     testpb.NewTestServiceClient(conn),
+		server:     srv,
+		serverLogs: serverLogs,
+		clientLogs: clientLogs,
+	}
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	env := newTestEnv(t, nil, nil)
+
+	_, err := env.client.UnaryCall(context.Background(), &testpb.SimpleRequest{FillUsername: true})
+	require.NoError(t, err, "Unexpected error from UnaryCall.")
+	assert.True(t, <-env.server.sawContextLogger, "Expected a request-scoped logger in the handler context.")
+
+	req := &testpb.SimpleRequest{ResponseStatus: &testpb.EchoStatus{Code: int32(codes.Internal), Message: "boom"}}
+	_, err = env.client.UnaryCall(context.Background(), req)
+	require.Error(t, err, "Expected an error from UnaryCall.")
+	<-env.server.sawContextLogger
+
+	serverLogs := env.serverLogs.FilterMessage("finished unary call").All()
+	require.Len(t, serverLogs, 2, "Expected one server entry per call.")
+
+	ok := serverLogs[0]
+	assert.Equal(t, ladcore.InfoLevel, ok.Level, "Unexpected level for a successful call.")
+	fields := ok.ContextMap()
+	assert.Equal(t, "grpc.testing.TestService", fields["grpc.service"], "Unexpected service.")
+	assert.Equal(t, "UnaryCall", fields["grpc.method"], "Unexpected method.")
+	assert.Equal(t, KindUnary, fields["grpc.kind"], "Unexpected kind.")
+	assert.Equal(t, "OK", fields["grpc.code"], "Unexpected code.")
+	assert.Equal(t, "bufconn", fields["peer.address"], "Unexpected peer.")
+	assert.Contains(t, fields, "grpc.duration", "Expected a duration.")
+	assert.Equal(t, int64(2), fields["grpc.request.size"], "Unexpected request size.")
+	assert.Equal(t, int64(6), fields["grpc.response.size"], "Unexpected response size.")
+	assert.NotContains(t, fields, "grpc.request", "Payloads should not be logged by default.")
+
+	failed := serverLogs[1]
+	assert.Equal(t, ladcore.ErrorLevel, failed.Level, "Unexpected level for an internal error.")
+	assert.Equal(t, "Internal", failed.ContextMap()["grpc.code"], "Unexpected code.")
+	assert.Equal(t, "rpc error: code = Internal desc = boom", failed.ContextMap()["error"], "Unexpected error.")
+
+	clientLogs := env.clientLogs.FilterMessage("finished client unary call").All()
+	require.Len(t, clientLogs, 2, "Expected one client entry per call.")
+	assert.Equal(t, ladcore.DebugLevel, clientLogs[0].Level, "Unexpected client level for a successful call.")
+	assert.Equal(t, ladcore.ErrorLevel, clientLogs[1].Level, "Unexpected client level for an internal error.")
+	assert.Equal(t, "bufnet", clientLogs[0].ContextMap()["grpc.target"], "Unexpected target.")
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	env := newTestEnv(t, nil, nil)
+
+	stream, err := env.client.FullDuplexCall(context.Background())
+	require.NoError(t, err, "Failed to open stream.")
+	for i := 0; i < 3; i++ {
+		require.NoError(t, stream.Send(&testpb.StreamingOutputCallRequest{
+			Payload: &testpb.Payload{Body: []byte("hello")},
+		}), "Failed to send.")
+		_, err := stream.Recv()
+		require.NoError(t, err, "Failed to receive.")
+	}
+	require.NoError(t, stream.CloseSend(), "Failed to close stream.")
+	_, err = stream.Recv()
+	require.ErrorIs(t, err, io.EOF, "Expected the stream to end.")
+	assert.True(t, <-env.server.sawContextLogger, "Expected a request-scoped logger in the stream context.")
+
+	for _, tt := range []struct {
+		logs *observer.ObservedLogs
+		msg  string
+	}{
+		{env.serverLogs, "finished streaming call"},
+		{env.clientLogs, "finished client streaming call"},
+	} {
+		entry, err := tt.logs.WaitFor(context.Background(), func(e observer.LoggedEntry) bool {
+			return e.Message == tt.msg
+		})
+		require.NoError(t, err, "Expected %q to be logged.", tt.msg)
+		fields := entry.ContextMap()
+		assert.Equal(t, KindBidiStream, fields["grpc.kind"], "Unexpected kind.")
+		assert.Equal(t, "OK", fields["grpc.code"], "Unexpected code.")
+		assert.Equal(t, int64(3), fields["grpc.msgs.sent"], "Unexpected number of messages sent.")
+		assert.Equal(t, int64(3), fields["grpc.msgs.received"], "Unexpected number of messages received.")
+		assert.Equal(t, int64(27), fields["grpc.bytes.sent"], "Unexpected number of bytes sent.")
+	}
+}
+
+func TestPayloadsAndRedaction(t *testing.T) {
+	redact := func(fullMethod string, msg interface{}) interface{} {
+		if _, ok := msg.(*testpb.SimpleResponse); ok {
+			return nil
+		}
+		return "redacted " + fullMethod
+	}
+	env := newTestEnv(t, []Option{WithPayloads(redact)}, []Option{WithPayloads(nil)})
+
+	_, err := env.client.UnaryCall(context.Background(), &testpb.SimpleRequest{FillUsername: true})
+	require.NoError(t, err, "Unexpected error from UnaryCall.")
+	<-env.server.sawContextLogger
+
+	fields := env.serverLogs.FilterMessage("finished unary call").All()[0].ContextMap()
+	assert.Equal(t, "redacted /grpc.testing.TestService/UnaryCall", fields["grpc.request"], "Expected the redacted request.")
+	assert.NotContains(t, fields, "grpc.response", "Expected the response to be omitted.")
+
+	clientFields := env.clientLogs.FilterMessage("finished client unary call").All()[0].ContextMap()
+	assert.Contains(t, clientFields, "grpc.request", "Expected the request payload.")
+	assert.Contains(t, clientFields, "grpc.response", "Expected the response payload.")
+}
+
+func TestLevelFuncSkipAndContextLogger(t *testing.T) {
+	env := newTestEnv(t,
+		[]Option{
+			WithLevelFunc(func(codes.Code) ladcore.Level { return ladcore.WarnLevel }),
+			WithoutContextLogger(),
+		},
+		[]Option{WithSkip(func(string) bool { return true })},
+	)
+
+	_, err := env.client.UnaryCall(context.Background(), &testpb.SimpleRequest{})
+	require.NoError(t, err, "Unexpected error from UnaryCall.")
+	assert.False(t, <-env.server.sawContextLogger, "Expected no request-scoped logger.")
+
+	logs := env.serverLogs.All()
+	require.Len(t, logs, 1, "Expected one server entry.")
+	assert.Equal(t, ladcore.WarnLevel, logs[0].Level, "Expected the custom level.")
+	assert.Zero(t, env.clientLogs.Len(), "Expected skipped calls not to be logged.")
+}
+
+func TestSplitMethod(t *testing.T) {
+	tests := []struct {
+		give, service, method string
+	}{
+		{"/pkg.Service/Method", "pkg.Service", "Method"},
+		{"Method", "unknown", "Method"},
+	}
+	for _, tt := range tests {
+		service, method := splitMethod(tt.give)
+		assert.Equal(t, tt.service, service, "Unexpected service for %q.", tt.give)
+		assert.Equal(t, tt.method, method, "Unexpected method for %q.", tt.give)
+	}
+}
+
+func TestDefaultLevels(t *testing.T) {
+	assert.Equal(t, ladcore.InfoLevel, DefaultServerLevel(codes.NotFound), "Unexpected server level.")
+	assert.Equal(t, ladcore.WarnLevel, DefaultServerLevel(codes.Unavailable), "Unexpected server level.")
+	assert.Equal(t, ladcore.ErrorLevel, DefaultServerLevel(codes.DataLoss), "Unexpected server level.")
+	assert.Equal(t, ladcore.WarnLevel, DefaultClientLevel(codes.NotFound), "Unexpected client level.")
+}
+
+func TestFromContextFallback(t *testing.T) {
+	assert.Equal(t, lad.L(), FromContext(context.Background()), "Expected the global logger.")
+	logger := lad.NewNop()
+	assert.Equal(t, logger, FromContext(NewContext(context.Background(), logger)), "Expected the stored logger.")
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package interceptor
+
+import (
+	"github.com/tnngo/lad/ladcore"
+	"google.golang.org/grpc/codes"
+)
+
+// An Option configures the interceptors.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) {
+	f(opts)
+}
+
+// LevelFunc decides the level at which a finished call is logged, based on
+// its status code.
+type LevelFunc func(codes.Code) ladcore.Level
+
+// PayloadRedactor returns the value that's logged in place of a request or
+// response message of the given method. Returning nil omits the message.
+type PayloadRedactor func(fullMethod string, msg interface{}) interface{}
+
+type options struct {
+	levelFunc     LevelFunc
+	logPayloads   bool
+	redactPayload PayloadRedactor
+	injectLogger  bool
+	skip          func(fullMethod string) bool
+}
+
+func newOptions(serverSide bool, opts []Option) *options {
+	o := &options{
+		levelFunc:    DefaultServerLevel,
+		injectLogger: true,
+	}
+	if !serverSide {
+		o.levelFunc = DefaultClientLevel
+	}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+// WithLevelFunc overrides the mapping of status codes to levels. It defaults
+// to DefaultServerLevel for server interceptors and DefaultClientLevel for
+// client interceptors.
+func WithLevelFunc(f LevelFunc) Option {
+	return optionFunc(func(opts *options) {
+		opts.levelFunc = f
+	})
+}
+
+// WithPayloads enables logging of request and response messages, which is
+// disabled by default. Each message is passed through redact, if it isn't
+// nil, before being logged with lad.Any.
+func WithPayloads(redact PayloadRedactor) Option {
+	return optionFunc(func(opts *options) {
+		opts.logPayloads = true
+		opts.redactPayload = redact
+	})
+}
+
+// WithoutContextLogger stops server interceptors from placing a
+// request-scoped Logger in the handler's context.
+func WithoutContextLogger() Option {
+	return optionFunc(func(opts *options) {
+		opts.injectLogger = false
+	})
+}
+
+// WithSkip disables logging for the methods for which skip returns true,
+// such as health checks. The request-scoped Logger is still injected.
+func WithSkip(skip func(fullMethod string) bool) Option {
+	return optionFunc(func(opts *options) {
+		opts.skip = skip
+	})
+}
+
+// DefaultServerLevel logs successful calls and client mistakes at
+// InfoLevel, conditions that may need attention at WarnLevel, and server
+// failures at ErrorLevel.
+func DefaultServerLevel(code codes.Code) ladcore.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return ladcore.InfoLevel
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return ladcore.WarnLevel
+	default:
+		return ladcore.ErrorLevel
+	}
+}
+
+// DefaultClientLevel logs successful calls at DebugLevel, and every failure
+// at WarnLevel or above, since the client can't tell whose fault it was.
+func DefaultClientLevel(code codes.Code) ladcore.Level {
+	switch code {
+	case codes.OK:
+		return ladcore.DebugLevel
+	case codes.Unknown, codes.Unimplemented, codes.Internal, codes.DataLoss:
+		return ladcore.ErrorLevel
+	default:
+		return ladcore.WarnLevel
+	}
+}
@@ -49,3 +49,26 @@ func TestLoggerV2(t *testing.T) {
 	assert.Equal(t, "hello from grpc", entry.Message,
 		"Log entry message did not match.")
 }
+
+func TestDepthLoggerV2(t *testing.T) {
+	core, observedLogs := observer.New(ladcore.InfoLevel)
+	zlog := lad.New(core, lad.AddCaller())
+
+	grpclog.SetLoggerV2(ladgrpc.NewLogger(zlog, ladgrpc.WithVerbosity(2)))
+
+	grpclog.Component("core").Infof("hello from %v", "component")
+
+	logs := observedLogs.TakeAll()
+	require.Len(t, logs, 1, "Expected one log entry.")
+	entry := logs[0]
+
+	assert.Equal(t, "hello from component", entry.Message,
+		"Log entry message did not match.")
+	assert.Equal(t, map[string]interface{}{"grpc.component": "core"}, entry.ContextMap(),
+		"Expected the component as a field.")
+	assert.Contains(t, entry.Caller.Function, "TestDepthLoggerV2",
+		"Expected the entry to be attributed to the component's caller.")
+
+	assert.True(t, grpclog.V(2), "Expected verbosity 2 to be enabled.")
+	assert.False(t, grpclog.V(3), "Expected verbosity 3 to be disabled.")
+}
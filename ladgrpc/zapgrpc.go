@@ -23,6 +23,8 @@ package ladgrpc // import "github.com/tnngo/lad/ladgrpc"
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/tnngo/lad"
 	"github.com/tnngo/lad/ladcore"
@@ -45,6 +47,13 @@ var _grpcToZapLevel = map[int]ladcore.Level{
 	grpcLvlFatal: ladcore.FatalLevel,
 }
 
+// _defaultComponentKey is the field key used for gRPC component names.
+const _defaultComponentKey = "grpc.component"
+
+// _verbosityEnv is the environment variable read by grpc-go's own logger to
+// determine its verbosity.
+const _verbosityEnv = "GRPC_GO_LOG_VERBOSITY_LEVEL"
+
 // An Option overrides a Logger's default configuration.
 type Option interface {
 	apply(*Logger)
@@ -72,6 +81,36 @@ func WithDebug() Option {
 	})
 }
 
+// WithVerbosity configures the verbosity threshold reported by V: V(l)
+// returns true for every l up to and including v, as long as InfoLevel is
+// enabled.
+//
+// Without this option, V treats its argument as a gRPC severity (info,
+// warning, error or fatal) and reports whether the matching lad level is
+// enabled.
+func WithVerbosity(v int) Option {
+	return optionFunc(func(logger *Logger) {
+		logger.verbosity = v
+		logger.hasVerbosity = true
+	})
+}
+
+// WithVerbosityFromEnv is like WithVerbosity, but reads the threshold from
+// the GRPC_GO_LOG_VERBOSITY_LEVEL environment variable, as grpc-go's own
+// logger does. A missing or invalid value sets a threshold of 0.
+func WithVerbosityFromEnv() Option {
+	v, _ := strconv.Atoi(os.Getenv(_verbosityEnv))
+	return WithVerbosity(v)
+}
+
+// WithComponentKey sets the key of the field holding the name of the gRPC
+// component that logged a message. It defaults to "grpc.component".
+func WithComponentKey(key string) Option {
+	return optionFunc(func(logger *Logger) {
+		logger.componentKey = key
+	})
+}
+
 // withWarn redirects the fatal level to the warn level, which makes testing
 // easier. This is intentionally unexported.
 func withWarn() Option {
@@ -89,7 +128,9 @@ func withWarn() Option {
 func NewLogger(l *lad.Logger, options ...Option) *Logger {
 	logger := &Logger{
 		delegate:     l.Sugar(),
+		desugared:    l,
 		levelEnabler: l.Core(),
+		componentKey: _defaultComponentKey,
 	}
 	logger.print = &printer{
 		enab:   logger.levelEnabler,
@@ -134,12 +175,17 @@ func (v *printer) Println(args ...interface{}) {
 	}
 }
 
-// Logger adapts zap's Logger to be compatible with grpclog.LoggerV2 and the deprecated grpclog.Logger.
+// Logger adapts zap's Logger to be compatible with grpclog.LoggerV2,
+// grpclog.DepthLoggerV2 and the deprecated grpclog.Logger.
 type Logger struct {
 	delegate     *lad.SugaredLogger
+	desugared    *lad.Logger
 	levelEnabler ladcore.LevelEnabler
 	print        *printer
 	fatal        *printer
+	componentKey string
+	verbosity    int
+	hasVerbosity bool
 	// printToDebug bool
 	// fatalToWarn  bool
 }
@@ -233,9 +279,58 @@ func (l *Logger) Fatalf(format string, args ...interface{}) {
 
 // V implements grpclog.LoggerV2.
 func (l *Logger) V(level int) bool {
+	if l.hasVerbosity {
+		return level <= l.verbosity && l.levelEnabler.Enabled(ladcore.InfoLevel)
+	}
 	return l.levelEnabler.Enabled(_grpcToZapLevel[level])
 }
 
+// InfoDepth implements grpclog.DepthLoggerV2.
+func (l *Logger) InfoDepth(depth int, args ...interface{}) {
+	l.logDepth(ladcore.InfoLevel, depth, args)
+}
+
+// WarningDepth implements grpclog.DepthLoggerV2.
+func (l *Logger) WarningDepth(depth int, args ...interface{}) {
+	l.logDepth(ladcore.WarnLevel, depth, args)
+}
+
+// ErrorDepth implements grpclog.DepthLoggerV2.
+func (l *Logger) ErrorDepth(depth int, args ...interface{}) {
+	l.logDepth(ladcore.ErrorLevel, depth, args)
+}
+
+// FatalDepth implements grpclog.DepthLoggerV2.
+func (l *Logger) FatalDepth(depth int, args ...interface{}) {
+	l.logDepth(l.fatal.level, depth, args)
+}
+
+// logDepth logs args in the manner of fmt.Println, attributing the entry to
+// the caller depth frames above grpclog's dispatching function. A leading
+// "[name]" argument, which grpclog.Component adds, is logged as a field
+// instead of being part of the message.
+//
+// It must be called directly by one of the *Depth methods.
+func (l *Logger) logDepth(lvl ladcore.Level, depth int, args []interface{}) {
+	if lvl < ladcore.DPanicLevel && !l.levelEnabler.Enabled(lvl) {
+		return
+	}
+
+	var fields []lad.Field
+	if len(args) > 0 {
+		if s, ok := args[0].(string); ok && len(s) > 2 && s[0] == '[' && s[len(s)-1] == ']' {
+			fields = append(fields, lad.String(l.componentKey, s[1:len(s)-1]))
+			args = args[1:]
+		}
+	}
+
+	// Skip logDepth, the *Depth method, and grpclog's dispatching function.
+	logger := l.desugared.WithOptions(lad.AddCallerSkip(depth + 3))
+	if ce := logger.Check(lvl, sprintln(args)); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
 func sprintln(args []interface{}) string {
 	s := fmt.Sprintln(args...)
 	// Drop the new line character added by Sprintln
@@ -261,3 +261,101 @@ func withLogger(
 	core, observedLogs := observer.New(enab)
 	f(NewLogger(lad.New(core), append(opts, withWarn())...), observedLogs)
 }
+
+func TestLoggerVerbosity(t *testing.T) {
+	tests := []struct {
+		desc     string
+		enab     ladcore.LevelEnabler
+		opts     []Option
+		enabled  []int
+		disabled []int
+	}{
+		{
+			desc:     "threshold",
+			enab:     ladcore.InfoLevel,
+			opts:     []Option{WithVerbosity(2)},
+			enabled:  []int{0, 1, 2},
+			disabled: []int{3, 4, 99},
+		},
+		{
+			desc:     "info disabled",
+			enab:     ladcore.WarnLevel,
+			opts:     []Option{WithVerbosity(99)},
+			disabled: []int{0, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withLogger(tt.enab, tt.opts, func(logger *Logger, _ *observer.ObservedLogs) {
+				for _, v := range tt.enabled {
+					require.True(t, logger.V(v), "Expected verbosity %d to be enabled.", v)
+				}
+				for _, v := range tt.disabled {
+					require.False(t, logger.V(v), "Expected verbosity %d to be disabled.", v)
+				}
+			})
+		})
+	}
+}
+
+func TestLoggerVerbosityFromEnv(t *testing.T) {
+	t.Setenv("GRPC_GO_LOG_VERBOSITY_LEVEL", "3")
+	withLogger(ladcore.InfoLevel, []Option{WithVerbosityFromEnv()}, func(logger *Logger, _ *observer.ObservedLogs) {
+		require.True(t, logger.V(3), "Expected verbosity 3 to be enabled.")
+		require.False(t, logger.V(4), "Expected verbosity 4 to be disabled.")
+	})
+}
+
+// dispatchInfoDepth mimics grpclog's dispatching function, which forwards
+// the depth unchanged.
+func dispatchInfoDepth(logger *Logger, depth int, args ...interface{}) {
+	logger.InfoDepth(depth, args...)
+}
+
+// componentInfo mimics a grpclog.Component logging at depth 1.
+func componentInfo(logger *Logger, args ...interface{}) {
+	dispatchInfoDepth(logger, 1, append([]interface{}{"[core]"}, args...)...)
+}
+
+func TestLoggerDepth(t *testing.T) {
+	core, logs := observer.New(ladcore.DebugLevel)
+	logger := NewLogger(lad.New(core, lad.AddCaller()), withWarn())
+
+	componentInfo(logger, "channel", "created")
+	dispatchInfoDepth(logger, 0, "plain")
+	logger.WarningDepth(0, "[transport]", "closing")
+	logger.ErrorDepth(0, "failed")
+	logger.FatalDepth(0, "[]", "not a component")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 5, "Unexpected number of entries.")
+
+	require.Equal(t, "channel created", entries[0].Message, "Unexpected message.")
+	require.Equal(t, map[string]interface{}{"grpc.component": "core"}, entries[0].ContextMap(), "Expected component field.")
+	require.Contains(t, entries[0].Caller.Function, "TestLoggerDepth", "Expected the caller of the component.")
+
+	require.Equal(t, "plain", entries[1].Message, "Unexpected message.")
+	require.Empty(t, entries[1].Context, "Expected no fields without a component.")
+	require.Contains(t, entries[1].Caller.Function, "TestLoggerDepth", "Expected the caller of the dispatcher.")
+
+	require.Equal(t, ladcore.WarnLevel, entries[2].Level, "Unexpected level.")
+	require.Equal(t, "transport", entries[2].ContextMap()["grpc.component"], "Unexpected component.")
+	require.Equal(t, ladcore.ErrorLevel, entries[3].Level, "Unexpected level.")
+	require.Equal(t, ladcore.WarnLevel, entries[4].Level, "Expected fatal to be redirected to warn.")
+	require.Equal(t, "[] not a component", entries[4].Message, "Expected empty brackets to stay in the message.")
+}
+
+func TestLoggerComponentKey(t *testing.T) {
+	withLogger(ladcore.DebugLevel, []Option{WithComponentKey("component")}, func(logger *Logger, logs *observer.ObservedLogs) {
+		logger.InfoDepth(0, "[dns]", "resolved")
+		require.Equal(t, map[string]interface{}{"component": "dns"}, logs.All()[0].ContextMap(), "Unexpected fields.")
+	})
+}
+
+func TestLoggerDepthDisabled(t *testing.T) {
+	withLogger(ladcore.ErrorLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		logger.InfoDepth(0, "[core]", "dropped")
+		require.Zero(t, logs.Len(), "Expected disabled levels to be dropped.")
+	})
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ladhttp provides net/http middleware that writes an access log
+// entry for every request.
+//
+//	mux := http.NewServeMux()
+//	// ...
+//	http.ListenAndServe(":8080", ladhttp.NewHandler(logger, mux))
+//
+// Each entry carries the method, path, status, response size, duration,
+// remote address, user agent and request ID of a request, and is logged at
+// a level chosen from the class of its status code.
+package ladhttp // import "github.com/tnngo/lad/ladhttp"
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tnngo/lad"
+)
+
+// _combinedTimeFormat is the timestamp layout of the NCSA combined format.
+const _combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// Middleware returns a function that wraps handlers with NewHandler.
+func Middleware(logger *lad.Logger, opts ...Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return NewHandler(logger, next, opts...)
+	}
+}
+
+// NewHandler wraps next so that every request it serves is logged to
+// logger once the response is complete. If next panics, the request is
+// logged with status 500 before the panic carries on.
+func NewHandler(logger *lad.Logger, next http.Handler, opts ...Option) http.Handler {
+	return &handler{
+		logger: logger,
+		next:   next,
+		opts:   newOptions(opts),
+	}
+}
+
+type handler struct {
+	logger *lad.Logger
+	next   http.Handler
+	opts   *options
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := h.opts.clock.Now()
+	requestID := r.Header.Get(h.opts.requestIDHeader)
+
+	if h.opts.contextLogger {
+		fields := []lad.Field{
+			lad.String("http.method", r.Method),
+			lad.String("http.path", r.URL.Path),
+		}
+		if requestID != "" {
+			fields = append(fields, lad.String("http.request_id", requestID))
+		}
+		r = r.WithContext(NewContext(r.Context(), h.logger.With(fields...)))
+	}
+
+	rw := &responseWriter{ResponseWriter: w}
+	defer func() {
+		if p := recover(); p != nil {
+			// net/http aborts the response of a handler that panics, so
+			// whatever was written, the request failed.
+			rw.status = http.StatusInternalServerError
+			h.log(r, rw, start, requestID)
+			panic(p)
+		}
+	}()
+	h.next.ServeHTTP(rw, r)
+	h.log(r, rw, start, requestID)
+}
+
+// log writes the access log entry for a request.
+func (h *handler) log(r *http.Request, rw *responseWriter, start time.Time, requestID string) {
+	if h.opts.skipped(r) {
+		return
+	}
+	status := rw.Status()
+	lvl := h.opts.level(status)
+	if !h.logger.Core().Enabled(lvl) {
+		return
+	}
+	duration := h.opts.clock.Now().Sub(start)
+
+	if h.opts.combined {
+		h.logger.Log(lvl, combinedLine(r, start, status, rw.bytes))
+		return
+	}
+
+	fields := make([]lad.Field, 0, 10)
+	fields = append(fields,
+		lad.String("http.method", r.Method),
+		lad.String("http.path", r.URL.Path),
+	)
+	if h.opts.routeFunc != nil {
+		if route := h.opts.routeFunc(r); route != "" {
+			fields = append(fields, lad.String("http.route", route))
+		}
+	}
+	fields = append(fields,
+		lad.Int("http.status", status),
+		lad.Int64("http.bytes", rw.bytes),
+		lad.Duration("http.duration", duration),
+		lad.String("http.remote_addr", r.RemoteAddr),
+		lad.String("http.user_agent", r.UserAgent()),
+	)
+	if requestID != "" {
+		fields = append(fields, lad.String("http.request_id", requestID))
+	}
+	h.logger.Log(lvl, "handled request", fields...)
+}
+
+// combinedLine formats a request in the NCSA combined log format.
+func combinedLine(r *http.Request, start time.Time, status int, bytes int64) string {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	} else if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	}
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s %s %s",
+		orDash(host),
+		user,
+		start.Format(_combinedTimeFormat),
+		r.Method,
+		r.URL.RequestURI(),
+		r.Proto,
+		status,
+		size,
+		quoteOrDash(r.Referer()),
+		quoteOrDash(r.UserAgent()),
+	)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func quoteOrDash(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+	return strconv.Quote(s)
+}
+
+// responseWriter records the status code and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int64
+}
+
+var (
+	_ http.Flusher  = (*responseWriter)(nil)
+	_ http.Hijacker = (*responseWriter)(nil)
+	_ io.ReaderFrom = (*responseWriter)(nil)
+)
+
+// Status returns the status code of the response, which is 200 if the
+// handler never set one.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational responses such as 103 Early Hints precede the final
+	// one, except for 101 Switching Protocols, which ends the exchange.
+	if w.status == 0 && (status >= http.StatusOK || status == http.StatusSwitchingProtocols) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// ReadFrom lets io.Copy use the wrapped ResponseWriter's ReadFrom method,
+// which serves files with sendfile where the platform supports it.
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	var (
+		n   int64
+		err error
+	)
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		// Hide this method from io.Copy to keep it from calling it again.
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, src)
+	}
+	w.bytes += n
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		if w.status == 0 {
+			w.status = http.StatusSwitchingProtocols
+		}
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("ladhttp: underlying ResponseWriter doesn't support hijacking")
+}
+
+// Unwrap returns the wrapped ResponseWriter for use by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladhttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/internal/ztest"
+	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladtest/observer"
+)
+
+func serve(t *testing.T, h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerFields(t *testing.T) {
+	core, logs := observer.New(ladcore.DebugLevel)
+	clock := ztest.NewMockClock()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Add(42 * time.Millisecond)
+		_, _ = w.Write([]byte("hello"))
+	})
+	h := NewHandler(lad.New(core), next,
+		WithClock(clock),
+		WithRouteFunc(func(*http.Request) string { return "/users/{id}" }),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42?q=1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("X-Request-Id", "req-1")
+	serve(t, h, req)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "Expected one entry.")
+	assert.Equal(t, ladcore.InfoLevel, entries[0].Level, "Unexpected level.")
+	assert.Equal(t, "handled request", entries[0].Message, "Unexpected message.")
+	assert.Equal(t, map[string]interface{}{
+		"http.method":      "GET",
+		"http.path":        "/users/42",
+		"http.route":       "/users/{id}",
+		"http.status":      int64(200),
+		"http.bytes":       int64(5),
+		"http.duration":    42 * time.Millisecond,
+		"http.remote_addr": "192.0.2.1:1234",
+		"http.user_agent":  "test-agent",
+		"http.request_id":  "req-1",
+	}, entries[0].ContextMap(), "Unexpected fields.")
+}
+
+func TestHandlerLevels(t *testing.T) {
+	tests := []struct {
+		status int
+		opts   []Option
+		want   ladcore.Level
+	}{
+		{http.StatusOK, nil, ladcore.InfoLevel},
+		{http.StatusFound, nil, ladcore.InfoLevel},
+		{http.StatusNotFound, nil, ladcore.WarnLevel},
+		{http.StatusBadGateway, nil, ladcore.ErrorLevel},
+		{http.StatusNotFound, []Option{WithClassLevel(4, ladcore.DebugLevel)}, ladcore.DebugLevel},
+		{http.StatusOK, []Option{WithClassLevel(2, ladcore.DebugLevel)}, ladcore.DebugLevel},
+		{http.StatusTeapot, []Option{WithLevelFunc(func(int) ladcore.Level { return ladcore.DPanicLevel })}, ladcore.DPanicLevel},
+		{999, nil, ladcore.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		core, logs := observer.New(ladcore.DebugLevel)
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		})
+		serve(t, NewHandler(lad.New(core), next, tt.opts...), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		entries := logs.All()
+		require.Len(t, entries, 1, "Expected one entry for status %d.", tt.status)
+		assert.Equal(t, tt.want, entries[0].Level, "Unexpected level for status %d.", tt.status)
+		assert.Equal(t, int64(tt.status), entries[0].ContextMap()["http.status"], "Unexpected status.")
+	}
+}
+
+func TestHandlerSkipAndDisabled(t *testing.T) {
+	core, logs := observer.New(ladcore.InfoLevel)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := Middleware(lad.New(core),
+		WithSkipPaths("/healthz", "/readyz"),
+		WithSkip(func(r *http.Request) bool { return r.Method == http.MethodOptions }),
+		WithClassLevel(2, ladcore.DebugLevel),
+	)(next)
+
+	serve(t, h, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	serve(t, h, httptest.NewRequest(http.MethodOptions, "/", nil))
+	serve(t, h, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Zero(t, logs.Len(), "Expected skipped and disabled requests not to be logged.")
+}
+
+func TestHandlerContextLogger(t *testing.T) {
+	core, logs := observer.New(ladcore.InfoLevel)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("inside handler")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("X-Trace", "trace-1")
+	serve(t, NewHandler(lad.New(core), next, WithContextLogger(), WithRequestIDHeader("X-Trace")), req)
+
+	entries := logs.FilterMessage("inside handler").All()
+	require.Len(t, entries, 1, "Expected the handler's entry.")
+	assert.Equal(t, map[string]interface{}{
+		"http.method":     "POST",
+		"http.path":       "/orders",
+		"http.request_id": "trace-1",
+	}, entries[0].ContextMap(), "Expected request-scoped fields.")
+
+	assert.Equal(t, lad.L(), FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()), "Expected the global logger without a request-scoped one.")
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time                       { return time.Time(c) }
+func (c fixedClock) NewTicker(time.Duration) *time.Ticker { return nil }
+
+func TestHandlerCombinedFormat(t *testing.T) {
+	core, logs := observer.New(ladcore.InfoLevel)
+	clock := fixedClock(time.Date(2000, time.October, 10, 20, 55, 36, 0, time.FixedZone("", -7*60*60)))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(make([]byte, 2326))
+	})
+	h := NewHandler(lad.New(core), next, WithCombinedFormat(), WithClock(clock))
+
+	req := httptest.NewRequest(http.MethodGet, "/apache_pb.gif?x=1", nil)
+	req.Proto = "HTTP/1.0"
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "http://www.example.com/start.html")
+	req.Header.Set("User-Agent", "Mozilla/4.08")
+	serve(t, h, req)
+
+	const wantTime = "10/Oct/2000:20:55:36 -0700"
+	entries := logs.All()
+	require.Len(t, entries, 1, "Expected one entry.")
+	assert.Equal(t,
+		`127.0.0.1 - frank [`+wantTime+`] "GET /apache_pb.gif?x=1 HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+		entries[0].Message, "Unexpected combined log line.")
+	assert.Empty(t, entries[0].Context, "Expected no structured fields in combined mode.")
+
+	logs.TakeAll()
+	req = httptest.NewRequest(http.MethodHead, "/", nil)
+	req.RemoteAddr = ""
+	serve(t, NewHandler(lad.New(core), http.NotFoundHandler(), WithCombinedFormat(), WithClock(clock)), req)
+	assert.Equal(t, `- - - [`+wantTime+`] "HEAD / HTTP/1.1" 404 19 "-" "-"`, logs.All()[0].Message, "Unexpected line for an anonymous request.")
+}
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	rec := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rw := &responseWriter{ResponseWriter: rec}
+
+	_, _, err := rw.Hijack()
+	require.NoError(t, err, "Unexpected error hijacking.")
+	assert.True(t, rec.hijacked, "Expected Hijack to be forwarded.")
+	assert.Equal(t, http.StatusSwitchingProtocols, rw.Status(), "Unexpected status after hijacking.")
+
+	rw = &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	_, _, err = rw.Hijack()
+	assert.Error(t, err, "Expected an error when hijacking isn't supported.")
+
+	rw.Flush()
+	assert.True(t, rw.ResponseWriter.(*httptest.ResponseRecorder).Flushed, "Expected Flush to be forwarded.")
+	assert.Equal(t, http.StatusOK, rw.Status(), "Expected flushing to imply a 200.")
+	assert.Equal(t, rw.ResponseWriter, rw.Unwrap(), "Unexpected unwrapped writer.")
+}
+
+func TestHandlerFinalStatus(t *testing.T) {
+	core, logs := observer.New(ladcore.DebugLevel)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	serve(t, NewHandler(lad.New(core), next), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "Expected one entry.")
+	assert.Equal(t, int64(http.StatusNotFound), entries[0].ContextMap()["http.status"],
+		"Expected the final status rather than the informational one.")
+
+	rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	rw.WriteHeader(http.StatusSwitchingProtocols)
+	assert.Equal(t, http.StatusSwitchingProtocols, rw.Status(), "Expected 101 to count as final.")
+}
+
+func TestHandlerPanic(t *testing.T) {
+	core, logs := observer.New(ladcore.DebugLevel)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	})
+	h := NewHandler(lad.New(core), next)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		serve(t, h, httptest.NewRequest(http.MethodGet, "/", nil))
+	}, "Expected the panic to be passed on.")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "Expected the request to be logged.")
+	assert.Equal(t, ladcore.ErrorLevel, entries[0].Level, "Unexpected level.")
+	assert.Equal(t, int64(http.StatusInternalServerError), entries[0].ContextMap()["http.status"],
+		"Expected a panicking handler to be logged as a 500.")
+}
+
+// readerFromRecorder is a ResponseRecorder that implements io.ReaderFrom,
+// like the http.ResponseWriter of net/http.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+
+	readFrom bool
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	rec := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rw := &responseWriter{ResponseWriter: rec}
+	// Hide strings.Reader's WriteTo method, which io.Copy would prefer.
+	n, err := io.Copy(rw, struct{ io.Reader }{strings.NewReader("hello")})
+	require.NoError(t, err, "Unexpected error copying.")
+	assert.Equal(t, int64(5), n, "Unexpected number of bytes copied.")
+	assert.True(t, rec.readFrom, "Expected ReadFrom to be forwarded.")
+	assert.Equal(t, int64(5), rw.bytes, "Unexpected response size.")
+	assert.Equal(t, http.StatusOK, rw.Status(), "Expected copying to imply a 200.")
+
+	plain := httptest.NewRecorder()
+	rw = &responseWriter{ResponseWriter: plain}
+	_, err = io.Copy(rw, struct{ io.Reader }{strings.NewReader("hello")})
+	require.NoError(t, err, "Unexpected error copying.")
+	assert.Equal(t, "hello", plain.Body.String(), "Unexpected body.")
+	assert.Equal(t, int64(5), rw.bytes, "Unexpected response size.")
+}
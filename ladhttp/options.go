@@ -0,0 +1,176 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladhttp
+
+import (
+	"net/http"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+// An Option configures a Handler.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) {
+	f(opts)
+}
+
+// LevelFunc decides the level at which a request is logged, based on the
+// status code of its response.
+type LevelFunc func(status int) ladcore.Level
+
+type options struct {
+	classLevels     [6]ladcore.Level // indexed by status / 100
+	levelFunc       LevelFunc
+	skipPaths       map[string]struct{}
+	skip            func(*http.Request) bool
+	routeFunc       func(*http.Request) string
+	requestIDHeader string
+	contextLogger   bool
+	combined        bool
+	clock           ladcore.Clock
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		classLevels: [6]ladcore.Level{
+			ladcore.InfoLevel,  // unknown
+			ladcore.InfoLevel,  // 1xx
+			ladcore.InfoLevel,  // 2xx
+			ladcore.InfoLevel,  // 3xx
+			ladcore.WarnLevel,  // 4xx
+			ladcore.ErrorLevel, // 5xx
+		},
+		requestIDHeader: "X-Request-Id",
+		clock:           ladcore.DefaultClock,
+	}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+// WithClassLevel sets the level for responses whose status code is in the
+// given class, from 1 for 1xx to 5 for 5xx. By default, 4xx responses are
+// logged at WarnLevel, 5xx responses at ErrorLevel and everything else at
+// InfoLevel.
+func WithClassLevel(class int, level ladcore.Level) Option {
+	return optionFunc(func(opts *options) {
+		if class >= 1 && class < len(opts.classLevels) {
+			opts.classLevels[class] = level
+		}
+	})
+}
+
+// WithLevelFunc overrides the mapping of status codes to levels, taking
+// precedence over WithClassLevel.
+func WithLevelFunc(f LevelFunc) Option {
+	return optionFunc(func(opts *options) {
+		opts.levelFunc = f
+	})
+}
+
+// WithSkipPaths disables logging of requests for the given URL paths, such
+// as health checks.
+func WithSkipPaths(paths ...string) Option {
+	return optionFunc(func(opts *options) {
+		if opts.skipPaths == nil {
+			opts.skipPaths = make(map[string]struct{}, len(paths))
+		}
+		for _, p := range paths {
+			opts.skipPaths[p] = struct{}{}
+		}
+	})
+}
+
+// WithSkip disables logging of requests for which skip returns true.
+func WithSkip(skip func(*http.Request) bool) Option {
+	return optionFunc(func(opts *options) {
+		opts.skip = skip
+	})
+}
+
+// WithRouteFunc reports the route pattern that matched a request, such as
+// "/users/{id}", in the http.route field. It's called after the wrapped
+// handler returns, so routers that record the matched route on the request
+// can be supported.
+func WithRouteFunc(f func(*http.Request) string) Option {
+	return optionFunc(func(opts *options) {
+		opts.routeFunc = f
+	})
+}
+
+// WithRequestIDHeader sets the request header holding the request ID. It
+// defaults to X-Request-Id.
+func WithRequestIDHeader(header string) Option {
+	return optionFunc(func(opts *options) {
+		opts.requestIDHeader = header
+	})
+}
+
+// WithContextLogger places a request-scoped Logger, annotated with the
+// method, path and request ID, in the request's context. Retrieve it with
+// FromContext.
+func WithContextLogger() Option {
+	return optionFunc(func(opts *options) {
+		opts.contextLogger = true
+	})
+}
+
+// WithCombinedFormat logs each request as a line in the Apache/NCSA
+// combined log format instead of as structured fields:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+func WithCombinedFormat() Option {
+	return optionFunc(func(opts *options) {
+		opts.combined = true
+	})
+}
+
+// WithClock sets the source of time used to measure requests. It defaults
+// to ladcore.DefaultClock.
+func WithClock(clock ladcore.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func (o *options) level(status int) ladcore.Level {
+	if o.levelFunc != nil {
+		return o.levelFunc(status)
+	}
+	class := status / 100
+	if class < 0 || class >= len(o.classLevels) {
+		class = 0
+	}
+	return o.classLevels[class]
+}
+
+func (o *options) skipped(r *http.Request) bool {
+	if _, ok := o.skipPaths[r.URL.Path]; ok {
+		return true
+	}
+	return o.skip != nil && o.skip(r)
+}
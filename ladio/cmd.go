@@ -0,0 +1,280 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladio
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// _jsonMessageKeys and _jsonReservedKeys list keys of JSON log lines that
+// are consumed by the decoder rather than turned into fields.
+var (
+	_jsonMessageKeys  = []string{"msg", "message"}
+	_jsonReservedKeys = map[string]struct{}{
+		"level": {}, "severity": {}, "lvl": {},
+		"ts": {}, "time": {}, "timestamp": {},
+		"caller": {},
+	}
+)
+
+// A CmdOption configures a Cmd.
+type CmdOption interface {
+	apply(*Cmd)
+}
+
+// cmdOptionFunc wraps a func so it satisfies the CmdOption interface.
+type cmdOptionFunc func(*Cmd)
+
+func (f cmdOptionFunc) apply(c *Cmd) {
+	f(c)
+}
+
+// WithStdoutLevel sets the level at which lines written to the child's
+// standard output are logged. Defaults to Info.
+func WithStdoutLevel(lvl ladcore.Level) CmdOption {
+	return cmdOptionFunc(func(c *Cmd) {
+		c.stdoutLevel = lvl
+	})
+}
+
+// WithStderrLevel sets the level at which lines written to the child's
+// standard error are logged. Defaults to Warn.
+func WithStderrLevel(lvl ladcore.Level) CmdOption {
+	return cmdOptionFunc(func(c *Cmd) {
+		c.stderrLevel = lvl
+	})
+}
+
+// WithLevelDetection logs each line at the level detected by DetectLevel,
+// falling back to the stream's level.
+func WithLevelDetection() CmdOption {
+	return cmdOptionFunc(func(c *Cmd) {
+		c.detectLevel = true
+	})
+}
+
+// WithJSONDecoding decodes lines that hold JSON objects, such as those
+// written by a child process that logs with lad's JSON encoder. The object's
+// "msg" or "message" value becomes the message of the entry and its other
+// keys become fields, rather than logging the whole line as a string.
+// Levels, timestamps and callers in the object are dropped; combine with
+// WithLevelDetection to honor the child's levels.
+func WithJSONDecoding() CmdOption {
+	return cmdOptionFunc(func(c *Cmd) {
+		c.decodeJSON = true
+	})
+}
+
+// WithCmdMaxLineLength limits the length of each logged line. See
+// Writer.MaxLineLength.
+func WithCmdMaxLineLength(n int) CmdOption {
+	return cmdOptionFunc(func(c *Cmd) {
+		c.maxLineLength = n
+	})
+}
+
+// Cmd runs an exec.Cmd, logging its standard output and standard error line
+// by line, and its exit status once it completes. For example,
+//
+//	cmd := ladio.Command(logger, exec.CommandContext(ctx, "make", "test"))
+//	if err := cmd.Run(); err != nil {
+//	    return err
+//	}
+//
+// Every entry carries the command line as "cmd" and the child's process ID as
+// "pid". Output entries also record the stream they were written to as
+// "stream".
+//
+// Streams that are already set on the exec.Cmd are left untouched and
+// aren't logged.
+type Cmd struct {
+	cmd *exec.Cmd
+	log *lad.Logger
+
+	stdoutLevel   ladcore.Level
+	stderrLevel   ladcore.Level
+	detectLevel   bool
+	decodeJSON    bool
+	maxLineLength int
+
+	writers []*Writer
+	start   time.Time
+}
+
+// Command builds a Cmd that logs the output of cmd to log.
+func Command(log *lad.Logger, cmd *exec.Cmd, opts ...CmdOption) *Cmd {
+	c := &Cmd{
+		cmd:         cmd,
+		log:         log.With(lad.Strings("cmd", commandLine(cmd))),
+		stdoutLevel: ladcore.InfoLevel,
+		stderrLevel: ladcore.WarnLevel,
+	}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	return c
+}
+
+// Start starts the command without waiting for it to complete. Call Wait to
+// wait for it, flush its remaining output and log its exit status.
+func (c *Cmd) Start() error {
+	if c.cmd.Stdout == nil {
+		c.cmd.Stdout = c.writer("stdout", c.stdoutLevel)
+	}
+	if c.cmd.Stderr == nil {
+		c.cmd.Stderr = c.writer("stderr", c.stderrLevel)
+	}
+
+	c.start = time.Now()
+	if err := c.cmd.Start(); err != nil {
+		c.log.Error("command failed to start", lad.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Wait waits for the command to exit, flushes any output it left without a
+// trailing newline, and logs its exit code and run time. Commands that exit
+// successfully are logged at Info and those that fail at Error.
+//
+// Wait returns the error reported by exec.Cmd.Wait.
+func (c *Cmd) Wait() error {
+	err := c.cmd.Wait()
+	duration := time.Since(c.start)
+
+	for _, w := range c.writers {
+		_ = w.Close() // never fails
+	}
+
+	fields := []lad.Field{
+		c.pidField(),
+		lad.Int("exit_code", c.cmd.ProcessState.ExitCode()),
+		lad.Duration("duration", duration),
+	}
+	if err != nil {
+		c.log.Error("command failed", append(fields, lad.Error(err))...)
+	} else {
+		c.log.Info("command finished", fields...)
+	}
+	return err
+}
+
+// Run starts the command and waits for it to complete.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+func (c *Cmd) writer(stream string, lvl ladcore.Level) *Writer {
+	w := &Writer{
+		Log:           c.log.With(lad.String("stream", stream)),
+		Level:         lvl,
+		MaxLineLength: c.maxLineLength,
+		// exec.Cmd only copies output after the process has started, so
+		// the process ID is always available by the time lines arrive.
+		LineFields: func([]byte) []lad.Field {
+			return []lad.Field{c.pidField()}
+		},
+	}
+	if c.detectLevel {
+		w.ParseLevel = DetectLevel
+	}
+	if c.decodeJSON {
+		w.decode = decodeJSONLine
+	}
+	c.writers = append(c.writers, w)
+	return w
+}
+
+func (c *Cmd) pidField() lad.Field {
+	if c.cmd.Process == nil {
+		return lad.Skip()
+	}
+	return lad.Int("pid", c.cmd.Process.Pid)
+}
+
+func commandLine(cmd *exec.Cmd) []string {
+	if len(cmd.Args) > 0 {
+		return cmd.Args
+	}
+	return []string{cmd.Path}
+}
+
+// decodeJSONLine unpacks a JSON object into a message and fields, sorted by
+// key. It reports false for lines that aren't JSON objects.
+func decodeJSONLine(line []byte) (string, []lad.Field, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return "", nil, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil || dec.More() {
+		return "", nil, false
+	}
+
+	var msg string
+	for _, key := range _jsonMessageKeys {
+		if s, ok := obj[key].(string); ok {
+			msg = s
+			delete(obj, key)
+			break
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		if _, ok := _jsonReservedKeys[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	fields := make([]lad.Field, len(keys))
+	for i, k := range keys {
+		fields[i] = jsonField(k, obj[k])
+	}
+	return msg, fields, true
+}
+
+func jsonField(key string, val interface{}) lad.Field {
+	if n, ok := val.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return lad.Int64(key, i)
+		}
+		if f, err := n.Float64(); err == nil {
+			return lad.Float64(key, f)
+		}
+		return lad.String(key, n.String())
+	}
+	return lad.Any(key, val)
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladtest/observer"
+)
+
+// TestHelperProcess isn't a real test. It's used as the child process by the
+// Cmd tests.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("LADIO_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	fmt.Fprintln(os.Stdout, "hello")
+	fmt.Fprint(os.Stderr, "ERROR: oops\r\n")
+	fmt.Fprintln(os.Stdout, `{"level":"warn","ts":1.5,"msg":"from child","count":3,"ratio":0.5,"tags":["a"]}`)
+	fmt.Fprint(os.Stdout, "no newline")
+	os.Exit(3)
+}
+
+func helperCommand(t *testing.T) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "LADIO_HELPER_PROCESS=1")
+	return cmd
+}
+
+func TestCmd(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(lad.DebugLevel)
+	cmd := helperCommand(t)
+	err := Command(lad.New(core), cmd, WithLevelDetection(), WithJSONDecoding()).Run()
+
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "Expected an exit error, got %v.", err)
+	pid := cmd.Process.Pid
+	args := make([]interface{}, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = arg
+	}
+
+	stdout := observed.FilterField(lad.String("stream", "stdout"))
+	stderr := observed.FilterField(lad.String("stream", "stderr"))
+	for _, logs := range []*observer.ObservedLogs{stdout, stderr} {
+		for _, ent := range logs.All() {
+			assert.Equal(t, int64(pid), ent.ContextMap()["pid"], "Expected the pid on %q.", ent.Message)
+			assert.Equal(t, args, ent.ContextMap()["cmd"], "Expected the command on %q.", ent.Message)
+		}
+	}
+
+	require.Equal(t, 1, stderr.Len(), "Expected one line on stderr.")
+	assert.Equal(t, ladcore.ErrorLevel, stderr.All()[0].Level, "Expected the level to be detected.")
+	assert.Equal(t, "ERROR: oops", stderr.All()[0].Message, "Expected CRLF to be trimmed.")
+
+	outs := stdout.All()
+	require.Len(t, outs, 3, "Unexpected stdout entries.")
+	assert.Equal(t, ladcore.InfoLevel, outs[0].Level, "Unexpected level.")
+	assert.Equal(t, "hello", outs[0].Message, "Unexpected message.")
+	assert.Equal(t, ladcore.WarnLevel, outs[1].Level, "Expected the JSON level to be honored.")
+	assert.Equal(t, "from child", outs[1].Message, "Expected the JSON message to be unpacked.")
+	ctx := outs[1].ContextMap()
+	assert.Equal(t, int64(3), ctx["count"], "Unexpected integer field.")
+	assert.Equal(t, 0.5, ctx["ratio"], "Unexpected float field.")
+	assert.NotContains(t, ctx, "ts", "Expected the timestamp to be dropped.")
+	assert.NotContains(t, ctx, "level", "Expected the level to be dropped.")
+	assert.Equal(t, "no newline", outs[2].Message, "Expected partial output to be flushed.")
+
+	done := observed.FilterMessage("command failed").All()
+	require.Len(t, done, 1, "Expected a completion entry.")
+	assert.Equal(t, ladcore.ErrorLevel, done[0].Level, "Unexpected completion level.")
+	assert.Equal(t, int64(3), done[0].ContextMap()["exit_code"], "Unexpected exit code.")
+	assert.IsType(t, time.Duration(0), done[0].ContextMap()["duration"], "Expected a duration.")
+}
+
+func TestCmdSuccess(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(lad.InfoLevel)
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, Command(lad.New(core), cmd).Run(), "Unexpected error running command.")
+
+	done := observed.FilterMessage("command finished").All()
+	require.Len(t, done, 1, "Expected a completion entry.")
+	assert.Equal(t, ladcore.InfoLevel, done[0].Level, "Unexpected completion level.")
+	assert.Equal(t, int64(0), done[0].ContextMap()["exit_code"], "Unexpected exit code.")
+}
+
+func TestCmdStartFailure(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(lad.InfoLevel)
+	err := Command(lad.New(core), exec.Command("/nonexistent/ladio-test-binary")).Run()
+	require.Error(t, err, "Expected an error starting a missing binary.")
+
+	entries := observed.FilterMessage("command failed to start").All()
+	require.Len(t, entries, 1, "Expected the failure to be logged.")
+	assert.Equal(t, []interface{}{"/nonexistent/ladio-test-binary"}, entries[0].ContextMap()["cmd"], "Unexpected command.")
+}
+
+func TestDecodeJSONLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line       string
+		wantMsg    string
+		wantFields []lad.Field
+		wantOK     bool
+	}{
+		{line: "plain"},
+		{line: "{broken"},
+		{line: `{"a":1} {"b":2}`},
+		{
+			line:       ` {"message":"hi","big":1e400,"nested":{"k":"v"}} `,
+			wantMsg:    "hi",
+			wantFields: []lad.Field{lad.String("big", "1e400"), lad.Any("nested", map[string]interface{}{"k": "v"})},
+			wantOK:     true,
+		},
+		{
+			line:       `{"caller":"x.go:1","time":"now"}`,
+			wantFields: []lad.Field{},
+			wantOK:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		msg, fields, ok := decodeJSONLine([]byte(tt.line))
+		assert.Equal(t, tt.wantOK, ok, "Unexpected ok for %q.", tt.line)
+		assert.Equal(t, tt.wantMsg, msg, "Unexpected message for %q.", tt.line)
+		assert.Equal(t, tt.wantFields, fields, "Unexpected fields for %q.", tt.line)
+	}
+}
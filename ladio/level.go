@@ -0,0 +1,79 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladio
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/tnngo/lad/internal"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// _levelKeys are the JSON keys DetectLevel inspects, in order of preference.
+var _levelKeys = []string{"level", "severity", "lvl"}
+
+// DetectLevel is a Writer.ParseLevel implementation that recognizes the
+// levels of lines produced by common logging setups. It understands
+//
+//   - JSON objects with a "level", "severity", or "lvl" key, such as
+//     {"level":"error","msg":"..."}; and
+//   - a leading level name followed by a colon or surrounded by brackets,
+//     such as "ERROR: ...", "warn: ...", or "[INFO] ...".
+//
+// Level names are matched case-insensitively. "WARNING", "ERR", "CRIT", and
+// "CRITICAL" are accepted as aliases for Warn, Error, and Error respectively.
+// "TRACE" maps to Debug.
+//
+// Fatal and Panic levels are reported as Error so that a child process's
+// output can't terminate the current process.
+func DetectLevel(line []byte) (ladcore.Level, bool) {
+	line = bytes.TrimLeft(line, " \t")
+	if len(line) == 0 {
+		return ladcore.InfoLevel, false
+	}
+
+	var name []byte
+	switch line[0] {
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(line, &obj); err != nil {
+			return ladcore.InfoLevel, false
+		}
+		for _, key := range _levelKeys {
+			var s string
+			if raw, ok := obj[key]; ok && json.Unmarshal(raw, &s) == nil {
+				name = []byte(s)
+				break
+			}
+		}
+	case '[':
+		if end := bytes.IndexByte(line, ']'); end > 0 {
+			name = line[1:end]
+		}
+	default:
+		if end := bytes.IndexByte(line, ':'); end > 0 {
+			name = line[:end]
+		}
+	}
+
+	return internal.LevelFromName(string(name))
+}
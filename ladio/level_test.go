@@ -0,0 +1,62 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tnngo/lad/ladcore"
+)
+
+func TestDetectLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line   string
+		want   ladcore.Level
+		wantOK bool
+	}{
+		{"", ladcore.InfoLevel, false},
+		{"plain output", ladcore.InfoLevel, false},
+		{"ERROR: failed", ladcore.ErrorLevel, true},
+		{"  warning: careful", ladcore.WarnLevel, true},
+		{"Debug: details", ladcore.DebugLevel, true},
+		{"TRACE: details", ladcore.DebugLevel, true},
+		{"[INFO] ready", ladcore.InfoLevel, true},
+		{"[crit] meltdown", ladcore.ErrorLevel, true},
+		{"FATAL: bye", ladcore.ErrorLevel, true},
+		{"http://example.com: not a level", ladcore.InfoLevel, false},
+		{"[unterminated", ladcore.InfoLevel, false},
+		{`{"level":"warn","msg":"x"}`, ladcore.WarnLevel, true},
+		{`{"severity":"ERROR"}`, ladcore.ErrorLevel, true},
+		{`{"lvl":"debug"}`, ladcore.DebugLevel, true},
+		{`{"level":3}`, ladcore.InfoLevel, false},
+		{`{"msg":"no level"}`, ladcore.InfoLevel, false},
+		{`{not json`, ladcore.InfoLevel, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := DetectLevel([]byte(tt.line))
+		assert.Equal(t, tt.wantOK, ok, "Unexpected ok for %q.", tt.line)
+		assert.Equal(t, tt.want, got, "Unexpected level for %q.", tt.line)
+	}
+}
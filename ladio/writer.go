@@ -24,6 +24,7 @@ package ladio
 import (
 	"bytes"
 	"io"
+	"unicode/utf8"
 
 	"github.com/tnngo/lad"
 	"github.com/tnngo/lad/ladcore"
@@ -47,6 +48,8 @@ import (
 //	    return err
 //	}
 //
+// Lines terminated by "\r\n" are logged without the trailing carriage return.
+//
 // Writer must be closed when finished to flush buffered data to the logger.
 type Writer struct {
 	// Log specifies the logger to which the Writer will write messages.
@@ -59,6 +62,34 @@ type Writer struct {
 	// If unspecified, defaults to Info.
 	Level ladcore.Level
 
+	// MaxLineLength limits the number of bytes buffered for a single line.
+	// Lines longer than this are split into multiple log entries of at most
+	// MaxLineLength bytes each, taking care not to split multi-byte UTF-8
+	// sequences.
+	//
+	// If unspecified or non-positive, lines are buffered without limit.
+	MaxLineLength int
+
+	// ParseLevel, if specified, is called with each line to determine the
+	// level at which it's logged. Lines for which it returns false are logged
+	// at Level.
+	//
+	// See DetectLevel for a parser that understands common prefixes and
+	// JSON-formatted lines.
+	ParseLevel func(line []byte) (ladcore.Level, bool)
+
+	// LineFields, if specified, is called with each line and returns
+	// additional fields to attach to its log entry.
+	//
+	// Fields that are the same for every line, such as the name of the stream
+	// or the ID of the process that produced it, are better attached to Log
+	// with Logger.With.
+	LineFields func(line []byte) []lad.Field
+
+	// decode, if set, may replace a line with a message and fields of its
+	// own. Used by Cmd to unpack JSON output from child processes.
+	decode func(line []byte) (msg string, fields []lad.Field, ok bool)
+
 	buff bytes.Buffer
 }
 
@@ -73,8 +104,9 @@ var (
 // Write will split the input on newlines and post each line as a new log entry
 // to the logger.
 func (w *Writer) Write(bs []byte) (n int, err error) {
-	// Skip all checks if the level isn't enabled.
-	if !w.Log.Core().Enabled(w.Level) {
+	// Skip all checks if the level isn't enabled. Lines may be logged at
+	// other levels if ParseLevel is set, so we can't skip them in that case.
+	if w.ParseLevel == nil && !w.Log.Core().Enabled(w.Level) {
 		return len(bs), nil
 	}
 
@@ -93,6 +125,7 @@ func (w *Writer) writeLine(line []byte) (remaining []byte) {
 	if idx < 0 {
 		// If there are no newlines, buffer the entire string.
 		w.buff.Write(line)
+		w.flushLong()
 		return nil
 	}
 
@@ -102,7 +135,7 @@ func (w *Writer) writeLine(line []byte) (remaining []byte) {
 	// Fast path: if we don't have a partial message from a previous write
 	// in the buffer, skip the buffer and log directly.
 	if w.buff.Len() == 0 {
-		w.log(line)
+		w.log(trimCR(line))
 		return
 	}
 
@@ -110,11 +143,24 @@ func (w *Writer) writeLine(line []byte) (remaining []byte) {
 
 	// Log empty messages in the middle of the stream so that we don't lose
 	// information when the user writes "foo\n\nbar".
+	w.buff.Truncate(len(trimCR(w.buff.Bytes())))
 	w.flush(true /* allowEmpty */)
 
 	return remaining
 }
 
+// flushLong logs full-length chunks of the buffered data if it has grown past
+// MaxLineLength.
+func (w *Writer) flushLong() {
+	if w.MaxLineLength <= 0 {
+		return
+	}
+
+	for w.buff.Len() > w.MaxLineLength {
+		w.logOne(w.buff.Next(w.cut(w.buff.Bytes())))
+	}
+}
+
 // Close closes the writer, flushing any buffered data in the process.
 //
 // Always call Close once you're done with the Writer to ensure that it flushes
@@ -142,8 +188,66 @@ func (w *Writer) flush(allowEmpty bool) {
 	w.buff.Reset()
 }
 
+// log logs the provided line, splitting it into multiple entries if it's
+// longer than MaxLineLength.
 func (w *Writer) log(b []byte) {
-	if ce := w.Log.Check(w.Level, string(b)); ce != nil {
-		ce.Write()
+	if w.MaxLineLength > 0 {
+		for len(b) > w.MaxLineLength {
+			n := w.cut(b)
+			w.logOne(b[:n])
+			b = b[n:]
+		}
+	}
+	w.logOne(b)
+}
+
+func (w *Writer) logOne(b []byte) {
+	lvl := w.Level
+	if w.ParseLevel != nil {
+		if l, ok := w.ParseLevel(b); ok {
+			lvl = l
+		}
+	}
+
+	var (
+		msg     string
+		decoded []lad.Field
+		ok      bool
+	)
+	if w.decode != nil {
+		msg, decoded, ok = w.decode(b)
+	}
+	if !ok {
+		msg = string(b)
+	}
+
+	if ce := w.Log.Check(lvl, msg); ce != nil {
+		var fields []lad.Field
+		if w.LineFields != nil {
+			fields = w.LineFields(b)
+		}
+		ce.Write(append(fields, decoded...)...)
+	}
+}
+
+// cut returns the length of the longest prefix of b that's at most
+// MaxLineLength bytes and doesn't end in the middle of a UTF-8 sequence.
+// b must be longer than MaxLineLength.
+func (w *Writer) cut(b []byte) int {
+	n := w.MaxLineLength
+	for i := n; i > 0 && i > n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			return i
+		}
+	}
+	// Not valid UTF-8. Split at the limit.
+	return n
+}
+
+// trimCR drops a trailing carriage return from a line.
+func trimCR(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\r' {
+		return b[:n-1]
 	}
+	return b
 }
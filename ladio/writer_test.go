@@ -190,6 +190,107 @@ func TestWrite_Sync(t *testing.T) {
 	})
 }
 
+func TestWriter_CRLF(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(lad.InfoLevel)
+	w := Writer{Log: lad.New(core)}
+
+	for _, s := range []string{"foo\r\nbar\r", "\n\r\nbaz\rqux\r\n"} {
+		_, err := io.WriteString(&w, s)
+		require.NoError(t, err, "Writer.Write failed.")
+	}
+	require.NoError(t, w.Close(), "Writer.Close failed.")
+
+	var got []string
+	for _, ent := range observed.All() {
+		got = append(got, ent.Message)
+	}
+	assert.Equal(t, []string{"foo", "bar", "", "baz\rqux"}, got, "Unexpected messages.")
+}
+
+func TestWriter_MaxLineLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		writes []string
+		want   []string
+	}{
+		{
+			desc:   "single write",
+			writes: []string{"abcdefgh\nij\n"},
+			want:   []string{"abc", "def", "gh", "ij"},
+		},
+		{
+			desc:   "flushed before newline",
+			writes: []string{"ab", "cdefg", "h"},
+			want:   []string{"abc", "def", "gh"},
+		},
+		{
+			desc:   "exact length",
+			writes: []string{"abc", "\n"},
+			want:   []string{"abc"},
+		},
+		{
+			desc:   "multi-byte runes",
+			writes: []string{"abé", "ü\n"},
+			want:   []string{"ab", "é", "ü"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // for t.Parallel
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			core, observed := observer.New(lad.InfoLevel)
+			w := Writer{Log: lad.New(core), MaxLineLength: 3}
+
+			for _, s := range tt.writes {
+				_, err := io.WriteString(&w, s)
+				require.NoError(t, err, "Writer.Write failed.")
+			}
+			require.NoError(t, w.Close(), "Writer.Close failed.")
+
+			var got []string
+			for _, ent := range observed.All() {
+				got = append(got, ent.Message)
+			}
+			assert.Equal(t, tt.want, got, "Unexpected messages.")
+		})
+	}
+}
+
+func TestWriter_ParseLevelAndFields(t *testing.T) {
+	t.Parallel()
+
+	core, observed := observer.New(lad.InfoLevel)
+	w := Writer{
+		Log:        lad.New(core).With(lad.String("stream", "stderr")),
+		Level:      lad.DebugLevel,
+		ParseLevel: DetectLevel,
+		LineFields: func(line []byte) []lad.Field {
+			return []lad.Field{lad.Int("len", len(line))}
+		},
+	}
+
+	_, err := io.WriteString(&w, "starting\nWARN: low disk\n{\"level\":\"error\",\"msg\":\"boom\"}\n")
+	require.NoError(t, err, "Writer.Write failed.")
+	require.NoError(t, w.Close(), "Writer.Close failed.")
+
+	assert.Equal(t, []observer.LoggedEntry{
+		{
+			Entry:   ladcore.Entry{Level: lad.WarnLevel, Message: "WARN: low disk"},
+			Context: []ladcore.Field{lad.String("stream", "stderr"), lad.Int("len", 14)},
+		},
+		{
+			Entry:   ladcore.Entry{Level: lad.ErrorLevel, Message: `{"level":"error","msg":"boom"}`},
+			Context: []ladcore.Field{lad.String("stream", "stderr"), lad.Int("len", 30)},
+		},
+	}, observed.AllUntimed(), "Unexpected entries; undetected lines should use the disabled default level.")
+}
+
 func BenchmarkWriter(b *testing.B) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,20 @@
+module github.com/tnngo/lad/ladkit
+
+go 1.19
+
+require (
+	github.com/go-kit/log v0.2.1
+	github.com/stretchr/testify v1.8.1
+	github.com/tnngo/lad v1.16.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logfmt/logfmt v0.5.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/tnngo/lad => ../
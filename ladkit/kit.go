@@ -0,0 +1,206 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ladkit implements the go-kit log.Logger interface on top of a lad
+// Logger, so that code written against github.com/go-kit/log can log
+// through lad.
+//
+//	var logger log.Logger = ladkit.NewLogger(ladLogger)
+//	logger = log.With(logger, "component", "store")
+//	level.Warn(logger).Log("msg", "retrying", "attempt", 2)
+//
+// The "msg" value becomes the message of the entry and values added by the
+// go-kit level package set its level. All other key-value pairs become
+// fields.
+package ladkit // import "github.com/tnngo/lad/ladkit"
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+)
+
+const (
+	// _defaultMessageKey is the key whose value becomes the message of the
+	// entry, as used throughout go-kit.
+	_defaultMessageKey = "msg"
+
+	// _kitPackage is the import path prefix of go-kit's log packages. Frames
+	// in these packages are skipped when annotating callers.
+	_kitPackage = "github.com/go-kit/log"
+)
+
+// An Option configures a Logger.
+type Option interface {
+	apply(*Logger)
+}
+
+// optionFunc wraps a func so it satisfies the Option interface.
+type optionFunc func(*Logger)
+
+func (f optionFunc) apply(l *Logger) {
+	f(l)
+}
+
+// WithLevel sets the level of entries that don't carry a go-kit level.
+// Defaults to Info.
+func WithLevel(lvl ladcore.Level) Option {
+	return optionFunc(func(l *Logger) {
+		l.level = lvl
+	})
+}
+
+// WithMessageKey sets the key whose value becomes the message of the entry.
+// Defaults to "msg".
+func WithMessageKey(key string) Option {
+	return optionFunc(func(l *Logger) {
+		l.messageKey = key
+	})
+}
+
+// Logger implements go-kit's log.Logger by writing to a lad Logger.
+//
+// When the lad Logger annotates callers, entries are attributed to the
+// first caller outside the go-kit log packages, so wrapping the Logger with
+// log.With or the level helpers needs no caller skip adjustments.
+type Logger struct {
+	l          *lad.Logger
+	level      ladcore.Level
+	messageKey string
+}
+
+var _ log.Logger = (*Logger)(nil)
+
+// NewLogger builds a Logger that writes to the supplied lad Logger.
+func NewLogger(l *lad.Logger, opts ...Option) *Logger {
+	logger := &Logger{
+		l:          l,
+		level:      ladcore.InfoLevel,
+		messageKey: _defaultMessageKey,
+	}
+	for _, opt := range opts {
+		opt.apply(logger)
+	}
+	return logger
+}
+
+// Log logs the given key-value pairs. As with go-kit's loggers, keys that
+// aren't strings are formatted with fmt.Sprint and a key without a value is
+// paired with log.ErrMissingValue.
+//
+// Log never returns an error.
+func (k *Logger) Log(keyvals ...interface{}) error {
+	var (
+		msg      string
+		lvl      = k.level
+		levelSet bool
+		fields   = make([]lad.Field, 0, len(keyvals)/2)
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		var val interface{} = log.ErrMissingValue
+		if i+1 < len(keyvals) {
+			val = keyvals[i+1]
+		}
+
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+
+		if key == k.messageKey {
+			msg = fmt.Sprint(val)
+			continue
+		}
+		if keyvals[i] == level.Key() && !levelSet {
+			if l, ok := parseLevel(val); ok {
+				lvl, levelSet = l, true
+				continue
+			}
+		}
+		fields = append(fields, lad.Any(key, val))
+	}
+
+	ce := k.l.Check(lvl, msg)
+	if ce == nil {
+		return nil
+	}
+	if ce.Caller.Defined {
+		ce.Caller = caller()
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+// parseLevel maps a go-kit level value, or a string holding a level name,
+// onto a lad level. Like other adapters for foreign output, it reports
+// "panic" and "fatal" as Error, so that a go-kit Log call can't panic or
+// exit the process.
+func parseLevel(val interface{}) (ladcore.Level, bool) {
+	switch v := val.(type) {
+	case level.Value:
+		return levelFromName(v.String())
+	case string:
+		return levelFromName(v)
+	default:
+		return ladcore.InfoLevel, false
+	}
+}
+
+// levelFromName matches level names case-insensitively, along with common
+// aliases such as "warning" and "trace". It mirrors the mapping lad uses for
+// other foreign output, which this module can't share since it's versioned
+// separately.
+func levelFromName(name string) (ladcore.Level, bool) {
+	switch strings.ToLower(name) {
+	case "trace", "debug":
+		return ladcore.DebugLevel, true
+	case "info":
+		return ladcore.InfoLevel, true
+	case "warn", "warning":
+		return ladcore.WarnLevel, true
+	case "err", "error", "crit", "critical", "dpanic", "panic", "fatal":
+		return ladcore.ErrorLevel, true
+	}
+	return ladcore.InfoLevel, false
+}
+
+// caller reports the first caller outside this package and go-kit's log
+// packages.
+func caller() ladcore.EntryCaller {
+	var pcs [16]uintptr
+	// Skip runtime.Callers, caller and Logger.Log.
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, _kitPackage) {
+			c := ladcore.NewEntryCaller(frame.PC, frame.File, frame.Line, frame.PC != 0)
+			c.Function = frame.Function
+			return c
+		}
+		if !more {
+			return ladcore.EntryCaller{}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladkit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladtest/observer"
+)
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+func newObserved(lvl ladcore.Level, opts ...Option) (*Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(lvl)
+	return NewLogger(lad.New(core, lad.AddCaller()), opts...), logs
+}
+
+func TestLog(t *testing.T) {
+	logger, logs := newObserved(ladcore.DebugLevel)
+
+	err := errors.New("boom")
+	require.NoError(t, logger.Log("msg", "hello", "k", 1, "err", err, 42, "answer"), "Unexpected error.")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "Expected an entry.")
+	assert.Equal(t, ladcore.InfoLevel, entries[0].Level, "Unexpected level.")
+	assert.Equal(t, "hello", entries[0].Message, "Unexpected message.")
+	assert.Equal(t, map[string]interface{}{
+		"k":   int64(1),
+		"err": "boom",
+		"42":  "answer",
+	}, entries[0].ContextMap(), "Unexpected fields.")
+}
+
+func TestLogEdgeCases(t *testing.T) {
+	tests := []struct {
+		desc       string
+		opts       []Option
+		keyvals    []interface{}
+		wantLevel  ladcore.Level
+		wantMsg    string
+		wantFields map[string]interface{}
+	}{
+		{
+			desc:       "empty",
+			wantLevel:  ladcore.InfoLevel,
+			wantFields: map[string]interface{}{},
+		},
+		{
+			desc:       "missing value",
+			keyvals:    []interface{}{"msg", "m", "dangling"},
+			wantLevel:  ladcore.InfoLevel,
+			wantMsg:    "m",
+			wantFields: map[string]interface{}{"dangling": log.ErrMissingValue.Error()},
+		},
+		{
+			desc:       "non-string message",
+			keyvals:    []interface{}{"msg", stringer{}},
+			wantLevel:  ladcore.InfoLevel,
+			wantMsg:    "stringer",
+			wantFields: map[string]interface{}{},
+		},
+		{
+			desc:       "string level",
+			keyvals:    []interface{}{"level", "WARN", "msg", "m"},
+			wantLevel:  ladcore.WarnLevel,
+			wantMsg:    "m",
+			wantFields: map[string]interface{}{},
+		},
+		{
+			desc:       "unknown level",
+			keyvals:    []interface{}{"level", "verbose"},
+			wantLevel:  ladcore.InfoLevel,
+			wantFields: map[string]interface{}{"level": "verbose"},
+		},
+		{
+			desc:       "panic level",
+			keyvals:    []interface{}{"level", "panic", "msg", "m"},
+			wantLevel:  ladcore.ErrorLevel,
+			wantMsg:    "m",
+			wantFields: map[string]interface{}{},
+		},
+		{
+			desc:       "fatal level",
+			keyvals:    []interface{}{"level", "FATAL", "msg", "m"},
+			wantLevel:  ladcore.ErrorLevel,
+			wantMsg:    "m",
+			wantFields: map[string]interface{}{},
+		},
+		{
+			desc:       "first level wins",
+			keyvals:    []interface{}{"level", level.ErrorValue(), "level", level.DebugValue()},
+			wantLevel:  ladcore.ErrorLevel,
+			wantFields: map[string]interface{}{"level": "debug"},
+		},
+		{
+			desc:       "options",
+			opts:       []Option{WithLevel(ladcore.WarnLevel), WithMessageKey("message")},
+			keyvals:    []interface{}{"message", "m", "msg", "field"},
+			wantLevel:  ladcore.WarnLevel,
+			wantMsg:    "m",
+			wantFields: map[string]interface{}{"msg": "field"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			logger, logs := newObserved(ladcore.DebugLevel, tt.opts...)
+			require.NoError(t, logger.Log(tt.keyvals...), "Unexpected error.")
+
+			entries := logs.AllUntimed()
+			require.Len(t, entries, 1, "Expected an entry.")
+			assert.Equal(t, tt.wantLevel, entries[0].Level, "Unexpected level.")
+			assert.Equal(t, tt.wantMsg, entries[0].Message, "Unexpected message.")
+			assert.Equal(t, tt.wantFields, entries[0].ContextMap(), "Unexpected fields.")
+		})
+	}
+}
+
+// TestKitHelpers checks that the Logger behaves with go-kit's own wrappers.
+func TestKitHelpers(t *testing.T) {
+	logger, logs := newObserved(ladcore.InfoLevel)
+
+	var kit log.Logger = logger
+	kit = log.With(kit, "component", "store", "valuer", log.Valuer(func() interface{} { return "resolved" }))
+
+	require.NoError(t, level.Error(kit).Log("msg", "failed"), "Unexpected error.")
+	require.NoError(t, level.Warn(kit).Log("msg", "retrying"), "Unexpected error.")
+	require.NoError(t, level.Debug(kit).Log("msg", "dropped"), "Unexpected error.")
+	require.NoError(t, log.WithPrefix(kit, "prefix", 1).Log("msg", "prefixed"), "Unexpected error.")
+	require.NoError(t, level.Info(level.NewFilter(kit, level.AllowError())).Log("msg", "filtered"), "Unexpected error.")
+
+	entries := logs.All()
+	require.Len(t, entries, 3, "Unexpected number of entries.")
+
+	assert.Equal(t, ladcore.ErrorLevel, entries[0].Level, "Unexpected level.")
+	assert.Equal(t, "failed", entries[0].Message, "Unexpected message.")
+	assert.Equal(t, map[string]interface{}{"component": "store", "valuer": "resolved"}, entries[0].ContextMap(), "Unexpected fields.")
+
+	assert.Equal(t, ladcore.WarnLevel, entries[1].Level, "Unexpected level.")
+	assert.Equal(t, "prefixed", entries[2].Message, "Unexpected message.")
+	assert.Equal(t, int64(1), entries[2].ContextMap()["prefix"], "Unexpected prefix field.")
+
+	for _, ent := range entries {
+		assert.Regexp(t, `/kit_test.go:\d+$`, ent.Caller.String(), "Unexpected caller for %q.", ent.Message)
+		assert.Contains(t, ent.Caller.Function, "TestKitHelpers", "Unexpected function for %q.", ent.Message)
+	}
+}
+
+func TestLogWithoutCaller(t *testing.T) {
+	core, logs := observer.New(ladcore.InfoLevel)
+	require.NoError(t, NewLogger(lad.New(core)).Log("msg", "m"), "Unexpected error.")
+
+	entries := logs.All()
+	require.Len(t, entries, 1, "Expected an entry.")
+	assert.False(t, entries[0].Caller.Defined, "Expected no caller.")
+}
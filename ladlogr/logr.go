@@ -0,0 +1,172 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ladlogr provides a logr.LogSink backed by a lad Logger, so that
+// code written against github.com/go-logr/logr can log through lad.
+//
+//	logger := ladlogr.NewLogger(ladLogger)
+//	logger.V(1).Info("reconciling", "namespace", ns, "name", name)
+//
+// logr's verbosity levels map onto lad levels below Info: V(0) logs at
+// InfoLevel, V(1) at DebugLevel, and V(n) at ladcore.Level(-n). Enable them
+// with a level enabler below DebugLevel, such as lad.NewAtomicLevelAt(-2).
+//
+// Names added with WithName become the name of the lad Logger, joined with
+// periods as with Logger.Named.
+package ladlogr // import "github.com/tnngo/lad/ladlogr"
+
+import (
+	"github.com/go-logr/logr"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+)
+
+const (
+	_oddNumberErrMsg    = "Ignored key without a value."
+	_nonStringKeyErrMsg = "Ignored key-value pairs with non-string keys."
+
+	// _minLevel is the lowest level representable by ladcore.Level.
+	_minLevel = ladcore.Level(-128)
+
+	// _sinkDepth is the number of LogSink frames between the logr.Logger
+	// and the lad Logger.
+	_sinkDepth = 1
+)
+
+// LogSink implements logr.LogSink on top of a lad Logger.
+type LogSink struct {
+	l *lad.Logger
+}
+
+var (
+	_ logr.LogSink          = (*LogSink)(nil)
+	_ logr.CallDepthLogSink = (*LogSink)(nil)
+)
+
+// NewLogger builds a logr.Logger that writes to the supplied lad Logger.
+func NewLogger(l *lad.Logger) logr.Logger {
+	return logr.New(NewLogSink(l))
+}
+
+// NewLogSink builds a LogSink that writes to the supplied lad Logger.
+func NewLogSink(l *lad.Logger) *LogSink {
+	return &LogSink{l: l}
+}
+
+// Init receives runtime information from logr. It adjusts the number of
+// callers skipped so that entries are attributed to the code calling the
+// logr.Logger.
+func (s *LogSink) Init(info logr.RuntimeInfo) {
+	s.l = s.l.WithOptions(lad.AddCallerSkip(info.CallDepth + _sinkDepth))
+}
+
+// Enabled reports whether messages at the given verbosity level are logged.
+func (s *LogSink) Enabled(level int) bool {
+	return s.l.Core().Enabled(toLadLevel(level))
+}
+
+// Info logs a message at the lad level matching the verbosity level.
+func (s *LogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if ce := s.l.Check(toLadLevel(level), msg); ce != nil {
+		ce.Write(s.fields(keysAndValues)...)
+	}
+}
+
+// Error logs an error at ErrorLevel, regardless of the verbosity level.
+func (s *LogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if ce := s.l.Check(ladcore.ErrorLevel, msg); ce != nil {
+		ce.Write(append(s.fields(keysAndValues), lad.Error(err))...)
+	}
+}
+
+// WithValues returns a LogSink that adds the given key-value pairs to every
+// message.
+func (s *LogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &LogSink{l: s.l.With(s.fields(keysAndValues)...)}
+}
+
+// WithName returns a LogSink with the given name appended to the lad
+// Logger's name.
+func (s *LogSink) WithName(name string) logr.LogSink {
+	return &LogSink{l: s.l.Named(name)}
+}
+
+// WithCallDepth returns a LogSink that skips the given number of additional
+// callers when annotating entries.
+func (s *LogSink) WithCallDepth(depth int) logr.LogSink {
+	return &LogSink{l: s.l.WithOptions(lad.AddCallerSkip(depth))}
+}
+
+// GetUnderlying returns the lad Logger behind the LogSink.
+func (s *LogSink) GetUnderlying() *lad.Logger {
+	return s.l
+}
+
+// toLadLevel maps a logr verbosity level onto a lad level, clamping levels
+// that lad can't represent.
+func toLadLevel(level int) ladcore.Level {
+	if level > -int(_minLevel) {
+		return _minLevel
+	}
+	if level < 0 {
+		return ladcore.InfoLevel
+	}
+	return ladcore.Level(-level)
+}
+
+// fields converts logr's key-value pairs to lad fields. Values that
+// implement logr.Marshaler are replaced by the result of MarshalLog, and lad
+// Fields may be passed in place of a pair.
+func (s *LogSink) fields(keysAndValues []interface{}) []lad.Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+
+	fields := make([]lad.Field, 0, len(keysAndValues)/2)
+	var invalid []interface{}
+	for i := 0; i < len(keysAndValues); {
+		if f, ok := keysAndValues[i].(lad.Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+
+		if i == len(keysAndValues)-1 {
+			s.l.Error(_oddNumberErrMsg, lad.Any("ignored", keysAndValues[i]))
+			break
+		}
+
+		key, val := keysAndValues[i], keysAndValues[i+1]
+		if m, ok := val.(logr.Marshaler); ok {
+			val = m.MarshalLog()
+		}
+		if keyStr, ok := key.(string); ok {
+			fields = append(fields, lad.Any(keyStr, val))
+		} else {
+			invalid = append(invalid, key)
+		}
+		i += 2
+	}
+
+	if len(invalid) > 0 {
+		s.l.Error(_nonStringKeyErrMsg, lad.Any("invalid", invalid))
+	}
+	return fields
+}
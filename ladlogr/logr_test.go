@@ -0,0 +1,154 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladlogr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladtest/observer"
+)
+
+type marshaledUser struct{ name string }
+
+func (u marshaledUser) MarshalLog() interface{} {
+	return map[string]string{"name": u.name}
+}
+
+func newObserved(lvl ladcore.Level, opts ...lad.Option) (logr.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(lvl)
+	return NewLogger(lad.New(core, opts...)), logs
+}
+
+func TestVerbosityLevels(t *testing.T) {
+	logger, logs := newObserved(ladcore.Level(-2))
+
+	tests := []struct {
+		v       int
+		want    ladcore.Level
+		enabled bool
+	}{
+		{0, ladcore.InfoLevel, true},
+		{1, ladcore.DebugLevel, true},
+		{2, ladcore.Level(-2), true},
+		{3, ladcore.Level(-3), false},
+		{1000, ladcore.Level(-128), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, toLadLevel(tt.v), "Unexpected level for V(%d).", tt.v)
+		assert.Equal(t, tt.enabled, logger.V(tt.v).Enabled(), "Unexpected Enabled for V(%d).", tt.v)
+
+		logger.V(tt.v).Info("msg")
+		entries := logs.TakeAll()
+		if !tt.enabled {
+			assert.Empty(t, entries, "Expected V(%d) to be dropped.", tt.v)
+			continue
+		}
+		require.Len(t, entries, 1, "Expected V(%d) to be logged.", tt.v)
+		assert.Equal(t, tt.want, entries[0].Level, "Unexpected level for V(%d).", tt.v)
+	}
+
+	// logr never passes negative levels, but sinks may be used directly.
+	assert.Equal(t, ladcore.InfoLevel, toLadLevel(-1), "Expected negative levels to map to Info.")
+}
+
+func TestErrorIgnoresVerbosity(t *testing.T) {
+	logger, logs := newObserved(ladcore.ErrorLevel)
+	err := errors.New("boom")
+	logger.V(5).Error(err, "failed", "k", 1)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "Expected an entry.")
+	assert.Equal(t, ladcore.ErrorLevel, entries[0].Level, "Unexpected level.")
+	assert.Equal(t, "failed", entries[0].Message, "Unexpected message.")
+	assert.Equal(t, map[string]interface{}{"k": int64(1), "error": "boom"}, entries[0].ContextMap(), "Unexpected fields.")
+}
+
+func TestWithNameAndValues(t *testing.T) {
+	logger, logs := newObserved(ladcore.DebugLevel)
+
+	base := logger.WithName("controller").WithValues("kind", "Pod")
+	child := base.WithName("reconciler").WithValues("name", "web")
+	child.Info("reconciling", "attempt", 2)
+	base.Info("idle")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2, "Unexpected number of entries.")
+	assert.Equal(t, "controller.reconciler", entries[0].LoggerName, "Unexpected logger name.")
+	assert.Equal(t, map[string]interface{}{"kind": "Pod", "name": "web", "attempt": int64(2)}, entries[0].ContextMap(), "Unexpected fields.")
+	assert.Equal(t, "controller", entries[1].LoggerName, "Expected the parent to be unaffected.")
+	assert.Equal(t, map[string]interface{}{"kind": "Pod"}, entries[1].ContextMap(), "Expected the parent to be unaffected.")
+}
+
+func TestKeysAndValues(t *testing.T) {
+	logger, logs := newObserved(ladcore.DebugLevel)
+
+	logger.Info("marshaler", "user", marshaledUser{"jane"}, lad.Int("field", 3))
+	logger.Info("odd", "a", 1, "dangling")
+	logger.Info("non-string", 42, "x", "b", 2)
+
+	assert.Equal(t, map[string]interface{}{
+		"user":  map[string]string{"name": "jane"},
+		"field": int64(3),
+	}, logs.FilterMessage("marshaler").All()[0].ContextMap(), "Unexpected fields.")
+
+	assert.Equal(t, map[string]interface{}{"a": int64(1)}, logs.FilterMessage("odd").All()[0].ContextMap(), "Unexpected fields.")
+	odd := logs.FilterMessage(_oddNumberErrMsg).All()
+	require.Len(t, odd, 1, "Expected the dangling key to be reported.")
+	assert.Equal(t, "dangling", odd[0].ContextMap()["ignored"], "Unexpected ignored key.")
+
+	assert.Equal(t, map[string]interface{}{"b": int64(2)}, logs.FilterMessage("non-string").All()[0].ContextMap(), "Unexpected fields.")
+	require.Equal(t, 1, logs.FilterMessage(_nonStringKeyErrMsg).Len(), "Expected the non-string key to be reported.")
+}
+
+func TestCaller(t *testing.T) {
+	logger, logs := newObserved(ladcore.DebugLevel, lad.AddCaller())
+
+	logger.Info("info")
+	logger.V(1).Info("v1")
+	logger.Error(errors.New("e"), "error")
+	logger.WithName("n").WithValues("k", "v").Info("derived")
+
+	helper := func(msg string) {
+		logger.WithCallDepth(1).Info(msg)
+	}
+	helper("depth")
+
+	entries := logs.All()
+	require.Len(t, entries, 5, "Unexpected number of entries.")
+	for _, ent := range entries {
+		assert.Regexp(t, `/logr_test.go:\d+$`, ent.Caller.String(), "Unexpected caller for %q.", ent.Message)
+		assert.Contains(t, ent.Caller.Function, "TestCaller", "Unexpected function for %q.", ent.Message)
+	}
+	assert.NotContains(t, entries[4].Caller.Function, "func", "Expected WithCallDepth to skip the helper.")
+}
+
+func TestGetUnderlying(t *testing.T) {
+	l := lad.NewNop()
+	sink, ok := NewLogger(l).GetSink().(*LogSink)
+	require.True(t, ok, "Expected a *LogSink.")
+	assert.NotNil(t, sink.GetUnderlying(), "Expected the underlying logger.")
+}
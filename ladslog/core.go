@@ -0,0 +1,303 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.21
+
+package ladslog
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+const (
+	// _loggerKey is the attribute holding the name of the lad Logger
+	// that produced an entry.
+	_loggerKey = "logger"
+
+	// _stacktraceKey is the attribute holding an entry's stack trace.
+	_stacktraceKey = "stacktrace"
+)
+
+// slogCore implements ladcore.Core by forwarding entries to a slog.Handler.
+type slogCore struct {
+	handler slog.Handler
+}
+
+// NewCore builds a [ladcore.Core] that forwards entries to the supplied
+// [slog.Handler]. Use it to send the logs of lad-based libraries to an
+// application that is standardized on slog:
+//
+//	logger := lad.New(ladslog.NewCore(slog.Default().Handler()))
+//
+// Fields are converted to slog attributes of the matching kind.
+// ObjectMarshalers become groups, namespaces become groups holding all
+// fields added after them, and ArrayMarshalers become slices of values.
+// Named loggers record their name in the "logger" attribute and stack
+// traces are recorded in the "stacktrace" attribute.
+//
+// Levels map to the slog levels of the same name. DPanic, Panic and Fatal
+// map to slog.LevelError+1, +2 and +3 respectively.
+func NewCore(handler slog.Handler) ladcore.Core {
+	return &slogCore{handler: handler}
+}
+
+var _ ladcore.Core = (*slogCore)(nil)
+
+func (c *slogCore) Enabled(lvl ladcore.Level) bool {
+	return c.handler.Enabled(context.Background(), convertLadLevel(lvl))
+}
+
+func (c *slogCore) With(fields []ladcore.Field) ladcore.Core {
+	enc := newAttrEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	h := c.handler
+	for i, scope := range enc.scopes {
+		if i > 0 {
+			h = h.WithGroup(scope.group)
+		}
+		if len(scope.attrs) > 0 {
+			h = h.WithAttrs(scope.attrs)
+		}
+	}
+	return &slogCore{handler: h}
+}
+
+func (c *slogCore) Check(ent ladcore.Entry, ce *ladcore.CheckedEntry) *ladcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(ent ladcore.Entry, fields []ladcore.Field) error {
+	var pc uintptr
+	if ent.Caller.Defined {
+		pc = ent.Caller.PC
+	}
+	record := slog.NewRecord(ent.Time, convertLadLevel(ent.Level), ent.Message, pc)
+
+	if ent.LoggerName != "" {
+		record.AddAttrs(slog.String(_loggerKey, ent.LoggerName))
+	}
+
+	enc := newAttrEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	record.AddAttrs(enc.attrs()...)
+
+	if ent.Stack != "" {
+		record.AddAttrs(slog.String(_stacktraceKey, ent.Stack))
+	}
+
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+// convertLadLevel maps lad Levels to slog Levels.
+// It's the inverse of convertSlogLevel for the levels that slog defines.
+func convertLadLevel(l ladcore.Level) slog.Level {
+	switch l {
+	case ladcore.DebugLevel:
+		return slog.LevelDebug
+	case ladcore.InfoLevel:
+		return slog.LevelInfo
+	case ladcore.WarnLevel:
+		return slog.LevelWarn
+	case ladcore.ErrorLevel:
+		return slog.LevelError
+	case ladcore.DPanicLevel:
+		return slog.LevelError + 1
+	case ladcore.PanicLevel:
+		return slog.LevelError + 2
+	case ladcore.FatalLevel:
+		return slog.LevelError + 3
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// attrScope holds the attributes added to a namespace.
+type attrScope struct {
+	group string // empty for the top-level scope
+	attrs []slog.Attr
+}
+
+// attrEncoder is a ladcore.ObjectEncoder that builds slog attributes.
+type attrEncoder struct {
+	// scopes holds the top-level scope followed by one scope for each
+	// namespace opened on the encoder, innermost last.
+	scopes []attrScope
+}
+
+var _ ladcore.ObjectEncoder = (*attrEncoder)(nil)
+
+func newAttrEncoder() *attrEncoder {
+	return &attrEncoder{scopes: []attrScope{{}}}
+}
+
+func (e *attrEncoder) add(attr slog.Attr) {
+	cur := &e.scopes[len(e.scopes)-1]
+	cur.attrs = append(cur.attrs, attr)
+}
+
+// attrs returns the encoded attributes, with each namespace nested in the
+// one that encloses it.
+func (e *attrEncoder) attrs() []slog.Attr {
+	attrs := e.scopes[len(e.scopes)-1].attrs
+	for i := len(e.scopes) - 1; i > 0; i-- {
+		parent := e.scopes[i-1].attrs
+		attrs = append(parent[:len(parent):len(parent)], slog.Attr{
+			Key:   e.scopes[i].group,
+			Value: slog.GroupValue(attrs...),
+		})
+	}
+	return attrs
+}
+
+func (e *attrEncoder) AddArray(key string, arr ladcore.ArrayMarshaler) error {
+	// Reuse the in-memory encoder's conversion to []interface{}.
+	m := ladcore.NewMapObjectEncoder()
+	err := m.AddArray(key, arr)
+	e.add(slog.Any(key, m.Fields[key]))
+	return err
+}
+
+func (e *attrEncoder) AddObject(key string, obj ladcore.ObjectMarshaler) error {
+	nested := newAttrEncoder()
+	err := obj.MarshalLogObject(nested)
+	e.add(slog.Attr{Key: key, Value: slog.GroupValue(nested.attrs()...)})
+	return err
+}
+
+func (e *attrEncoder) AddBinary(key string, val []byte) {
+	e.add(slog.Any(key, val))
+}
+
+func (e *attrEncoder) AddByteString(key string, val []byte) {
+	e.add(slog.String(key, string(val)))
+}
+
+func (e *attrEncoder) AddBool(key string, val bool) {
+	e.add(slog.Bool(key, val))
+}
+
+func (e *attrEncoder) AddComplex128(key string, val complex128) {
+	e.add(slog.String(key, formatComplex(val, 64)))
+}
+
+func (e *attrEncoder) AddComplex64(key string, val complex64) {
+	e.add(slog.String(key, formatComplex(complex128(val), 32)))
+}
+
+func (e *attrEncoder) AddDuration(key string, val time.Duration) {
+	e.add(slog.Duration(key, val))
+}
+
+func (e *attrEncoder) AddFloat64(key string, val float64) {
+	e.add(slog.Float64(key, val))
+}
+
+func (e *attrEncoder) AddFloat32(key string, val float32) {
+	e.add(slog.Float64(key, float64(val)))
+}
+
+func (e *attrEncoder) AddInt(key string, val int) {
+	e.add(slog.Int(key, val))
+}
+
+func (e *attrEncoder) AddInt64(key string, val int64) {
+	e.add(slog.Int64(key, val))
+}
+
+func (e *attrEncoder) AddInt32(key string, val int32) {
+	e.add(slog.Int64(key, int64(val)))
+}
+
+func (e *attrEncoder) AddInt16(key string, val int16) {
+	e.add(slog.Int64(key, int64(val)))
+}
+
+func (e *attrEncoder) AddInt8(key string, val int8) {
+	e.add(slog.Int64(key, int64(val)))
+}
+
+func (e *attrEncoder) AddString(key, val string) {
+	e.add(slog.String(key, val))
+}
+
+func (e *attrEncoder) AddTime(key string, val time.Time) {
+	e.add(slog.Time(key, val))
+}
+
+func (e *attrEncoder) AddUint(key string, val uint) {
+	e.add(slog.Uint64(key, uint64(val)))
+}
+
+func (e *attrEncoder) AddUint64(key string, val uint64) {
+	e.add(slog.Uint64(key, val))
+}
+
+func (e *attrEncoder) AddUint32(key string, val uint32) {
+	e.add(slog.Uint64(key, uint64(val)))
+}
+
+func (e *attrEncoder) AddUint16(key string, val uint16) {
+	e.add(slog.Uint64(key, uint64(val)))
+}
+
+func (e *attrEncoder) AddUint8(key string, val uint8) {
+	e.add(slog.Uint64(key, uint64(val)))
+}
+
+func (e *attrEncoder) AddUintptr(key string, val uintptr) {
+	e.add(slog.Uint64(key, uint64(val)))
+}
+
+func (e *attrEncoder) AddReflected(key string, val interface{}) error {
+	e.add(slog.Any(key, val))
+	return nil
+}
+
+func (e *attrEncoder) OpenNamespace(key string) {
+	e.scopes = append(e.scopes, attrScope{group: key})
+}
+
+// formatComplex formats a complex number the way lad's JSON encoder does.
+// slog has no complex kind, and encoding/json can't marshal complex numbers.
+func formatComplex(val complex128, precision int) string {
+	r, i := real(val), imag(val)
+	s := strconv.FormatFloat(r, 'f', -1, precision)
+	if i >= 0 {
+		s += "+"
+	}
+	return s + strconv.FormatFloat(i, 'f', -1, precision) + "i"
+}
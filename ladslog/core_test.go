@@ -0,0 +1,243 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.21
+
+package ladslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+)
+
+type slogTestUser struct {
+	Name string
+	Age  int
+}
+
+func (u slogTestUser) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	enc.AddInt("age", u.Age)
+	return nil
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	enc.AddString("partial", "yes")
+	return errors.New("fail")
+}
+
+// newJSONCore builds a Core writing to a slog.JSONHandler without
+// timestamps, and returns a function that decodes the logged lines.
+func newJSONCore(t *testing.T, lvl slog.Level) (ladcore.Core, func() []map[string]interface{}) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: lvl,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	return NewCore(h), func() []map[string]interface{} {
+		var out []map[string]interface{}
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var m map[string]interface{}
+			require.NoError(t, dec.Decode(&m), "Failed to decode JSON output.")
+			out = append(out, m)
+		}
+		return out
+	}
+}
+
+func TestCoreFields(t *testing.T) {
+	t.Parallel()
+
+	core, output := newJSONCore(t, slog.LevelDebug)
+	logger := lad.New(core)
+
+	logger.Info("msg",
+		lad.Bool("bool", true),
+		lad.ByteString("bytestring", []byte("abc")),
+		lad.Binary("binary", []byte{1, 2}),
+		lad.Complex128("complex", 1+2i),
+		lad.Complex64("complex64", 1.5-2i),
+		lad.Duration("duration", time.Second),
+		lad.Float32("float32", 1.5),
+		lad.Int8("int8", -8),
+		lad.Uint16("uint16", 16),
+		lad.Uintptr("uintptr", 10),
+		lad.Time("when", time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)),
+		lad.Stringer("stringer", time.Minute),
+		lad.Error(errors.New("oops")),
+		lad.Object("user", slogTestUser{Name: "jane", Age: 30}),
+		lad.Inline(slogTestUser{Name: "inline", Age: 1}),
+		lad.Object("broken", failingMarshaler{}),
+		lad.Ints("ints", []int{1, 2}),
+		lad.Objects("users", []slogTestUser{{Name: "a"}}),
+		lad.Reflect("reflect", map[string]int{"k": 1}),
+		lad.Skip(),
+	)
+
+	assert.Equal(t, []map[string]interface{}{{
+		"level":       "INFO",
+		"msg":         "msg",
+		"bool":        true,
+		"bytestring":  "abc",
+		"binary":      "AQI=",
+		"complex":     "1+2i",
+		"complex64":   "1.5-2i",
+		"duration":    float64(time.Second),
+		"float32":     1.5,
+		"int8":        float64(-8),
+		"uint16":      float64(16),
+		"uintptr":     float64(10),
+		"when":        "2023-01-02T03:04:05Z",
+		"stringer":    "1m0s",
+		"error":       "oops",
+		"user":        map[string]interface{}{"name": "jane", "age": float64(30)},
+		"name":        "inline",
+		"age":         float64(1),
+		"broken":      map[string]interface{}{"partial": "yes"},
+		"brokenError": "fail",
+		"ints":        []interface{}{float64(1), float64(2)},
+		"users":       []interface{}{map[string]interface{}{"name": "a", "age": float64(0)}},
+		"reflect":     map[string]interface{}{"k": float64(1)},
+	}}, output(), "Unexpected output.")
+}
+
+func TestCoreNamespaces(t *testing.T) {
+	t.Parallel()
+
+	core, output := newJSONCore(t, slog.LevelDebug)
+	logger := lad.New(core).With(
+		lad.String("service", "api"),
+		lad.Namespace("request"),
+		lad.String("id", "r1"),
+	).Named("http")
+
+	logger.Warn("msg",
+		lad.Int("status", 200),
+		lad.Namespace("timing"),
+		lad.Duration("total", time.Millisecond),
+	)
+	logger.Info("no fields")
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"level":   "WARN",
+			"msg":     "msg",
+			"service": "api",
+			"request": map[string]interface{}{
+				"id":     "r1",
+				"logger": "http",
+				"status": float64(200),
+				"timing": map[string]interface{}{
+					"total": float64(time.Millisecond),
+				},
+			},
+		},
+		{
+			"level":   "INFO",
+			"msg":     "no fields",
+			"service": "api",
+			"request": map[string]interface{}{
+				"id":     "r1",
+				"logger": "http",
+			},
+		},
+	}, output(), "Unexpected output.")
+}
+
+func TestCoreLevels(t *testing.T) {
+	t.Parallel()
+
+	core, output := newJSONCore(t, slog.LevelWarn)
+	assert.False(t, core.Enabled(ladcore.InfoLevel), "Expected Info to be disabled.")
+	assert.True(t, core.Enabled(ladcore.WarnLevel), "Expected Warn to be enabled.")
+
+	logger := lad.New(core)
+	logger.Info("dropped")
+	logger.Error("error")
+	logger.DPanic("dpanic")
+	assert.Panics(t, func() { logger.Panic("panic") }, "Expected Panic to panic.")
+
+	var levels []interface{}
+	for _, line := range output() {
+		levels = append(levels, line["level"])
+	}
+	assert.Equal(t, []interface{}{"ERROR", "ERROR+1", "ERROR+2"}, levels, "Unexpected levels.")
+
+	for _, lvl := range []ladcore.Level{ladcore.DebugLevel, ladcore.InfoLevel, ladcore.WarnLevel, ladcore.ErrorLevel} {
+		assert.Equal(t, lvl, convertSlogLevel(convertLadLevel(lvl)), "Expected %v to round-trip.", lvl)
+	}
+	assert.Equal(t, ladcore.ErrorLevel, convertSlogLevel(convertLadLevel(ladcore.FatalLevel)), "Unexpected level for Fatal.")
+	assert.Equal(t, slog.LevelInfo, convertLadLevel(ladcore.Level(99)), "Expected unknown levels to map to Info.")
+}
+
+func TestCoreCallerAndStack(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})
+	logger := lad.New(NewCore(h), lad.AddCaller(), lad.AddStacktrace(ladcore.ErrorLevel))
+	logger.Error("msg")
+	require.NoError(t, logger.Sync(), "Unexpected error syncing.")
+
+	var out struct {
+		Source struct {
+			File string `json:"file"`
+		} `json:"source"`
+		Stacktrace string `json:"stacktrace"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out), "Failed to decode JSON output.")
+	assert.Regexp(t, `/core_test.go$`, out.Source.File, "Unexpected source file.")
+	assert.Contains(t, out.Stacktrace, "TestCoreCallerAndStack", "Unexpected stack trace.")
+}
+
+func TestCoreRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// slog -> lad -> slog should preserve groups and attributes.
+	core, output := newJSONCore(t, slog.LevelDebug)
+	sl := slog.New(NewHandler(core)).WithGroup("g").With("a", 1)
+	sl.Info("msg", slog.Group("sub", "b", true))
+
+	assert.Equal(t, []map[string]interface{}{{
+		"level": "INFO",
+		"msg":   "msg",
+		"g": map[string]interface{}{
+			"a":   float64(1),
+			"sub": map[string]interface{}{"b": true},
+		},
+	}}, output(), "Unexpected output.")
+}
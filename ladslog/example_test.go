@@ -29,7 +29,7 @@ import (
 	"time"
 
 	"github.com/tnngo/lad"
-	"github.com/tnngo/lad/exp/ladslog"
+	"github.com/tnngo/lad/ladslog"
 )
 
 type Password string
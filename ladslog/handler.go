@@ -34,11 +34,17 @@ import (
 
 // Handler implements the slog.Handler by writing to a zap Core.
 type Handler struct {
-	core       ladcore.Core
-	name       string // logger name
-	addCaller  bool
-	addStackAt slog.Level
-	callerSkip int
+	core        ladcore.Core
+	name        string // logger name
+	addCaller   bool
+	addSource   bool
+	addStackAt  slog.Level
+	callerSkip  int
+	replaceAttr func(groups []string, attr slog.Attr) slog.Attr
+	levelMapper func(slog.Level) ladcore.Level
+
+	// All groups opened with WithGroup, passed to ReplaceAttr.
+	openGroups []string
 
 	// List of unapplied groups.
 	//
@@ -52,8 +58,9 @@ type Handler struct {
 // with options.
 func NewHandler(core ladcore.Core, opts ...HandlerOption) *Handler {
 	h := &Handler{
-		core:       core,
-		addStackAt: slog.LevelError,
+		core:        core,
+		addStackAt:  slog.LevelError,
+		levelMapper: convertSlogLevel,
 	}
 	for _, v := range opts {
 		v.apply(h)
@@ -63,17 +70,37 @@ func NewHandler(core ladcore.Core, opts ...HandlerOption) *Handler {
 
 var _ slog.Handler = (*Handler)(nil)
 
-// groupObject holds all the Attrs saved in a slog.GroupValue.
-type groupObject []slog.Attr
+// groupObject holds the converted attributes of a slog.GroupValue.
+type groupObject []ladcore.Field
 
 func (gs groupObject) MarshalLogObject(enc ladcore.ObjectEncoder) error {
-	for _, attr := range gs {
-		convertAttrToField(attr).AddTo(enc)
+	for _, f := range gs {
+		f.AddTo(enc)
 	}
 	return nil
 }
 
-func convertAttrToField(attr slog.Attr) ladcore.Field {
+// sourceObject encodes a slog.Source the way slog's handlers do.
+type sourceObject slog.Source
+
+func (s *sourceObject) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	enc.AddString("function", s.Function)
+	enc.AddString("file", s.File)
+	enc.AddInt("line", s.Line)
+	return nil
+}
+
+// convertAttrToField converts an attribute nested in the given groups,
+// resolving LogValuers and applying ReplaceAttr at every level.
+//
+// It returns a Skip field for attributes that must be omitted: empty attrs,
+// attrs removed by ReplaceAttr, and groups without attributes.
+func (h *Handler) convertAttrToField(groups []string, attr slog.Attr) ladcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if h.replaceAttr != nil && attr.Value.Kind() != slog.KindGroup {
+		attr = h.replaceAttr(groups, attr)
+		attr.Value = attr.Value.Resolve()
+	}
 	if attr.Equal(slog.Attr{}) {
 		// Ignore empty attrs.
 		return lad.Skip()
@@ -95,26 +122,44 @@ func convertAttrToField(attr slog.Attr) ladcore.Field {
 	case slog.KindUint64:
 		return lad.Uint64(attr.Key, attr.Value.Uint64())
 	case slog.KindGroup:
+		inner := groups
+		if attr.Key != "" {
+			inner = append(groups[:len(groups):len(groups)], attr.Key)
+		}
+		fields := h.convertAttrs(inner, attr.Value.Group())
+		if len(fields) == 0 {
+			// Groups without attributes are omitted.
+			return lad.Skip()
+		}
 		if attr.Key == "" {
 			// Inlines recursively.
-			return lad.Inline(groupObject(attr.Value.Group()))
+			return lad.Inline(groupObject(fields))
 		}
-		return lad.Object(attr.Key, groupObject(attr.Value.Group()))
-	case slog.KindLogValuer:
-		return convertAttrToField(slog.Attr{
-			Key: attr.Key,
-			// TODO: resolve the value in a lazy way.
-			// This probably needs a new Zap field type
-			// that can be resolved lazily.
-			Value: attr.Value.Resolve(),
-		})
+		return lad.Object(attr.Key, groupObject(fields))
 	default:
+		if src, ok := attr.Value.Any().(*slog.Source); ok && src != nil {
+			return lad.Object(attr.Key, (*sourceObject)(src))
+		}
 		return lad.Any(attr.Key, attr.Value.Any())
 	}
 }
 
+// convertAttrs converts a list of attributes nested in the given groups,
+// dropping those that must be omitted.
+func (h *Handler) convertAttrs(groups []string, attrs []slog.Attr) []ladcore.Field {
+	var fields []ladcore.Field
+	for _, attr := range attrs {
+		if f := h.convertAttrToField(groups, attr); f.Type != ladcore.SkipType {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
 // convertSlogLevel maps slog Levels to zap Levels.
 // Note that there is some room between slog levels while zap levels are continuous, so we can't 1:1 map them.
+// Levels between the standard ones are rounded down to the nearest standard
+// level; use [WithLevelMapper] to map them differently.
 // See also https://go.googlesource.com/proposal/+/master/design/56345-structured-logging.md?pli=1#levels
 func convertSlogLevel(l slog.Level) ladcore.Level {
 	switch {
@@ -131,13 +176,13 @@ func convertSlogLevel(l slog.Level) ladcore.Level {
 
 // Enabled reports whether the handler handles records at the given level.
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.core.Enabled(convertSlogLevel(level))
+	return h.core.Enabled(h.levelMapper(level))
 }
 
 // Handle handles the Record.
 func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
 	ent := ladcore.Entry{
-		Level:      convertSlogLevel(record.Level),
+		Level:      h.levelMapper(record.Level),
 		Time:       record.Time,
 		Message:    record.Message,
 		LoggerName: h.name,
@@ -147,16 +192,18 @@ func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
 		return nil
 	}
 
-	if h.addCaller && record.PC != 0 {
-		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
-		if frame.PC != 0 {
-			ce.Caller = ladcore.EntryCaller{
-				Defined:  true,
-				PC:       frame.PC,
-				File:     frame.File,
-				Line:     frame.Line,
-				Function: frame.Function,
-			}
+	var frame runtime.Frame
+	if (h.addCaller || h.addSource) && record.PC != 0 {
+		frame, _ = runtime.CallersFrames([]uintptr{record.PC}).Next()
+	}
+
+	if h.addCaller && frame.PC != 0 {
+		ce.Caller = ladcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
 		}
 	}
 
@@ -168,12 +215,28 @@ func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
 		ce.Stack = stacktrace.Take(3 + h.callerSkip)
 	}
 
-	fields := make([]ladcore.Field, 0, record.NumAttrs()+len(h.groups))
+	fields := make([]ladcore.Field, 0, record.NumAttrs()+len(h.groups)+1)
+
+	if h.addSource && frame.PC != 0 {
+		// Like slog's handlers, pass the source to ReplaceAttr as a
+		// built-in attribute outside any groups.
+		f := h.convertAttrToField(nil, slog.Any(slog.SourceKey, &slog.Source{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}))
+		if f.Type != ladcore.SkipType {
+			fields = append(fields, f)
+		}
+	}
 
 	var addedNamespace bool
 	record.Attrs(func(attr slog.Attr) bool {
-		f := convertAttrToField(attr)
-		if !addedNamespace && len(h.groups) > 0 && f != lad.Skip() {
+		f := h.convertAttrToField(h.openGroups, attr)
+		if f.Type == ladcore.SkipType {
+			return true
+		}
+		if !addedNamespace && len(h.groups) > 0 {
 			// Namespaces are added only if at least one field is present
 			// to avoid creating empty groups.
 			fields = h.appendGroups(fields)
@@ -196,37 +259,33 @@ func (h *Handler) appendGroups(fields []ladcore.Field) []ladcore.Field {
 
 // WithAttrs returns a new Handler whose attributes consist of
 // both the receiver's attributes and the arguments.
+//
+// LogValuers among the attributes are resolved immediately.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	fields := make([]ladcore.Field, 0, len(attrs)+len(h.groups))
-	var addedNamespace bool
-	for _, attr := range attrs {
-		f := convertAttrToField(attr)
-		if !addedNamespace && len(h.groups) > 0 && f != lad.Skip() {
-			// Namespaces are added only if at least one field is present
-			// to avoid creating empty groups.
-			fields = h.appendGroups(fields)
-			addedNamespace = true
-		}
-		fields = append(fields, f)
+	converted := h.convertAttrs(h.openGroups, attrs)
+	if len(converted) == 0 {
+		return h
 	}
 
+	fields := make([]ladcore.Field, 0, len(converted)+len(h.groups))
+	fields = append(h.appendGroups(fields), converted...)
+
 	cloned := *h
 	cloned.core = h.core.With(fields)
-	if addedNamespace {
-		// These groups have been applied so we can clear them.
-		cloned.groups = nil
-	}
+	// These groups have been applied so we can clear them.
+	cloned.groups = nil
 	return &cloned
 }
 
 // WithGroup returns a new Handler with the given group appended to
 // the receiver's existing groups.
 func (h *Handler) WithGroup(group string) slog.Handler {
-	newGroups := make([]string, len(h.groups)+1)
-	copy(newGroups, h.groups)
-	newGroups[len(h.groups)] = group
+	if group == "" {
+		return h
+	}
 
 	cloned := *h
-	cloned.groups = newGroups
+	cloned.groups = append(h.groups[:len(h.groups):len(h.groups)], group)
+	cloned.openGroups = append(h.openGroups[:len(h.openGroups):len(h.openGroups)], group)
 	return &cloned
 }
@@ -24,8 +24,10 @@ package ladslog
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
+	"path/filepath"
 	"sync"
 	"testing"
 	"testing/slogtest"
@@ -64,7 +66,7 @@ func TestAddStack(t *testing.T) {
 	entry := logs.AllUntimed()[0]
 	require.Equal(t, "msg", entry.Message, "Unexpected message")
 	assert.Regexp(t,
-		`^github.com/tnngo/lad/exp/ladslog.TestAddStack`,
+		`^github.com/tnngo/lad/ladslog.TestAddStack`,
 		entry.Stack,
 		"Unexpected stack trace annotation.",
 	)
@@ -380,6 +382,155 @@ func TestAttrKinds(t *testing.T) {
 		entry.ContextMap())
 }
 
+type lazyGroup struct{}
+
+func (lazyGroup) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("user", "jane"), slog.Any("token", Token("secret")))
+}
+
+type lazyEmpty struct{}
+
+func (lazyEmpty) LogValue() slog.Value {
+	return slog.GroupValue()
+}
+
+func TestLogValuerInGroups(t *testing.T) {
+	fac, logs := observer.New(ladcore.DebugLevel)
+	sl := slog.New(NewHandler(fac)).WithGroup("G").With("lazy", lazyGroup{}, "empty", lazyEmpty{})
+	sl.Info("msg", slog.Group("sub", "tok", Token("x")))
+
+	require.Len(t, logs.AllUntimed(), 1, "Expected exactly one entry to be logged")
+	assert.Equal(t, map[string]any{
+		"G": map[string]any{
+			"lazy": map[string]any{"user": "jane", "token": "REDACTED_TOKEN"},
+			"sub":  map[string]any{"tok": "REDACTED_TOKEN"},
+		},
+	}, logs.AllUntimed()[0].ContextMap(), "Unexpected context")
+}
+
+func TestReplaceAttr(t *testing.T) {
+	fac, logs := observer.New(ladcore.DebugLevel)
+
+	var seen [][]string
+	replace := func(groups []string, a slog.Attr) slog.Attr {
+		seen = append(seen, append([]string{a.Key}, groups...))
+		switch a.Key {
+		case "password":
+			return slog.String(a.Key, "***")
+		case "drop":
+			return slog.Attr{}
+		case "token":
+			// LogValuers are resolved before ReplaceAttr sees them.
+			assert.Equal(t, "REDACTED_TOKEN", a.Value.String(), "Expected a resolved value.")
+		}
+		return a
+	}
+
+	sl := slog.New(NewHandler(fac, WithReplaceAttr(replace))).
+		With("drop", 1).
+		WithGroup("G").
+		With("password", "hunter2")
+	sl.Info("msg",
+		"token", Token("t"),
+		slog.Group("empty", "drop", 2),
+		slog.Group("", slog.Group("inner", "x", 1)),
+	)
+
+	require.Len(t, logs.AllUntimed(), 1, "Expected exactly one entry to be logged")
+	assert.Equal(t, map[string]any{
+		"G": map[string]any{
+			"password": "***",
+			"token":    "REDACTED_TOKEN",
+			"inner":    map[string]any{"x": int64(1)},
+		},
+	}, logs.AllUntimed()[0].ContextMap(), "Unexpected context")
+	assert.Equal(t, [][]string{
+		{"drop"},
+		{"password", "G"},
+		{"token", "G"},
+		{"drop", "G", "empty"},
+		{"x", "G", "inner"},
+	}, seen, "Unexpected ReplaceAttr calls")
+}
+
+func TestLevelMapper(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		tests := []struct {
+			give slog.Level
+			want ladcore.Level
+		}{
+			{slog.LevelDebug - 4, ladcore.DebugLevel},
+			{slog.LevelDebug + 2, ladcore.DebugLevel},
+			{slog.LevelInfo + 2, ladcore.InfoLevel},
+			{slog.LevelWarn + 1, ladcore.WarnLevel},
+			{slog.LevelError + 8, ladcore.ErrorLevel},
+		}
+		fac, logs := observer.New(ladcore.DebugLevel)
+		sl := slog.New(NewHandler(fac))
+		for _, tt := range tests {
+			sl.Log(context.Background(), tt.give, "msg")
+			entries := logs.TakeAll()
+			require.Len(t, entries, 1, "Expected exactly one entry to be logged")
+			assert.Equal(t, tt.want, entries[0].Level, "Unexpected level for %v", tt.give)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		fac, logs := observer.New(ladcore.WarnLevel)
+		h := NewHandler(fac, WithLevelMapper(func(l slog.Level) ladcore.Level {
+			if l > slog.LevelInfo && l < slog.LevelWarn {
+				return ladcore.WarnLevel // NOTICE
+			}
+			return ladcore.DebugLevel
+		}))
+		assert.True(t, h.Enabled(context.Background(), slog.LevelInfo+2), "Expected mapped level to be enabled")
+		assert.False(t, h.Enabled(context.Background(), slog.LevelError), "Expected mapped level to be disabled")
+
+		slog.New(h).Log(context.Background(), slog.LevelInfo+2, "notice")
+		require.Len(t, logs.AllUntimed(), 1, "Expected exactly one entry to be logged")
+		assert.Equal(t, ladcore.WarnLevel, logs.AllUntimed()[0].Level, "Unexpected level")
+	})
+}
+
+func TestWithSource(t *testing.T) {
+	var buff bytes.Buffer
+	core := ladcore.NewCore(
+		ladcore.NewJSONEncoder(ladcore.EncoderConfig{MessageKey: "msg"}),
+		ladcore.AddSync(&buff),
+		ladcore.DebugLevel,
+	)
+
+	sl := slog.New(NewHandler(core, WithSource(true))).WithGroup("G")
+	sl.Info("msg", "k", "v")
+
+	var got struct {
+		Source struct {
+			Function string `json:"function"`
+			File     string `json:"file"`
+			Line     int    `json:"line"`
+		} `json:"source"`
+		G map[string]any `json:"G"`
+	}
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &got), "Failed to decode output")
+	assert.Equal(t, "github.com/tnngo/lad/ladslog.TestWithSource", got.Source.Function, "Unexpected function")
+	assert.Regexp(t, `/handler_test.go$`, got.Source.File, "Unexpected file")
+	assert.NotZero(t, got.Source.Line, "Expected a line number")
+	assert.Equal(t, map[string]any{"k": "v"}, got.G, "Expected the source outside groups")
+
+	t.Run("replaced", func(t *testing.T) {
+		buff.Reset()
+		sl := slog.New(NewHandler(core, WithSource(true), WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.SourceKey && len(groups) == 0 {
+				src := a.Value.Any().(*slog.Source)
+				return slog.String("src", filepath.Base(src.File))
+			}
+			return a
+		})))
+		sl.Info("msg")
+		assert.Equal(t, `{"msg":"msg","src":"handler_test.go"}`+"\n", buff.String(), "Unexpected output")
+	})
+}
+
 func TestSlogtest(t *testing.T) {
 	var buff bytes.Buffer
 	core := ladcore.NewCore(
@@ -22,7 +22,11 @@
 
 package ladslog
 
-import "log/slog"
+import (
+	"log/slog"
+
+	"github.com/tnngo/lad/ladcore"
+)
 
 // A HandlerOption configures a slog Handler.
 type HandlerOption interface {
@@ -70,3 +74,45 @@ func AddStacktraceAt(lvl slog.Level) HandlerOption {
 		log.addStackAt = lvl
 	})
 }
+
+// WithSource configures the Handler to record the source of each message in
+// the "source" field, formatted as slog's handlers do with
+// slog.HandlerOptions.AddSource: an object holding the function, file and
+// line of the caller.
+//
+// This differs from [WithCaller], which records the caller in the entry and
+// leaves its formatting to the Core's encoder.
+func WithSource(enabled bool) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) {
+		h.addSource = enabled
+	})
+}
+
+// WithReplaceAttr configures the Handler to rewrite or remove attributes
+// before they're logged, like slog.HandlerOptions.ReplaceAttr.
+//
+// The function is called for each non-group attribute, after LogValuers are
+// resolved, with the names of the groups that contain it. It is also called
+// for the "source" attribute added by [WithSource]. Returning an empty Attr
+// removes the attribute; groups left without attributes are removed too.
+//
+// The entry's time, level and message are written by the Core's encoder and
+// aren't passed to the function. Configure them with the encoder instead.
+func WithReplaceAttr(replace func(groups []string, attr slog.Attr) slog.Attr) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) {
+		h.replaceAttr = replace
+	})
+}
+
+// WithLevelMapper configures how slog levels map to lad levels.
+//
+// By default, slog's standard levels map to the lad levels of the same name
+// and levels between them round down to the nearest standard level. For
+// example, slog.LevelInfo+2 maps to InfoLevel. Use this option to map
+// custom levels differently, for instance to treat slog.LevelError+4 as
+// DPanicLevel.
+func WithLevelMapper(mapper func(slog.Level) ladcore.Level) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) {
+		h.levelMapper = mapper
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.22
+
+package ladslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// TestSlogtestRun runs each slogtest case as a subtest against handlers
+// built with different options.
+func TestSlogtestRun(t *testing.T) {
+	identity := func(_ []string, a slog.Attr) slog.Attr { return a }
+
+	tests := []struct {
+		name string
+		opts []HandlerOption
+	}{
+		{name: "default"},
+		{name: "caller", opts: []HandlerOption{WithCaller(true), WithName("named")}},
+		{name: "replace attr", opts: []HandlerOption{WithReplaceAttr(identity)}},
+		{name: "level mapper", opts: []HandlerOption{WithLevelMapper(convertSlogLevel)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buff *bytes.Buffer
+			newHandler := func(t *testing.T) slog.Handler {
+				buff = new(bytes.Buffer)
+				core := ladcore.NewCore(
+					ladcore.NewJSONEncoder(ladcore.EncoderConfig{
+						TimeKey:     slog.TimeKey,
+						MessageKey:  slog.MessageKey,
+						LevelKey:    slog.LevelKey,
+						EncodeLevel: ladcore.CapitalLevelEncoder,
+						EncodeTime:  ladcore.RFC3339TimeEncoder,
+					}),
+					ladcore.AddSync(buff),
+					ladcore.DebugLevel,
+				)
+				return NewHandler(core, tt.opts...)
+			}
+			result := func(t *testing.T) map[string]any {
+				var ent map[string]any
+				require.NoError(t, json.Unmarshal(buff.Bytes(), &ent), "Error decoding log message")
+				return ent
+			}
+			slogtest.Run(t, newHandler, result)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladtest
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// GoldenTime is the time reported by the frozen clock of a Golden logger.
+var GoldenTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// UpdateGoldenEnv is the environment variable that makes Golden rewrite its
+// golden files instead of comparing against them. A flag would clash with
+// test packages that define their own -update.
+const UpdateGoldenEnv = "LADTEST_UPDATE"
+
+// updateGolden reports whether $LADTEST_UPDATE is set to a true value.
+func updateGolden() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateGoldenEnv))
+	return update
+}
+
+// GoldenOption configures a Golden built by NewGolden.
+type GoldenOption interface {
+	applyGoldenOption(*goldenOptions)
+}
+
+type goldenOptions struct {
+	Level        ladcore.LevelEnabler
+	Clock        ladcore.Clock
+	Dir          string
+	Replacements []goldenReplacement
+	zapOptions   []lad.Option
+}
+
+type goldenReplacement struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+type goldenOptionFunc func(*goldenOptions)
+
+func (f goldenOptionFunc) applyGoldenOption(opts *goldenOptions) {
+	f(opts)
+}
+
+// GoldenLevel controls which messages are captured by a Golden. It defaults
+// to lad.DebugLevel.
+func GoldenLevel(enab ladcore.LevelEnabler) GoldenOption {
+	return goldenOptionFunc(func(opts *goldenOptions) {
+		opts.Level = enab
+	})
+}
+
+// GoldenClock replaces the frozen clock, which always reports GoldenTime.
+func GoldenClock(clock ladcore.Clock) GoldenOption {
+	return goldenOptionFunc(func(opts *goldenOptions) {
+		opts.Clock = clock
+	})
+}
+
+// GoldenDir sets the directory holding golden files. It defaults to
+// "testdata".
+func GoldenDir(dir string) GoldenOption {
+	return goldenOptionFunc(func(opts *goldenOptions) {
+		opts.Dir = dir
+	})
+}
+
+// GoldenReplace replaces every match of re in the captured output with repl,
+// as regexp.ReplaceAllString does. Use it to normalize values that vary
+// between runs, such as IDs or durations.
+func GoldenReplace(re *regexp.Regexp, repl string) GoldenOption {
+	return goldenOptionFunc(func(opts *goldenOptions) {
+		opts.Replacements = append(opts.Replacements, goldenReplacement{re, repl})
+	})
+}
+
+// GoldenWrapOptions adds lad.Option's to the Logger of a Golden.
+func GoldenWrapOptions(zapOpts ...lad.Option) GoldenOption {
+	return goldenOptionFunc(func(opts *goldenOptions) {
+		opts.zapOptions = zapOpts
+	})
+}
+
+// Golden captures the encoded output of a Logger so that it can be compared
+// against a golden file.
+//
+//	g := ladtest.NewGolden(ladcore.NewJSONEncoder(lad.NewProductionEncoderConfig()))
+//	runCodeUnderTest(g.Logger())
+//	g.AssertMatches(t, "code_under_test")
+//
+// Entry timestamps come from a frozen clock, and the volatile parts of
+// entries are normalized before encoding: callers are reduced to their file
+// name with the line number set to 0, and stack traces are reduced to the
+// names of the functions they contain, leaving out frames from the runtime
+// and testing packages. The goroutine dumps of lad.WithGoroutineDump are
+// reduced the same way to the stack of the logging goroutine.
+//
+// Error stacks are rendered by the encoder, out of reach of Golden, so leave
+// EncoderConfig.ErrorStackSuffix empty in the encoder of a Golden.
+//
+// Run the tests with LADTEST_UPDATE=1 in the environment to create or
+// rewrite the golden files.
+type Golden struct {
+	buf    Buffer
+	opts   goldenOptions
+	logger *lad.Logger
+}
+
+// NewGolden builds a Golden that encodes entries with enc.
+func NewGolden(enc ladcore.Encoder, opts ...GoldenOption) *Golden {
+	g := &Golden{
+		opts: goldenOptions{
+			Level: ladcore.DebugLevel,
+			Clock: frozenClock{},
+			Dir:   "testdata",
+		},
+	}
+	for _, o := range opts {
+		o.applyGoldenOption(&g.opts)
+	}
+
+	core := ladcore.NewCore(enc, ladcore.Lock(&g.buf), g.opts.Level)
+	zapOptions := []lad.Option{lad.WithClock(g.opts.Clock)}
+	zapOptions = append(zapOptions, g.opts.zapOptions...)
+	g.logger = lad.New(normalizingCore{core}, zapOptions...)
+	return g
+}
+
+// Logger returns the Logger whose output is captured.
+func (g *Golden) Logger() *lad.Logger {
+	return g.logger
+}
+
+// Output returns the captured output after applying the replacements
+// configured with GoldenReplace.
+func (g *Golden) Output() string {
+	out := g.buf.String()
+	for _, r := range g.opts.Replacements {
+		out = r.re.ReplaceAllString(out, r.repl)
+	}
+	return out
+}
+
+// AssertMatches compares the captured output with the golden file
+// <dir>/<name>.golden, failing the test if they differ. If $LADTEST_UPDATE
+// is true, it writes the output to the golden file instead.
+func (g *Golden) AssertMatches(t TestingT, name string) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	path := filepath.Join(g.opts.Dir, name+".golden")
+	got := g.Output()
+	if updateGolden() {
+		if err := os.MkdirAll(g.opts.Dir, 0o755); err != nil {
+			t.Errorf("creating golden file directory: %v", err)
+			return false
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Errorf("updating golden file: %v", err)
+			return false
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("reading golden file (run with LADTEST_UPDATE=1 to create it): %v", err)
+		return false
+	}
+	if string(want) != got {
+		t.Errorf("output doesn't match golden file %v (run with LADTEST_UPDATE=1 to rewrite it):\n%s",
+			path, lineDiff(string(want), got))
+		return false
+	}
+	return true
+}
+
+// lineDiff lists the lines that differ between want and got.
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	n := len(wantLines)
+	if len(gotLines) > n {
+		n = len(gotLines)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		sb.WriteString("line ")
+		sb.WriteString(strconv.Itoa(i + 1))
+		sb.WriteString(":\n\t- ")
+		sb.WriteString(w)
+		sb.WriteString("\n\t+ ")
+		sb.WriteString(g)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+type frozenClock struct{}
+
+func (frozenClock) Now() time.Time {
+	return GoldenTime
+}
+
+func (frozenClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+// normalizingCore strips the volatile parts of entries before passing them
+// on to the wrapped Core.
+type normalizingCore struct {
+	ladcore.Core
+}
+
+func (c normalizingCore) With(fields []ladcore.Field) ladcore.Core {
+	return normalizingCore{c.Core.With(fields)}
+}
+
+func (c normalizingCore) Check(ent ladcore.Entry, ce *ladcore.CheckedEntry) *ladcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c normalizingCore) Write(ent ladcore.Entry, fields []ladcore.Field) error {
+	if ent.Caller.Defined {
+		ent.Caller.File = filepath.Base(ent.Caller.File)
+		ent.Caller.Line = 0
+		ent.Caller.PC = 0
+	}
+	if ent.Stack != "" {
+		ent.Stack = normalizeStack(ent.Stack)
+	}
+	copied := false
+	for i, f := range fields {
+		if f.Key != _goroutineDumpKey || f.Type != ladcore.StringType {
+			continue
+		}
+		if !copied {
+			// The fields belong to the caller.
+			fields = append([]ladcore.Field(nil), fields...)
+			copied = true
+		}
+		fields[i].String = normalizeGoroutineDump(f.String)
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// _goroutineDumpKey is the key of the field added by lad.WithGoroutineDump.
+const _goroutineDumpKey = "goroutines"
+
+// normalizeStack keeps only the function names of a stack trace formatted by
+// lad or runtime.Stack, dropping frames from the runtime and testing
+// packages along with goroutine IDs.
+func normalizeStack(stack string) string {
+	var funcs []string
+	for _, line := range strings.Split(stack, "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if strings.HasPrefix(line, "goroutine ") || strings.HasPrefix(line, "created by ") {
+			continue
+		}
+		if strings.HasPrefix(line, "runtime.") || strings.HasPrefix(line, "testing.") {
+			continue
+		}
+		funcs = append(funcs, line)
+	}
+	return strings.Join(funcs, "\n")
+}
+
+// normalizeGoroutineDump reduces a dump of all goroutines to the normalized
+// stack of the first one, which runtime.Stack reports for the calling
+// goroutine. The others vary from run to run, as do the argument values
+// runtime.Stack prints after function names.
+func normalizeGoroutineDump(dump string) string {
+	first, _, _ := strings.Cut(dump, "\n\n")
+	funcs := strings.Split(normalizeStack(first), "\n")
+	for i, fn := range funcs {
+		if strings.HasSuffix(fn, ")") {
+			if j := strings.LastIndexByte(fn, '('); j > 0 {
+				funcs[i] = fn[:j]
+			}
+		}
+	}
+	return strings.Join(funcs, "\n")
+}
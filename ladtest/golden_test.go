@@ -0,0 +1,165 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ladtest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/internal/exit"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// errorSpy is a testing.TB that records errors instead of failing the test.
+type errorSpy struct {
+	testing.TB
+
+	errors []string
+}
+
+func (t *errorSpy) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func logGoldenScenario(logger *lad.Logger) {
+	logger = logger.Named("golden").With(lad.String("request_id", "req-8c1f"))
+	logger.Debug("starting", lad.Int("attempt", 1))
+	logger.Info("request served", lad.Int("status", 200), lad.Dict("user", lad.String("name", "jane")))
+	logger.Error("request failed", lad.Error(fmt.Errorf("boom")))
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  ladcore.Encoder
+	}{
+		{"json", ladcore.NewJSONEncoder(lad.NewProductionEncoderConfig())},
+		{"console", ladcore.NewConsoleEncoder(lad.NewDevelopmentEncoderConfig())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGolden(tt.enc,
+				GoldenWrapOptions(lad.AddCaller(), lad.AddStacktrace(lad.ErrorLevel)),
+				GoldenReplace(regexp.MustCompile(`req-[0-9a-f]+`), "req-ID"),
+			)
+			logGoldenScenario(g.Logger())
+			assert.True(t, g.AssertMatches(t, "golden_"+tt.name), "Expected output to match golden file.")
+		})
+	}
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	if updateGolden() {
+		t.Skip("Golden files are being updated.")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mismatch.golden"), []byte("old line\n"), 0o644), "Failed to write golden file.")
+
+	g := NewGolden(
+		ladcore.NewConsoleEncoder(lad.NewDevelopmentEncoderConfig()),
+		GoldenDir(dir),
+		GoldenLevel(lad.InfoLevel),
+	)
+	g.Logger().Debug("dropped")
+	g.Logger().Info("new line")
+
+	spy := &errorSpy{TB: t}
+	assert.False(t, g.AssertMatches(spy, "mismatch"), "Expected a mismatch.")
+	require.Len(t, spy.errors, 1, "Expected exactly one error.")
+	assert.Contains(t, spy.errors[0], "line 1:\n\t- old line\n\t+ 2024-01-01T00:00:00.000Z\tINFO\tnew line", "Unexpected diff.")
+
+	spy = &errorSpy{TB: t}
+	assert.False(t, g.AssertMatches(spy, "missing"), "Expected an error for a missing golden file.")
+	require.Len(t, spy.errors, 1, "Expected exactly one error.")
+	assert.Contains(t, spy.errors[0], "run with LADTEST_UPDATE=1 to create it", "Unexpected error.")
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	assert.Nil(t, flag.Lookup("update"), "Expected ladtest not to register an -update flag.")
+
+	dir := t.TempDir()
+	t.Setenv(UpdateGoldenEnv, "1")
+	g := NewGolden(ladcore.NewConsoleEncoder(lad.NewDevelopmentEncoderConfig()), GoldenDir(dir))
+	g.Logger().Info("written")
+	assert.True(t, g.AssertMatches(t, "update"), "Expected updating to succeed.")
+
+	got, err := os.ReadFile(filepath.Join(dir, "update.golden"))
+	require.NoError(t, err, "Expected the golden file to be written.")
+	assert.Equal(t, g.Output(), string(got), "Unexpected golden file contents.")
+}
+
+func TestNormalizeStack(t *testing.T) {
+	stack := "goroutine 7 [running]:\n" +
+		"github.com/tnngo/lad.Stack\n" +
+		"\t/home/user/lad/field.go:123\n" +
+		"github.com/tnngo/lad/ladtest.TestNormalizeStack\n" +
+		"\t/home/user/lad/ladtest/golden_test.go:45\n" +
+		"testing.tRunner\n" +
+		"\t/usr/local/go/src/testing/testing.go:1595\n" +
+		"runtime.goexit\n" +
+		"\t/usr/local/go/src/runtime/asm_amd64.s:1650"
+	assert.Equal(t,
+		"github.com/tnngo/lad.Stack\ngithub.com/tnngo/lad/ladtest.TestNormalizeStack",
+		normalizeStack(stack),
+		"Unexpected normalized stack.")
+}
+
+func TestNormalizeGoroutineDump(t *testing.T) {
+	dump := "goroutine 7 [running]:\n" +
+		"github.com/tnngo/lad/internal/stacktrace.TakeAll()\n" +
+		"\t/home/user/lad/internal/stacktrace/stack.go:213 +0x45\n" +
+		"github.com/tnngo/lad.(*Logger).Fatal(0xc000150000, {0x5d1b2e, 0x5}, {0x0, 0x0, 0x0})\n" +
+		"\t/home/user/lad/logger.go:300 +0x5b\n" +
+		"created by testing.(*T).Run in goroutine 1\n" +
+		"\t/usr/local/go/src/testing/testing.go:1648 +0x3ad\n" +
+		"\n" +
+		"goroutine 1 [chan receive]:\n" +
+		"testing.(*T).Run(0xc0000076c0, {0x5d7a4f, 0xc})\n" +
+		"\t/usr/local/go/src/testing/testing.go:1649 +0x3c8"
+	assert.Equal(t,
+		"github.com/tnngo/lad/internal/stacktrace.TakeAll\ngithub.com/tnngo/lad.(*Logger).Fatal",
+		normalizeGoroutineDump(dump),
+		"Unexpected normalized dump.")
+}
+
+func TestGoldenGoroutineDump(t *testing.T) {
+	g := NewGolden(
+		ladcore.NewJSONEncoder(lad.NewProductionEncoderConfig()),
+		GoldenWrapOptions(lad.WithGoroutineDump(true), lad.StacktraceGoroutine(true)),
+	)
+	exit.WithStub(func() { g.Logger().Fatal("fatal") })
+
+	out := g.Output()
+	assert.Contains(t, out, `"goroutines":"github.com/tnngo/lad/internal/stacktrace.TakeAll\n`, "Expected a normalized dump.")
+	assert.Contains(t, out, "ladtest.TestGoldenGoroutineDump", "Expected the logging goroutine in the dump.")
+	assert.NotContains(t, out, "goroutine ", "Expected goroutine IDs to be removed.")
+	assert.False(t, strings.Contains(out, ".go:") || strings.Contains(out, "0x"), "Expected no file names or arguments:\n%s", out)
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+// _maxCandidates is the number of near misses reported when an assertion
+// fails.
+const _maxCandidates = 3
+
+// TestingT is the subset of *testing.T used by the assertion helpers.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// Expectation describes a log entry that the assertion helpers look for.
+//
+// Fields are matched partially: an entry matches if it has every expected
+// key with an equal value, and it may carry additional keys. Nested objects
+// are compared the same way, so only the keys of interest need to be listed.
+type Expectation struct {
+	Level   ladcore.Level
+	Message string
+	Fields  []ladcore.Field
+}
+
+// Expect builds an Expectation.
+func Expect(level ladcore.Level, msg string, fields ...ladcore.Field) Expectation {
+	return Expectation{Level: level, Message: msg, Fields: fields}
+}
+
+// Matches reports whether the entry satisfies the expectation.
+func (e Expectation) Matches(entry LoggedEntry) bool {
+	return entry.Level == e.Level &&
+		entry.Message == e.Message &&
+		len(e.fieldDiffs(entry.ContextMap())) == 0
+}
+
+func (e Expectation) fieldMap() map[string]interface{} {
+	return LoggedEntry{Context: e.Fields}.ContextMap()
+}
+
+// fieldDiffs describes every expected field that's missing from, or
+// different in, the given context.
+func (e Expectation) fieldDiffs(got map[string]interface{}) []string {
+	want := e.fieldMap()
+	var diffs []string
+	for _, key := range sortedKeys(want) {
+		gv, ok := got[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("field %q: missing, want %s", key, formatValue(want[key])))
+		case !isSubset(want[key], gv):
+			diffs = append(diffs, fmt.Sprintf("field %q: want %s, got %s", key, formatValue(want[key]), formatValue(gv)))
+		}
+	}
+	return diffs
+}
+
+func (e Expectation) String() string {
+	s := fmt.Sprintf("%v %q", e.Level, e.Message)
+	if len(e.Fields) > 0 {
+		s += " " + formatValue(e.fieldMap())
+	}
+	return s
+}
+
+// AssertLogged asserts that at least one observed entry has the given level
+// and message, and carries the given fields. On failure, it reports the
+// closest observed entries and how they differ.
+func (o *ObservedLogs) AssertLogged(t TestingT, level ladcore.Level, msg string, fields ...ladcore.Field) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	want := Expect(level, msg, fields...)
+	logs := o.All()
+	for _, entry := range logs {
+		if want.Matches(entry) {
+			return true
+		}
+	}
+	t.Errorf("expected log entry not found: %v\n%s", want, describeCandidates(want, logs, 0))
+	return false
+}
+
+// AssertNotLogged asserts that no observed entry has the given level and
+// message and carries the given fields.
+func (o *ObservedLogs) AssertNotLogged(t TestingT, level ladcore.Level, msg string, fields ...ladcore.Field) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	want := Expect(level, msg, fields...)
+	for i, entry := range o.All() {
+		if want.Matches(entry) {
+			t.Errorf("unexpected log entry found at index %d: %v\n\tentry: %s", i, want, formatEntry(entry))
+			return false
+		}
+	}
+	return true
+}
+
+// AssertSequence asserts that the observed entries contain the expectations
+// in the given order. Other entries may be interleaved between them.
+func (o *ObservedLogs) AssertSequence(t TestingT, want ...Expectation) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	logs := o.All()
+	next := 0
+	for i, exp := range want {
+		start, found := next, false
+		for ; next < len(logs); next++ {
+			if exp.Matches(logs[next]) {
+				found = true
+				next++
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected log entry %d of %d in sequence not found at or after index %d: %v\n%s",
+				i+1, len(want), start, exp, describeCandidates(exp, logs, start))
+			return false
+		}
+	}
+	return true
+}
+
+type candidate struct {
+	index int
+	score int
+	diffs []string
+}
+
+// describeCandidates lists the entries at or after start that come closest
+// to the expectation, with what sets them apart.
+func describeCandidates(want Expectation, logs []LoggedEntry, start int) string {
+	if start >= len(logs) {
+		return fmt.Sprintf("no entries to compare against (%d observed)", len(logs))
+	}
+
+	wantFields := len(want.fieldMap())
+	cands := make([]candidate, 0, len(logs)-start)
+	for i := start; i < len(logs); i++ {
+		entry := logs[i]
+		c := candidate{index: i}
+		if entry.Message == want.Message {
+			c.score += 2
+		} else {
+			c.diffs = append(c.diffs, fmt.Sprintf("message: want %q, got %q", want.Message, entry.Message))
+		}
+		if entry.Level == want.Level {
+			c.score++
+		} else {
+			c.diffs = append(c.diffs, fmt.Sprintf("level: want %v, got %v", want.Level, entry.Level))
+		}
+		fieldDiffs := want.fieldDiffs(entry.ContextMap())
+		c.score += wantFields - len(fieldDiffs)
+		c.diffs = append(c.diffs, fieldDiffs...)
+		cands = append(cands, c)
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		return cands[i].score > cands[j].score
+	})
+	if len(cands) > _maxCandidates {
+		cands = cands[:_maxCandidates]
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "closest candidates (%d observed):", len(logs))
+	for _, c := range cands {
+		fmt.Fprintf(&sb, "\n\t[%d] %s", c.index, formatEntry(logs[c.index]))
+		for _, d := range c.diffs {
+			fmt.Fprintf(&sb, "\n\t\t%s", d)
+		}
+	}
+	return sb.String()
+}
+
+func formatEntry(entry LoggedEntry) string {
+	s := fmt.Sprintf("%v %q", entry.Level, entry.Message)
+	if len(entry.Context) > 0 {
+		s += " " + formatValue(entry.ContextMap())
+	}
+	return s
+}
+
+func formatValue(v interface{}) string {
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// isSubset reports whether want is equal to got, ignoring keys of nested
+// objects in got that aren't present in want.
+func isSubset(want, got interface{}) bool {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, wv := range w {
+			gv, ok := g[k]
+			if !ok || !isSubset(wv, gv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !isSubset(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
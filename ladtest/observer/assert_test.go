@@ -0,0 +1,140 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+	"github.com/tnngo/lad/ladcore"
+
+	//revive:disable:dot-imports
+	. "github.com/tnngo/lad/ladtest/observer"
+)
+
+// recordingT is a TestingT that records failures instead of reporting them.
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func observedLogs() *ObservedLogs {
+	core, logs := New(lad.DebugLevel)
+	logger := lad.New(core).With(lad.String("service", "api"))
+	logger.Debug("starting")
+	logger.Info("request", lad.Int("status", 200), lad.Dict("user", lad.String("name", "jane"), lad.Int("age", 30)))
+	logger.Warn("slow request", lad.Duration("took", 5))
+	logger.Info("stopping")
+	return logs
+}
+
+func TestAssertLogged(t *testing.T) {
+	logs := observedLogs()
+
+	tests := []struct {
+		desc   string
+		level  ladcore.Level
+		msg    string
+		fields []ladcore.Field
+		ok     bool
+	}{
+		{"message only", lad.InfoLevel, "stopping", nil, true},
+		{"context field", lad.DebugLevel, "starting", []ladcore.Field{lad.String("service", "api")}, true},
+		{"partial nested object", lad.InfoLevel, "request", []ladcore.Field{lad.Dict("user", lad.String("name", "jane"))}, true},
+		{"wrong level", lad.ErrorLevel, "stopping", nil, false},
+		{"wrong nested value", lad.InfoLevel, "request", []ladcore.Field{lad.Dict("user", lad.String("name", "joe"))}, false},
+		{"missing field", lad.InfoLevel, "request", []ladcore.Field{lad.String("path", "/")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			rt := &recordingT{}
+			assert.Equal(t, tt.ok, logs.AssertLogged(rt, tt.level, tt.msg, tt.fields...), "Unexpected AssertLogged result.")
+			assert.Equal(t, !tt.ok, len(rt.errors) > 0, "Unexpected failures reported: %v", rt.errors)
+
+			rt = &recordingT{}
+			assert.Equal(t, !tt.ok, logs.AssertNotLogged(rt, tt.level, tt.msg, tt.fields...), "Unexpected AssertNotLogged result.")
+			assert.Equal(t, tt.ok, len(rt.errors) > 0, "Unexpected failures reported: %v", rt.errors)
+		})
+	}
+}
+
+func TestAssertLoggedFailureMessage(t *testing.T) {
+	logs := observedLogs()
+
+	rt := &recordingT{}
+	logs.AssertLogged(rt, lad.InfoLevel, "request", lad.Int("status", 500), lad.String("path", "/"))
+	require.Len(t, rt.errors, 1, "Expected exactly one failure.")
+
+	msg := rt.errors[0]
+	assert.Contains(t, msg, `expected log entry not found: info "request" {"path":"/","status":500}`, "Expected the expectation in the failure.")
+	assert.Contains(t, msg, "closest candidates (4 observed):\n\t[1] info \"request\"", "Expected the closest candidate first.")
+	assert.Contains(t, msg, `field "path": missing, want "/"`, "Expected a missing field diff.")
+	assert.Contains(t, msg, `field "status": want 500, got 200`, "Expected a field value diff.")
+
+	rt = &recordingT{}
+	_, noLogs := New(lad.InfoLevel)
+	noLogs.AssertLogged(rt, lad.InfoLevel, "anything")
+	require.Len(t, rt.errors, 1, "Expected exactly one failure.")
+	assert.Contains(t, rt.errors[0], "no entries to compare against (0 observed)", "Unexpected failure for empty logs.")
+}
+
+func TestAssertSequence(t *testing.T) {
+	logs := observedLogs()
+
+	rt := &recordingT{}
+	assert.True(t, logs.AssertSequence(rt,
+		Expect(lad.DebugLevel, "starting"),
+		Expect(lad.WarnLevel, "slow request"),
+		Expect(lad.InfoLevel, "stopping"),
+	), "Expected sequence with gaps to match: %v", rt.errors)
+
+	rt = &recordingT{}
+	assert.False(t, logs.AssertSequence(rt,
+		Expect(lad.WarnLevel, "slow request"),
+		Expect(lad.InfoLevel, "request"),
+	), "Expected out-of-order sequence to fail.")
+	require.Len(t, rt.errors, 1, "Expected exactly one failure.")
+	assert.Contains(t, rt.errors[0], "expected log entry 2 of 2 in sequence not found at or after index 3", "Unexpected failure message.")
+	assert.Contains(t, rt.errors[0], "[3] info \"stopping\"", "Expected candidates after the last match.")
+}
+
+func TestExpectationMatches(t *testing.T) {
+	entry := LoggedEntry{
+		Entry: ladcore.Entry{Level: lad.InfoLevel, Message: "msg"},
+		Context: []ladcore.Field{
+			lad.Strings("tags", []string{"a", "b"}),
+			lad.Int("n", 1),
+		},
+	}
+
+	assert.True(t, Expect(lad.InfoLevel, "msg").Matches(entry), "Expected match without fields.")
+	assert.True(t, Expect(lad.InfoLevel, "msg", lad.Strings("tags", []string{"a", "b"})).Matches(entry), "Expected array match.")
+	assert.False(t, Expect(lad.InfoLevel, "msg", lad.Strings("tags", []string{"a"})).Matches(entry), "Expected arrays of different lengths to differ.")
+	assert.False(t, Expect(lad.InfoLevel, "msg", lad.Dict("n", lad.Int("x", 1))).Matches(entry), "Expected object not to match a scalar.")
+	assert.Equal(t, `info "msg" {"n":1}`, Expect(lad.InfoLevel, "msg", lad.Int("n", 1)).String(), "Unexpected String output.")
+}
@@ -37,6 +37,10 @@ import (
 type ObservedLogs struct {
 	mu   sync.RWMutex
 	logs []LoggedEntry
+
+	// added is closed and cleared whenever an entry is added, waking up
+	// goroutines blocked in WaitFor and WaitForN. It's created on demand.
+	added chan struct{}
 }
 
 // Len returns the number of items in the collection.
@@ -147,6 +151,10 @@ func (o *ObservedLogs) Filter(keep func(LoggedEntry) bool) *ObservedLogs {
 func (o *ObservedLogs) add(log LoggedEntry) {
 	o.mu.Lock()
 	o.logs = append(o.logs, log)
+	if o.added != nil {
+		close(o.added)
+		o.added = nil
+	}
 	o.mu.Unlock()
 }
 
@@ -0,0 +1,109 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tnngo/lad/internal/ztest"
+)
+
+// DefaultWaitTimeout is how long WaitFor and WaitForN block when the
+// provided context has no deadline. It's scaled by $TEST_TIMEOUT_SCALE, in
+// the same way as ladtest.Timeout.
+const DefaultWaitTimeout = 5 * time.Second
+
+// WaitFor blocks until an entry for which match returns true has been
+// observed, and returns it.
+//
+// It returns an error if ctx is done first. If ctx has no deadline, WaitFor
+// gives up after DefaultWaitTimeout. It's safe to call while other goroutines
+// are logging.
+//
+// Only entries still held by o are considered, so TakeAll discards entries
+// that a waiter hasn't matched yet. The ObservedLogs returned by Filter and
+// its variants are snapshots that never change, so waiting on them only
+// succeeds if a match is already present.
+func (o *ObservedLogs) WaitFor(ctx context.Context, match func(LoggedEntry) bool) (LoggedEntry, error) {
+	entries, err := o.WaitForN(ctx, 1, match)
+	if err != nil {
+		return LoggedEntry{}, err
+	}
+	return entries[0], nil
+}
+
+// WaitForN blocks until n entries for which match returns true have been
+// observed, and returns the first n of them in the order they were logged.
+// A nil match accepts every entry, and n must be positive.
+//
+// See WaitFor for details on timeouts and concurrency. match is called
+// without holding any locks, so it may use o.
+func (o *ObservedLogs) WaitForN(ctx context.Context, n int, match func(LoggedEntry) bool) ([]LoggedEntry, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("can't wait for %d log entries, n must be positive", n)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ztest.Timeout(DefaultWaitTimeout))
+		defer cancel()
+	}
+
+	for {
+		matched, added := o.collect(n, match)
+		if len(matched) == n {
+			return matched, nil
+		}
+
+		select {
+		case <-added:
+		case <-ctx.Done():
+			return matched, fmt.Errorf("observed %d of %d expected log entries: %w", len(matched), n, ctx.Err())
+		}
+	}
+}
+
+// collect returns up to n matching entries and, if there were fewer than n,
+// a channel that's closed when the next entry is added.
+func (o *ObservedLogs) collect(n int, match func(LoggedEntry) bool) ([]LoggedEntry, <-chan struct{}) {
+	// Take the channel along with the entries, so that entries added while
+	// matching still wake the caller up. Entries are only ever appended, so
+	// the snapshot stays valid after unlocking.
+	o.mu.Lock()
+	logs := o.logs
+	if o.added == nil {
+		o.added = make(chan struct{})
+	}
+	added := o.added
+	o.mu.Unlock()
+
+	var matched []LoggedEntry
+	for _, entry := range logs {
+		if match == nil || match(entry) {
+			matched = append(matched, entry)
+			if len(matched) == n {
+				return matched, nil
+			}
+		}
+	}
+	return matched, added
+}
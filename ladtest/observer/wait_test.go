@@ -0,0 +1,121 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad"
+
+	//revive:disable:dot-imports
+	. "github.com/tnngo/lad/ladtest/observer"
+)
+
+func TestWaitFor(t *testing.T) {
+	core, logs := New(lad.InfoLevel)
+	logger := lad.New(core)
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			logger.Info("tick", lad.Int("i", i))
+		}
+		logger.Info("done")
+	}()
+
+	entry, err := logs.WaitFor(context.Background(), func(e LoggedEntry) bool {
+		return e.Message == "done"
+	})
+	require.NoError(t, err, "Unexpected error waiting for entry.")
+	assert.Equal(t, "done", entry.Message, "Unexpected entry returned.")
+	assert.Equal(t, 6, logs.Len(), "Expected all entries logged before the match.")
+}
+
+func TestWaitForN(t *testing.T) {
+	core, logs := New(lad.InfoLevel)
+	logger := lad.New(core)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			logger.Info("work", lad.Bool("even", i%2 == 0))
+		}(i)
+	}
+	defer wg.Wait()
+
+	even, err := logs.WaitForN(context.Background(), 5, func(e LoggedEntry) bool {
+		return e.ContextMap()["even"] == true
+	})
+	require.NoError(t, err, "Unexpected error waiting for entries.")
+	assert.Len(t, even, 5, "Unexpected number of entries returned.")
+
+	all, err := logs.WaitForN(context.Background(), 10, nil)
+	require.NoError(t, err, "Unexpected error waiting for all entries.")
+	assert.Len(t, all, 10, "Unexpected number of entries returned.")
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	core, logs := New(lad.InfoLevel)
+	lad.New(core).Info("only")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	got, err := logs.WaitForN(ctx, 2, nil)
+	require.Error(t, err, "Expected an error when the context expires.")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Expected the context's error to be wrapped.")
+	assert.Contains(t, err.Error(), "observed 1 of 2 expected log entries", "Unexpected error message.")
+	assert.Len(t, got, 1, "Expected partial results on timeout.")
+
+	_, err = logs.WaitFor(ctx, func(e LoggedEntry) bool { return e.Message == "missing" })
+	assert.Error(t, err, "Expected an error for an expired context.")
+}
+
+func TestWaitForMatchUsesLogs(t *testing.T) {
+	core, logs := New(lad.InfoLevel)
+	logger := lad.New(core)
+	logger.Info("first")
+	go logger.Info("second")
+
+	entry, err := logs.WaitFor(context.Background(), func(e LoggedEntry) bool {
+		return logs.FilterMessage(e.Message).Len() == 1 && logs.Len() == 2
+	})
+	require.NoError(t, err, "Unexpected error waiting from a predicate that reads the logs.")
+	assert.Equal(t, "first", entry.Message, "Unexpected entry returned.")
+}
+
+func TestWaitForNInvalid(t *testing.T) {
+	core, logs := New(lad.InfoLevel)
+	lad.New(core).Info("present")
+
+	for _, n := range []int{0, -1} {
+		start := time.Now()
+		_, err := logs.WaitForN(context.Background(), n, nil)
+		assert.ErrorContains(t, err, "must be positive", "Expected an error for n = %d.", n)
+		assert.Less(t, time.Since(start), time.Second, "Expected WaitForN(%d) to return right away.", n)
+	}
+}
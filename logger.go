@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"strings"
 
 	"github.com/tnngo/lad/internal/bufferpool"
@@ -49,7 +50,11 @@ type Logger struct {
 	name        string
 	errorOutput ladcore.WriteSyncer
 
-	addStack ladcore.LevelEnabler
+	addStack       ladcore.LevelEnabler
+	stackFilter    func(runtime.Frame) bool
+	stackMaxFrames int
+	stackGoroutine bool
+	fatalDump      bool
 
 	callerSkip int
 
@@ -340,7 +345,11 @@ func (log *Logger) check(lvl ladcore.Level, msg string) *ladcore.CheckedEntry {
 		Level:      lvl,
 		Message:    msg,
 	}
-	ce := log.core.Check(ent, nil)
+	core := log.core
+	if lvl == ladcore.FatalLevel && log.fatalDump {
+		core = goroutineDumpCore{Core: core, errorOutput: log.errorOutput}
+	}
+	ce := core.Check(ent, nil)
 	willWrite := ce != nil
 
 	// Set up any required terminal behavior.
@@ -407,13 +416,22 @@ func (log *Logger) check(lvl ladcore.Level, msg string) *ladcore.CheckedEntry {
 		buffer := bufferpool.Get()
 		defer buffer.Free()
 
-		stackfmt := stacktrace.NewFormatter(buffer)
+		if log.stackGoroutine {
+			buffer.AppendString("goroutine ")
+			buffer.AppendInt(stacktrace.GoroutineID())
+			buffer.AppendString(" [running]:\n")
+		}
 
-		// We've already extracted the first frame, so format that
-		// separately and defer to stackfmt for the rest.
-		stackfmt.FormatFrame(frame)
-		if more {
-			stackfmt.FormatStack(stack)
+		stackfmt := stacktrace.NewFormatter(buffer)
+		if log.stackFilter == nil && log.stackMaxFrames <= 0 {
+			// We've already extracted the first frame, so format that
+			// separately and defer to stackfmt for the rest.
+			stackfmt.FormatFrame(frame)
+			if more {
+				stackfmt.FormatStack(stack)
+			}
+		} else {
+			log.formatFilteredStack(&stackfmt, frame, more, stack)
 		}
 		ce.Stack = buffer.String()
 	}
@@ -421,6 +439,24 @@ func (log *Logger) check(lvl ladcore.Level, msg string) *ladcore.CheckedEntry {
 	return ce
 }
 
+// formatFilteredStack formats first and the remaining frames of stack,
+// minus the final runtime.main/runtime.goexit frame, applying the Logger's
+// frame filter and frame limit.
+func (log *Logger) formatFilteredStack(stackfmt *stacktrace.Formatter, frame runtime.Frame, more bool, stack *stacktrace.Stack) {
+	for n := 0; log.stackMaxFrames <= 0 || n < log.stackMaxFrames; {
+		if log.stackFilter == nil || log.stackFilter(frame) {
+			stackfmt.FormatFrame(frame)
+			n++
+		}
+		if !more {
+			return
+		}
+		if frame, more = stack.Next(); !more {
+			return
+		}
+	}
+}
+
 func terminalHookOverride(defaultHook, override ladcore.CheckWriteHook) ladcore.CheckWriteHook {
 	// A nil or WriteThenNoop hook will lead to continued execution after
 	// a Panic or Fatal log entry, which is unexpected. For example,
@@ -437,3 +473,38 @@ func terminalHookOverride(defaultHook, override ladcore.CheckWriteHook) ladcore.
 	}
 	return override
 }
+
+// goroutineDumpCore adds the stack traces of all goroutines to the entries
+// it writes, as configured by WithGoroutineDump. The dump is only taken when
+// an entry is written, so entries that are dropped or only checked don't
+// pay for it.
+type goroutineDumpCore struct {
+	ladcore.Core
+
+	errorOutput ladcore.WriteSyncer
+}
+
+func (c goroutineDumpCore) Check(ent ladcore.Entry, ce *ladcore.CheckedEntry) *ladcore.CheckedEntry {
+	// Let the wrapped Core choose the Cores that write the entry, and write
+	// to them with the dump added.
+	downstream := c.Core.Check(ent, nil)
+	if downstream == nil {
+		return ce
+	}
+	downstream.ErrorOutput = c.errorOutput
+	return ce.AddCore(ent, goroutineDumpWriter{Core: c.Core, ce: downstream})
+}
+
+// goroutineDumpWriter writes entries to the Cores of a CheckedEntry.
+type goroutineDumpWriter struct {
+	ladcore.Core
+
+	ce *ladcore.CheckedEntry
+}
+
+func (w goroutineDumpWriter) Write(ent ladcore.Entry, fields []ladcore.Field) error {
+	// The Logger adds the caller and stack trace after checking.
+	w.ce.Entry = ent
+	w.ce.Write(append(fields[:len(fields):len(fields)], String("goroutines", stacktrace.TakeAll()))...)
+	return nil
+}
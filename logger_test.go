@@ -23,7 +23,9 @@ package lad
 import (
 	"errors"
 	"fmt"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -759,6 +761,61 @@ func TestLoggerAddCallerFail(t *testing.T) {
 	})
 }
 
+func TestLoggerStacktraceOptions(t *testing.T) {
+	stackLines := func(opts ...Option) []string {
+		var lines []string
+		withLogger(t, DebugLevel, append(opts, AddStacktrace(DebugLevel)), func(logger *Logger, logs *observer.ObservedLogs) {
+			logger.Info("")
+			require.Equal(t, 1, logs.Len(), "Expected a single entry.")
+			lines = strings.Split(logs.AllUntimed()[0].Stack, "\n")
+		})
+		return lines
+	}
+
+	t.Run("default", func(t *testing.T) {
+		lines := stackLines()
+		assert.Contains(t, lines[0], "TestLoggerStacktraceOptions", "Expected the stack to start at the test.")
+		assert.Contains(t, strings.Join(lines, "\n"), "testing.tRunner", "Expected test runner frames by default.")
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		lines := stackLines(StacktraceFilter(IsUserFrame))
+		require.NotEmpty(t, lines, "Expected some frames to be kept.")
+		assert.Contains(t, lines[0], "TestLoggerStacktraceOptions", "Expected the stack to start at the test.")
+		assert.NotContains(t, strings.Join(lines, "\n"), "testing.", "Expected test runner frames to be filtered.")
+	})
+
+	t.Run("max frames", func(t *testing.T) {
+		lines := stackLines(StacktraceMaxFrames(1))
+		require.Len(t, lines, 2, "Expected a function and a location line.")
+		assert.Contains(t, lines[0], "TestLoggerStacktraceOptions", "Expected the first frame to be kept.")
+	})
+
+	t.Run("goroutine", func(t *testing.T) {
+		lines := stackLines(StacktraceGoroutine(true), StacktraceMaxFrames(1))
+		require.Len(t, lines, 3, "Expected a goroutine header and a single frame.")
+		assert.Regexp(t, `^goroutine [1-9]\d* \[running\]:$`, lines[0], "Unexpected goroutine header.")
+	})
+}
+
+func TestIsUserFrame(t *testing.T) {
+	tests := []struct {
+		frame runtime.Frame
+		want  bool
+	}{
+		{runtime.Frame{Function: "main.main", File: "/src/app/main.go"}, true},
+		{runtime.Frame{Function: "runtime.goexit", File: "/go/src/runtime/asm_amd64.s"}, false},
+		{runtime.Frame{Function: "runtime/debug.Stack", File: "/go/src/runtime/debug/stack.go"}, false},
+		{runtime.Frame{Function: "testing.tRunner", File: "/go/src/testing/testing.go"}, false},
+		{runtime.Frame{Function: "github.com/a/b.F", File: "/src/app/vendor/github.com/a/b/b.go"}, false},
+		{runtime.Frame{Function: "github.com/a/runtimex.F", File: "/src/runtimex/f.go"}, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsUserFrame(tt.frame), "Unexpected result for %+v.", tt.frame)
+	}
+}
+
 func TestLoggerReplaceCore(t *testing.T) {
 	replace := WrapCore(func(ladcore.Core) ladcore.Core {
 		return ladcore.NewNopCore()
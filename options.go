@@ -22,6 +22,8 @@ package lad
 
 import (
 	"fmt"
+	"runtime"
+	"strings"
 
 	"github.com/tnngo/lad/ladcore"
 )
@@ -119,6 +121,60 @@ func AddStacktrace(lvl ladcore.LevelEnabler) Option {
 	})
 }
 
+// StacktraceFilter configures the Logger to leave out of recorded stack
+// traces the frames for which keep returns false. IsUserFrame is a ready-made
+// filter that drops frames from the runtime and testing packages and from
+// vendored code.
+func StacktraceFilter(keep func(runtime.Frame) bool) Option {
+	return optionFunc(func(log *Logger) {
+		log.stackFilter = keep
+	})
+}
+
+// StacktraceMaxFrames configures the Logger to record at most n frames in
+// stack traces, counted after StacktraceFilter is applied. A value of zero
+// or less records every frame.
+func StacktraceMaxFrames(n int) Option {
+	return optionFunc(func(log *Logger) {
+		log.stackMaxFrames = n
+	})
+}
+
+// StacktraceGoroutine configures the Logger to start recorded stack traces
+// with a "goroutine N [running]:" line identifying the logging goroutine, as
+// runtime.Stack does, or not, depending on the value of enabled.
+func StacktraceGoroutine(enabled bool) Option {
+	return optionFunc(func(log *Logger) {
+		log.stackGoroutine = enabled
+	})
+}
+
+// IsUserFrame reports whether frame belongs to user code. Frames from the
+// runtime and testing packages and from vendored code are not. It's meant to
+// be used with StacktraceFilter.
+func IsUserFrame(frame runtime.Frame) bool {
+	fn := frame.Function
+	switch {
+	case strings.HasPrefix(fn, "runtime."), strings.HasPrefix(fn, "runtime/"),
+		strings.HasPrefix(fn, "testing."):
+		return false
+	case strings.Contains(frame.File, "/vendor/"):
+		return false
+	}
+	return true
+}
+
+// WithGoroutineDump configures the Logger to add the stack traces of all
+// goroutines, in the format used by runtime.Stack, to FatalLevel entries
+// under the "goroutines" key, or not, depending on the value of enabled.
+// The dump is written before the Logger's fatal hook (see WithFatalHook)
+// runs, so it survives the process exiting.
+func WithGoroutineDump(enabled bool) Option {
+	return optionFunc(func(log *Logger) {
+		log.fatalDump = enabled
+	})
+}
+
 // IncreaseLevel increase the level of the logger. It has no effect if
 // the passed in level tries to decrease the level of the logger.
 func IncreaseLevel(lvl ladcore.LevelEnabler) Option {
@@ -0,0 +1,142 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lad
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+// A RecoverOption configures Recover and Go.
+type RecoverOption interface {
+	apply(*recoverer)
+}
+
+// recoverOptionFunc wraps a func so it satisfies the RecoverOption interface.
+type recoverOptionFunc func(*recoverer)
+
+func (f recoverOptionFunc) apply(r *recoverer) {
+	f(r)
+}
+
+// RecoverLevel sets the level at which recovered panics are logged.
+// Defaults to Error.
+func RecoverLevel(lvl ladcore.Level) RecoverOption {
+	return recoverOptionFunc(func(r *recoverer) {
+		r.level = lvl
+	})
+}
+
+// RecoverMessage sets the message logged for recovered panics. Defaults to
+// "panic recovered".
+func RecoverMessage(msg string) RecoverOption {
+	return recoverOptionFunc(func(r *recoverer) {
+		r.msg = msg
+	})
+}
+
+// Repanic controls whether recovered panics are re-raised with their
+// original value once logged. Disabled by default.
+func Repanic(enabled bool) RecoverOption {
+	return recoverOptionFunc(func(r *recoverer) {
+		r.repanic = enabled
+	})
+}
+
+type recoverer struct {
+	level   ladcore.Level
+	msg     string
+	repanic bool
+}
+
+// Recover recovers from a panic in the calling goroutine and logs it to l.
+// The entry holds the panic value under "panic" and the panicking
+// goroutine's stack trace under "stack", and if l records callers, its
+// caller is the function that panicked. Recover must be deferred directly:
+//
+//	defer lad.Recover(logger)
+//
+// Panics are logged at ErrorLevel and swallowed unless RecoverLevel and
+// Repanic say otherwise. Logging at PanicLevel or FatalLevel panics or exits
+// like any other entry at those levels.
+func Recover(l *Logger, opts ...RecoverOption) {
+	// recover only stops a panic when called directly by a deferred
+	// function, so it can't move into a helper.
+	if v := recover(); v != nil {
+		logPanic(l, v, opts)
+	}
+}
+
+// Go runs fn in a new goroutine, recovering and logging any panic as
+// Recover does.
+func Go(l *Logger, fn func(), opts ...RecoverOption) {
+	go func() {
+		defer Recover(l, opts...)
+		fn()
+	}()
+}
+
+func logPanic(l *Logger, v interface{}, opts []RecoverOption) {
+	r := recoverer{
+		level: ErrorLevel,
+		msg:   "panic recovered",
+	}
+	for _, opt := range opts {
+		opt.apply(&r)
+	}
+
+	if ce := l.Check(r.level, r.msg); ce != nil {
+		if ce.Caller.Defined {
+			ce.Caller = panicCaller()
+		}
+		// Skip logPanic and Recover; the trace starts in the runtime's
+		// panic handling, followed by the function that panicked.
+		ce.Write(Any("panic", v), StackSkip("stack", 2))
+	}
+
+	if r.repanic {
+		panic(v)
+	}
+}
+
+// panicCaller returns the first frame outside the runtime above Recover,
+// which is the function that panicked.
+func panicCaller() ladcore.EntryCaller {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(4 /* runtime.Callers, panicCaller, logPanic, Recover */, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			return ladcore.EntryCaller{
+				Defined:  frame.PC != 0,
+				PC:       frame.PC,
+				File:     frame.File,
+				Line:     frame.Line,
+				Function: frame.Function,
+			}
+		}
+		if !more {
+			return ladcore.EntryCaller{}
+		}
+	}
+}
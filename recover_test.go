@@ -0,0 +1,134 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lad
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad/internal/exit"
+	"github.com/tnngo/lad/ladtest/observer"
+)
+
+//go:noinline
+func panicking(v interface{}) {
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+	withLogger(t, DebugLevel, opts(AddCaller()), func(logger *Logger, logs *observer.ObservedLogs) {
+		func() {
+			defer Recover(logger)
+			panicking("oh no")
+		}()
+
+		entries := logs.All()
+		require.Len(t, entries, 1, "Expected a single entry.")
+		entry := entries[0]
+		assert.Equal(t, ErrorLevel, entry.Level, "Unexpected level.")
+		assert.Equal(t, "panic recovered", entry.Message, "Unexpected message.")
+		assert.Contains(t, entry.Caller.Function, ".panicking", "Expected the panicking function as caller.")
+
+		fields := entry.ContextMap()
+		assert.Equal(t, "oh no", fields["panic"], "Unexpected panic value.")
+		stack, _ := fields["stack"].(string)
+		assert.Contains(t, stack, ".panicking", "Expected the panicking function in the stack.")
+		assert.Contains(t, stack, ".TestRecover", "Expected the test in the stack.")
+		assert.NotContains(t, stack, "lad.logPanic", "Expected lad's frames to be skipped.")
+	})
+}
+
+func TestRecoverOptions(t *testing.T) {
+	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		err := errors.New("boom")
+		assert.PanicsWithValue(t, err, func() {
+			defer Recover(logger, RecoverLevel(WarnLevel), RecoverMessage("worker crashed"), Repanic(true))
+			panicking(err)
+		}, "Expected the original panic to be re-raised.")
+
+		entries := logs.All()
+		require.Len(t, entries, 1, "Expected a single entry.")
+		assert.Equal(t, WarnLevel, entries[0].Level, "Unexpected level.")
+		assert.Equal(t, "worker crashed", entries[0].Message, "Unexpected message.")
+		assert.Equal(t, "boom", entries[0].ContextMap()["panic"], "Expected errors to be logged as errors.")
+	})
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		func() {
+			defer Recover(logger)
+		}()
+		assert.Zero(t, logs.Len(), "Expected no entries without a panic.")
+	})
+}
+
+func TestGo(t *testing.T) {
+	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		Go(logger, func() { panicking(42) }, RecoverMessage("goroutine panicked"))
+
+		require.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, time.Millisecond,
+			"Expected the goroutine's panic to be logged.")
+		entry := logs.All()[0]
+		assert.Equal(t, "goroutine panicked", entry.Message, "Unexpected message.")
+		assert.Equal(t, int64(42), entry.ContextMap()["panic"], "Unexpected panic value.")
+	})
+}
+
+func TestLoggerGoroutineDump(t *testing.T) {
+	withLogger(t, DebugLevel, opts(WithGoroutineDump(true)), func(logger *Logger, logs *observer.ObservedLogs) {
+		logger.Error("not fatal")
+		stub := exit.WithStub(func() {
+			logger.Fatal("fatal")
+		})
+		assert.True(t, stub.Exited, "Expected Fatal to exit.")
+
+		entries := logs.All()
+		require.Len(t, entries, 2, "Expected two entries.")
+		assert.Empty(t, entries[0].Context, "Expected no dump for non-fatal entries.")
+
+		dump, _ := entries[1].ContextMap()["goroutines"].(string)
+		assert.Contains(t, dump, "goroutine ", "Expected a goroutine dump.")
+		assert.Contains(t, dump, ".TestLoggerGoroutineDump", "Expected the logging goroutine in the dump.")
+	})
+
+	withLogger(t, DebugLevel, opts(WithGoroutineDump(true), AddCaller()), func(logger *Logger, logs *observer.ObservedLogs) {
+		exit.WithStub(func() {
+			if ce := logger.Check(FatalLevel, "checked"); ce != nil {
+				ce.Write(String("k", "v"))
+			}
+		})
+
+		entries := logs.All()
+		require.Len(t, entries, 1, "Expected one entry.")
+		assert.True(t, entries[0].Caller.Defined, "Expected the caller to be kept.")
+		assert.Equal(t, "v", entries[0].ContextMap()["k"], "Expected the fields passed to Write.")
+		assert.Contains(t, entries[0].ContextMap()["goroutines"], "goroutine ", "Expected a goroutine dump.")
+	})
+
+	withLogger(t, DebugLevel, nil, func(logger *Logger, logs *observer.ObservedLogs) {
+		exit.WithStub(func() { logger.Fatal("fatal") })
+		assert.Empty(t, logs.All()[0].Context, "Expected no dump by default.")
+	})
+}
@@ -55,6 +55,10 @@ type nopCloserSink struct{ ladcore.WriteSyncer }
 
 func (nopCloserSink) Close() error { return nil }
 
+// Unwrap exposes the wrapped file so encoders can tell whether it's a
+// terminal.
+func (s nopCloserSink) Unwrap() io.Writer { return s.WriteSyncer }
+
 type sinkRegistry struct {
 	mu        sync.Mutex
 	factories map[string]func(*url.URL) (Sink, error)          // keyed by scheme
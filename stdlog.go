@@ -0,0 +1,396 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lad
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tnngo/lad/internal"
+	"github.com/tnngo/lad/ladcore"
+)
+
+// _stdLogTimeFlags are the standard library flags that add timestamps. Lad
+// records its own timestamps, so redirects drop them.
+const _stdLogTimeFlags = log.Ldate | log.Ltime | log.Lmicroseconds | log.LUTC
+
+// A StdLogOption configures the bridges built by NewStdLogBridge and
+// RedirectStdLogBridge.
+type StdLogOption interface {
+	apply(*stdLogBridge)
+}
+
+// stdLogOptionFunc wraps a func so it satisfies the StdLogOption interface.
+type stdLogOptionFunc func(*stdLogBridge)
+
+func (f stdLogOptionFunc) apply(b *stdLogBridge) {
+	f(b)
+}
+
+// StdLogLevel sets the level for messages that don't carry a level marker.
+// Defaults to Info.
+func StdLogLevel(lvl ladcore.Level) StdLogOption {
+	return stdLogOptionFunc(func(b *stdLogBridge) {
+		b.level = lvl
+	})
+}
+
+// StdLogParseLevels controls whether messages that start with a level marker
+// such as "[WARN]", "ERROR:" or "level=debug" are logged at that level.
+// Enabled by default.
+func StdLogParseLevels(enabled bool) StdLogOption {
+	return stdLogOptionFunc(func(b *stdLogBridge) {
+		b.parseLevels = enabled
+	})
+}
+
+// StdLogFlags sets the flags and prefix of the *log.Logger built by
+// NewStdLogBridge. The bridge strips the annotations they produce from each
+// message. It has no effect on RedirectStdLogBridge, which keeps the flags
+// and prefix of the standard library's package-global logger.
+func StdLogFlags(flags int, prefix string) StdLogOption {
+	return stdLogOptionFunc(func(b *stdLogBridge) {
+		b.flags = flags
+		b.prefix = prefix
+	})
+}
+
+// NewStdLogBridge returns a *log.Logger which writes to the supplied Logger.
+//
+// Unlike NewStdLog, the bridge attributes entries to the code that called
+// the *log.Logger no matter how many layers of the log package sit between
+// them, and it turns the standard library's annotations into structured
+// data:
+//
+//   - a prefix set with StdLogFlags is recorded as the "prefix" field,
+//     whether or not log.Lmsgprefix is set;
+//   - file and line annotations from log.Lshortfile or log.Llongfile become
+//     the entry's caller if the Logger records callers, or the "source" field
+//     otherwise; and
+//   - timestamps from log.Ldate and log.Ltime are dropped.
+//
+// Messages that start with a level marker, such as "[WARN] disk full",
+// "ERROR: oops", or "level=debug msg", are logged at that level with the
+// marker removed. Markers for the Fatal and Panic levels are logged at
+// ErrorLevel so that the standard library's output can't end the process.
+//
+// Changing the flags or prefix of the returned *log.Logger isn't supported;
+// use StdLogFlags instead.
+func NewStdLogBridge(l *Logger, opts ...StdLogOption) (*log.Logger, error) {
+	b, err := newStdLogBridge(l, opts)
+	if err != nil {
+		return nil, err
+	}
+	return log.New(b, b.prefix, b.flags), nil
+}
+
+// RedirectStdLogBridge redirects output from the standard library's
+// package-global logger to the supplied Logger through a bridge like the one
+// built by NewStdLogBridge. It keeps the current prefix and file and line
+// flags of the package-global logger, and drops its timestamp flags.
+//
+// It returns a function to undo the redirect. Redirects may be stacked and
+// undone in any order: undoing the innermost redirect restores the output,
+// flags and prefix of the redirect beneath it, or those from before the
+// first redirect if none remain. Calling the function more than once has no
+// effect.
+func RedirectStdLogBridge(l *Logger, opts ...StdLogOption) (func(), error) {
+	b, err := newStdLogBridge(l, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	_stdLogRedirects.mu.Lock()
+	defer _stdLogRedirects.mu.Unlock()
+	b.flags = log.Flags() &^ _stdLogTimeFlags
+	b.prefix = log.Prefix()
+	return _stdLogRedirects.pushLocked(stdLogState{
+		out:    b,
+		flags:  b.flags,
+		prefix: b.prefix,
+	}), nil
+}
+
+func newStdLogBridge(l *Logger, opts []StdLogOption) (*stdLogBridge, error) {
+	b := &stdLogBridge{
+		logger:      l,
+		level:       InfoLevel,
+		parseLevels: true,
+	}
+	for _, opt := range opts {
+		opt.apply(b)
+	}
+	if b.level < DebugLevel || b.level > FatalLevel {
+		return nil, fmt.Errorf("unrecognized level: %q", b.level)
+	}
+	return b, nil
+}
+
+// stdLogBridge is the io.Writer behind the standard library loggers built by
+// NewStdLogBridge and RedirectStdLogBridge. Each call to Write receives one
+// message.
+type stdLogBridge struct {
+	logger      *Logger
+	level       ladcore.Level
+	parseLevels bool
+
+	// The flags and prefix of the *log.Logger writing to the bridge. The log
+	// package holds a lock while writing, so they can't be queried from Write.
+	flags  int
+	prefix string
+}
+
+func (b *stdLogBridge) Write(p []byte) (int, error) {
+	n := len(p)
+	msg := string(bytes.TrimRight(p, "\n"))
+
+	var (
+		fields []Field
+		file   string
+		line   int
+	)
+	// The log package writes "prefix date time file:line: msg", or
+	// "date time file:line: prefix msg" with Lmsgprefix.
+	var prefix string
+	if b.flags&log.Lmsgprefix == 0 {
+		msg, prefix = b.stripPrefix(msg)
+	}
+	msg = stripStdLogTime(msg, b.flags)
+	if b.flags&(log.Lshortfile|log.Llongfile) != 0 {
+		msg, file, line = stripStdLogSource(msg)
+	}
+	if b.flags&log.Lmsgprefix != 0 {
+		msg, prefix = b.stripPrefix(msg)
+	}
+	if prefix = strings.TrimSpace(prefix); prefix != "" {
+		fields = append(fields, String("prefix", prefix))
+	}
+
+	lvl := b.level
+	if b.parseLevels {
+		if parsed, rest, ok := parseStdLogLevel(msg); ok {
+			lvl, msg = parsed, rest
+		}
+	}
+
+	ce := b.logger.Check(lvl, msg)
+	if ce == nil {
+		return n, nil
+	}
+	if ce.Caller.Defined {
+		if file != "" {
+			ce.Caller = ladcore.NewEntryCaller(0, file, line, true)
+		} else {
+			ce.Caller = stdLogCaller()
+		}
+	} else if file != "" {
+		fields = append(fields, String("source", file+":"+strconv.Itoa(line)))
+	}
+	ce.Write(fields...)
+	return n, nil
+}
+
+// stripPrefix removes the bridge's prefix from the start of msg, returning
+// the trimmed message and the removed prefix.
+func (b *stdLogBridge) stripPrefix(msg string) (string, string) {
+	if b.prefix == "" || !strings.HasPrefix(msg, b.prefix) {
+		return msg, ""
+	}
+	return msg[len(b.prefix):], b.prefix
+}
+
+// stdLogCaller reports the first caller outside the log package.
+func stdLogCaller() ladcore.EntryCaller {
+	var pcs [16]uintptr
+	// Skip runtime.Callers, stdLogCaller and stdLogBridge.Write.
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "log.") && !strings.HasPrefix(frame.Function, "log/") {
+			caller := ladcore.NewEntryCaller(frame.PC, frame.File, frame.Line, frame.PC != 0)
+			caller.Function = frame.Function
+			return caller
+		}
+		if !more {
+			return ladcore.EntryCaller{}
+		}
+	}
+}
+
+// stripStdLogTime drops the date and time annotations that the given flags
+// add to a message.
+func stripStdLogTime(msg string, flags int) string {
+	if flags&log.Ldate != 0 {
+		// 2009/01/23
+		if len(msg) < 11 || msg[4] != '/' || msg[7] != '/' || msg[10] != ' ' {
+			return msg
+		}
+		msg = msg[11:]
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		// 01:23:23 or 01:23:23.123123
+		n := 8
+		if flags&log.Lmicroseconds != 0 {
+			n += 7
+		}
+		if len(msg) < n+1 || msg[2] != ':' || msg[5] != ':' || msg[n] != ' ' {
+			return msg
+		}
+		msg = msg[n+1:]
+	}
+	return msg
+}
+
+// stripStdLogSource removes the "file:line: " annotation from the start of
+// a message.
+func stripStdLogSource(msg string) (rest, file string, line int) {
+	end := strings.Index(msg, ": ")
+	if end < 0 {
+		return msg, "", 0
+	}
+	colon := strings.LastIndexByte(msg[:end], ':')
+	if colon < 0 {
+		return msg, "", 0
+	}
+	line, err := strconv.Atoi(msg[colon+1 : end])
+	if err != nil {
+		return msg, "", 0
+	}
+	return msg[end+2:], msg[:colon], line
+}
+
+// parseStdLogLevel recognizes a leading "[LEVEL]", "LEVEL:" or "level=LEVEL"
+// marker, returning the level and the message without the marker.
+func parseStdLogLevel(msg string) (ladcore.Level, string, bool) {
+	var name, rest string
+	switch {
+	case strings.HasPrefix(msg, "["):
+		end := strings.IndexByte(msg, ']')
+		if end < 0 {
+			return InfoLevel, msg, false
+		}
+		name, rest = msg[1:end], msg[end+1:]
+	case strings.HasPrefix(msg, "level="):
+		name = msg[len("level="):]
+		if end := strings.IndexByte(name, ' '); end >= 0 {
+			name, rest = name[:end], name[end:]
+		}
+		name = strings.Trim(name, `"`)
+	default:
+		end := strings.IndexByte(msg, ':')
+		if end < 0 {
+			return InfoLevel, msg, false
+		}
+		name, rest = msg[:end], msg[end+1:]
+	}
+
+	lvl, ok := internal.LevelFromName(name)
+	if !ok {
+		return InfoLevel, msg, false
+	}
+	return lvl, strings.TrimLeft(rest, " "), true
+}
+
+// _stdLogRedirects tracks redirects of the standard library's package-global
+// logger so that they can be undone in any order.
+var _stdLogRedirects stdLogRedirects
+
+// stdLogState is the configuration of the standard library's package-global
+// logger.
+type stdLogState struct {
+	out    io.Writer
+	flags  int
+	prefix string
+}
+
+func currentStdLogState() stdLogState {
+	return stdLogState{
+		out:    log.Writer(),
+		flags:  log.Flags(),
+		prefix: log.Prefix(),
+	}
+}
+
+func (s *stdLogState) apply() {
+	log.SetOutput(s.out)
+	log.SetFlags(s.flags)
+	log.SetPrefix(s.prefix)
+}
+
+type stdLogRedirects struct {
+	mu sync.Mutex
+
+	// base is the state from before the first active redirect.
+	base stdLogState
+
+	// stack holds the active redirects, innermost last.
+	stack []*stdLogState
+}
+
+// pushLocked applies the given state and returns a function that removes it
+// again. The caller must hold mu.
+func (r *stdLogRedirects) pushLocked(s stdLogState) func() {
+	// Remember changes made while the current redirect was active so that
+	// they survive this one.
+	if n := len(r.stack); n > 0 {
+		*r.stack[n-1] = currentStdLogState()
+	} else {
+		r.base = currentStdLogState()
+	}
+
+	state := &s
+	r.stack = append(r.stack, state)
+	state.apply()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.remove(state)
+	}
+}
+
+// remove drops the given redirect, restoring the state beneath it if it was
+// the innermost one.
+func (r *stdLogRedirects) remove(state *stdLogState) {
+	for i, s := range r.stack {
+		if s != state {
+			continue
+		}
+
+		top := i == len(r.stack)-1
+		r.stack = append(r.stack[:i], r.stack[i+1:]...)
+		if !top {
+			return
+		}
+		if n := len(r.stack); n > 0 {
+			r.stack[n-1].apply()
+		} else {
+			r.base.apply()
+		}
+		return
+	}
+}
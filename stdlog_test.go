@@ -0,0 +1,283 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lad
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad/ladcore"
+	"github.com/tnngo/lad/ladtest/observer"
+)
+
+func TestNewStdLogBridge(t *testing.T) {
+	tests := []struct {
+		desc       string
+		opts       []StdLogOption
+		msg        string
+		wantLevel  ladcore.Level
+		wantMsg    string
+		wantFields []Field
+	}{
+		{
+			desc:      "plain",
+			msg:       "hello",
+			wantLevel: InfoLevel,
+			wantMsg:   "hello",
+		},
+		{
+			desc:      "default level",
+			opts:      []StdLogOption{StdLogLevel(WarnLevel)},
+			msg:       "hello",
+			wantLevel: WarnLevel,
+			wantMsg:   "hello",
+		},
+		{
+			desc:      "bracketed level",
+			msg:       "[WARN] disk full",
+			wantLevel: WarnLevel,
+			wantMsg:   "disk full",
+		},
+		{
+			desc:      "colon level",
+			msg:       "error: oops",
+			wantLevel: ErrorLevel,
+			wantMsg:   "oops",
+		},
+		{
+			desc:      "logfmt level",
+			msg:       `level="debug" msg=hi`,
+			wantLevel: DebugLevel,
+			wantMsg:   "msg=hi",
+		},
+		{
+			desc:      "fatal is downgraded",
+			msg:       "[FATAL] bye",
+			wantLevel: ErrorLevel,
+			wantMsg:   "bye",
+		},
+		{
+			desc:      "unknown marker",
+			msg:       "[http] GET /",
+			wantLevel: InfoLevel,
+			wantMsg:   "[http] GET /",
+		},
+		{
+			desc:      "level parsing disabled",
+			opts:      []StdLogOption{StdLogParseLevels(false)},
+			msg:       "[WARN] disk full",
+			wantLevel: InfoLevel,
+			wantMsg:   "[WARN] disk full",
+		},
+		{
+			desc:       "prefix and timestamps",
+			opts:       []StdLogOption{StdLogFlags(log.LstdFlags|log.Lmicroseconds, "[svc] ")},
+			msg:        "[ERROR] boom",
+			wantLevel:  ErrorLevel,
+			wantMsg:    "boom",
+			wantFields: []Field{String("prefix", "[svc]")},
+		},
+		{
+			desc:       "message prefix",
+			opts:       []StdLogOption{StdLogFlags(log.Ltime|log.Lmsgprefix, "svc: ")},
+			msg:        "hello",
+			wantLevel:  InfoLevel,
+			wantMsg:    "hello",
+			wantFields: []Field{String("prefix", "svc:")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			withLogger(t, DebugLevel, nil, func(l *Logger, logs *observer.ObservedLogs) {
+				std, err := NewStdLogBridge(l, tt.opts...)
+				require.NoError(t, err, "Unexpected error.")
+				std.Print(tt.msg)
+
+				want := tt.wantFields
+				if want == nil {
+					want = []Field{}
+				}
+				assert.Equal(t, []observer.LoggedEntry{{
+					Entry:   ladcore.Entry{Level: tt.wantLevel, Message: tt.wantMsg},
+					Context: want,
+				}}, logs.AllUntimed(), "Unexpected log output.")
+			})
+		})
+	}
+}
+
+func TestNewStdLogBridgeInvalidLevel(t *testing.T) {
+	_, err := NewStdLogBridge(NewNop(), StdLogLevel(ladcore.Level(99)))
+	assert.ErrorContains(t, err, "99", "Expected level code in error message.")
+
+	_, err = RedirectStdLogBridge(NewNop(), StdLogLevel(ladcore.Level(99)))
+	assert.ErrorContains(t, err, "99", "Expected level code in error message.")
+}
+
+func TestNewStdLogBridgeCaller(t *testing.T) {
+	withLogger(t, DebugLevel, []Option{AddCaller()}, func(l *Logger, logs *observer.ObservedLogs) {
+		std, err := NewStdLogBridge(l)
+		require.NoError(t, err, "Unexpected error.")
+
+		std.Print("print")
+		std.Printf("printf")
+		std.Println("println")
+		require.NoError(t, std.Output(1, "output"), "Unexpected error.")
+
+		entries := logs.All()
+		require.Len(t, entries, 4, "Unexpected number of logs.")
+		for _, ent := range entries {
+			assert.Regexp(t, `/stdlog_test.go:\d+$`, ent.Caller.String(), "Unexpected caller for %q.", ent.Message)
+			assert.Contains(t, ent.Caller.Function, "TestNewStdLogBridgeCaller", "Unexpected function for %q.", ent.Message)
+		}
+	})
+}
+
+func TestNewStdLogBridgeSource(t *testing.T) {
+	// Output(2, ...) attributes the message to this helper's caller, which
+	// only the file and line annotation knows about.
+	logVia := func(std *log.Logger) {
+		require.NoError(t, std.Output(2, "hello"), "Unexpected error.")
+	}
+
+	withLogger(t, DebugLevel, []Option{AddCaller()}, func(l *Logger, logs *observer.ObservedLogs) {
+		std, err := NewStdLogBridge(l, StdLogFlags(log.Lshortfile, ""))
+		require.NoError(t, err, "Unexpected error.")
+		logVia(std)
+
+		entries := logs.All()
+		require.Len(t, entries, 1, "Unexpected number of logs.")
+		assert.Equal(t, "hello", entries[0].Message, "Expected the annotation to be stripped.")
+		assert.Equal(t, "stdlog_test.go", entries[0].Caller.File, "Unexpected caller file.")
+		assert.Empty(t, entries[0].Context, "Expected no fields when callers are recorded.")
+	})
+
+	withLogger(t, DebugLevel, nil, func(l *Logger, logs *observer.ObservedLogs) {
+		std, err := NewStdLogBridge(l, StdLogFlags(log.Lshortfile, ""))
+		require.NoError(t, err, "Unexpected error.")
+		std.Print("hello")
+
+		entries := logs.All()
+		require.Len(t, entries, 1, "Unexpected number of logs.")
+		assert.Regexp(t, `^stdlog_test.go:\d+$`, entries[0].ContextMap()["source"], "Expected a source field.")
+		assert.False(t, entries[0].Caller.Defined, "Expected no caller.")
+	})
+}
+
+func TestRedirectStdLogBridge(t *testing.T) {
+	initialFlags := log.Flags()
+	initialPrefix := log.Prefix()
+	initialOutput := log.Writer()
+
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("app: ")
+	defer func() {
+		log.SetFlags(initialFlags)
+		log.SetPrefix(initialPrefix)
+	}()
+
+	withLogger(t, DebugLevel, []Option{AddCaller()}, func(l *Logger, logs *observer.ObservedLogs) {
+		restore, err := RedirectStdLogBridge(l)
+		require.NoError(t, err, "Unexpected error.")
+		defer restore()
+
+		assert.Equal(t, log.Lshortfile, log.Flags(), "Expected timestamp flags to be dropped.")
+		log.Print("[WARN] redirected")
+
+		entries := logs.All()
+		require.Len(t, entries, 1, "Unexpected number of logs.")
+		assert.Equal(t, WarnLevel, entries[0].Level, "Unexpected level.")
+		assert.Equal(t, "redirected", entries[0].Message, "Unexpected message.")
+		assert.Equal(t, "stdlog_test.go", entries[0].Caller.File, "Unexpected caller.")
+		assert.Equal(t, []Field{String("prefix", "app:")}, entries[0].Context, "Unexpected fields.")
+	})
+
+	assert.Equal(t, log.LstdFlags|log.Lshortfile, log.Flags(), "Expected to restore flags.")
+	assert.Equal(t, "app: ", log.Prefix(), "Expected to restore prefix.")
+	assert.Equal(t, initialOutput, log.Writer(), "Expected to restore output.")
+}
+
+func TestRedirectStdLogStacked(t *testing.T) {
+	initialFlags := log.Flags()
+	initialPrefix := log.Prefix()
+	initialOutput := log.Writer()
+
+	var out bytes.Buffer
+	outer, outerLogs := observer.New(DebugLevel)
+	inner, innerLogs := observer.New(DebugLevel)
+
+	restoreOuter := RedirectStdLog(New(outer))
+	restoreInner, err := RedirectStdLogBridge(New(inner))
+	require.NoError(t, err, "Unexpected error.")
+
+	log.Print("inner")
+	restoreOuter() // out of order: the inner redirect stays in effect
+	log.Print("inner again")
+	restoreInner()
+	restoreInner() // no-op
+
+	log.SetOutput(&out)
+	log.Print("direct")
+	log.SetOutput(initialOutput)
+
+	assert.Equal(t, 0, outerLogs.Len(), "Expected no logs on the outer logger.")
+	assert.Equal(t, 2, innerLogs.Len(), "Expected logs on the inner logger.")
+	assert.Contains(t, out.String(), "direct", "Expected the standard library to log directly.")
+	assert.Equal(t, initialFlags, log.Flags(), "Expected to restore flags.")
+	assert.Equal(t, initialPrefix, log.Prefix(), "Expected to restore prefix.")
+	assert.Equal(t, initialOutput, log.Writer(), "Expected to restore output.")
+
+	// Undo in order, with a change made while the outer redirect is active.
+	restoreOuter = RedirectStdLog(New(outer))
+	log.SetPrefix("changed: ")
+	restoreInner, err = RedirectStdLogBridge(New(inner))
+	require.NoError(t, err, "Unexpected error.")
+	assert.Equal(t, "changed: ", log.Prefix(), "Expected the bridge to keep the prefix.")
+	restoreInner()
+	assert.Equal(t, "changed: ", log.Prefix(), "Expected to restore the outer redirect's prefix.")
+	log.Print("outer")
+	restoreOuter()
+
+	assert.Equal(t, 1, outerLogs.Len(), "Expected a log on the outer logger.")
+	assert.Equal(t, initialPrefix, log.Prefix(), "Expected to restore prefix.")
+	assert.Equal(t, initialOutput, log.Writer(), "Expected to restore output.")
+}
+
+func TestStripStdLogHeader(t *testing.T) {
+	assert.Equal(t, "msg", stripStdLogTime("2009/01/23 01:23:23.123123 msg", log.Ldate|log.Lmicroseconds), "Unexpected message.")
+	assert.Equal(t, "not a date", stripStdLogTime("not a date", log.Ldate), "Expected malformed dates to be kept.")
+	assert.Equal(t, "short", stripStdLogTime("short", log.Ltime), "Expected malformed times to be kept.")
+
+	msg, file, line := stripStdLogSource(`C:\src\main.go:12: hi: there`)
+	assert.Equal(t, "hi: there", msg, "Unexpected message.")
+	assert.Equal(t, `C:\src\main.go`, file, "Unexpected file.")
+	assert.Equal(t, 12, line, "Unexpected line.")
+
+	for _, s := range []string{"no source", "file.go: x", "file.go:x: y"} {
+		msg, file, _ = stripStdLogSource(s)
+		assert.Equal(t, s, msg, "Expected %q to be kept.", s)
+		assert.Empty(t, file, "Expected no file for %q.", s)
+	}
+}
@@ -0,0 +1,628 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lad
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tnngo/lad/ladcore"
+)
+
+// RedactedValue is logged in place of struct fields tagged with the "redact"
+// option.
+const RedactedValue = "[REDACTED]"
+
+// _tagName is the struct tag consulted by Tagged and TaggedObject.
+const _tagName = "lad"
+
+var (
+	_timeType            = reflect.TypeOf(time.Time{})
+	_durationType        = reflect.TypeOf(time.Duration(0))
+	_objectMarshalerType = reflect.TypeOf((*ladcore.ObjectMarshaler)(nil)).Elem()
+	_arrayMarshalerType  = reflect.TypeOf((*ladcore.ArrayMarshaler)(nil)).Elem()
+	_errorType           = reflect.TypeOf((*error)(nil)).Elem()
+	_stringerType        = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+	// _structCodecs caches a *structCodec for every struct type seen by
+	// Tagged, so the reflection cost is paid once per type.
+	_structCodecs sync.Map // map[reflect.Type]*structCodec
+
+	// _taggedTypes caches the result of containsTags.
+	_taggedTypes sync.Map // map[reflect.Type]bool
+)
+
+// Tagged constructs a field that marshals a struct, or a pointer to a struct,
+// through the typed ObjectEncoder methods instead of the ReflectedEncoder.
+//
+// Fields are named and filtered with `lad` struct tags, following the same
+// conventions as encoding/json:
+//
+//	type User struct {
+//		Name     string `lad:"name"`
+//		Email    string `lad:"email,omitempty"`
+//		Password string `lad:"password,redact"`
+//		Internal string `lad:"-"`
+//	}
+//
+// Untagged exported fields are logged under their Go name, and unexported
+// fields are ignored. Nested structs, including those held in slices, arrays,
+// maps and pointers, are encoded the same way if they use `lad` tags
+// somewhere inside, and with the ReflectedEncoder otherwise. The "omitempty"
+// option drops the field when it holds its zero value, and the "redact"
+// option logs RedactedValue in place of the real value. Anonymous struct
+// fields without an explicit name are inlined into the enclosing object.
+// Map keys are formatted with fmt and sorted.
+//
+// Slices, arrays and maps of tagged structs are encoded as arrays and
+// objects. Other values are passed on to Reflect.
+func Tagged(key string, val interface{}) Field {
+	if m, ok := taggedObject(val); ok {
+		return Object(key, m)
+	}
+
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.IsValid() && containsTags(v.Type()) {
+		switch v.Kind() {
+		case reflect.Slice:
+			if !v.IsNil() {
+				return Array(key, taggedArray{v: v})
+			}
+		case reflect.Array:
+			return Array(key, taggedArray{v: v})
+		case reflect.Map:
+			if !v.IsNil() {
+				return Object(key, taggedMap{v: v})
+			}
+		}
+	}
+	return Reflect(key, val)
+}
+
+// TaggedObject returns a ladcore.ObjectMarshaler that encodes a struct using
+// its `lad` struct tags. See Tagged for the supported tag options. It returns
+// nil if val isn't a struct or a non-nil pointer to a struct.
+func TaggedObject(val interface{}) ladcore.ObjectMarshaler {
+	m, ok := taggedObject(val)
+	if !ok {
+		return nil
+	}
+	return m
+}
+
+func taggedObject(val interface{}) (taggedStruct, bool) {
+	v := reflect.ValueOf(val)
+	var path taggedPath
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return taggedStruct{}, false
+		}
+		path = append(path, v.Pointer())
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return taggedStruct{}, false
+	}
+	if !v.CanAddr() {
+		// Copy the struct so that methods declared on its pointer receiver
+		// are visible to its fields.
+		pv := reflect.New(v.Type()).Elem()
+		pv.Set(v)
+		v = pv
+	}
+	return taggedStruct{codec: codecFor(v.Type()), v: v, path: path}, true
+}
+
+// hasLadTags reports whether val's type uses `lad` struct tags anywhere,
+// looking through pointers, slices, arrays, maps and struct fields. lad.Any
+// uses this to decide whether a value should bypass the ReflectedEncoder,
+// which would ignore the "redact" option.
+func hasLadTags(val interface{}) bool {
+	t := reflect.TypeOf(val)
+	return t != nil && containsTags(t)
+}
+
+// containsTags reports whether values of type t hold a struct with `lad`
+// tags. Interface-typed fields can't be seen through, since their dynamic
+// type is only known when encoding.
+func containsTags(t reflect.Type) bool {
+	if b, ok := _taggedTypes.Load(t); ok {
+		return b.(bool)
+	}
+	b := typeContainsTags(t, make(map[reflect.Type]struct{}))
+	_taggedTypes.Store(t, b)
+	return b
+}
+
+func typeContainsTags(t reflect.Type, visiting map[reflect.Type]struct{}) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return typeContainsTags(t.Elem(), visiting)
+	case reflect.Struct:
+		if _, ok := visiting[t]; ok || t == _timeType {
+			// Recursive types are tagged if any of their other fields are.
+			return false
+		}
+		visiting[t] = struct{}{}
+		c := codecFor(t)
+		if c.tagged {
+			return true
+		}
+		for _, f := range c.fields {
+			if typeContainsTags(t.Field(f.index).Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// taggedPath holds the pointers, maps and slices being encoded, from the
+// outermost value in, so that cyclic values are reported instead of
+// recursing forever.
+type taggedPath []uintptr
+
+// enter returns the path extended with v, or an error if v is already on
+// it. v must be a non-nil pointer, map or slice.
+func (p taggedPath) enter(v reflect.Value) (taggedPath, error) {
+	ptr := v.Pointer()
+	for _, seen := range p {
+		if seen == ptr {
+			return nil, fmt.Errorf("encountered a cycle via %s", v.Type())
+		}
+	}
+	// Cap the slice so that siblings don't share their descendants.
+	return append(p[:len(p):len(p)], ptr), nil
+}
+
+type taggedStruct struct {
+	codec *structCodec
+	v     reflect.Value
+	path  taggedPath
+}
+
+func (s taggedStruct) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	return s.codec.encode(enc, s.v, s.path)
+}
+
+// structCodec holds the precomputed encoding plan for a struct type.
+type structCodec struct {
+	fields []structField
+	tagged bool // whether any field carries a `lad` tag
+}
+
+type structField struct {
+	key       string
+	index     int
+	omitEmpty bool
+	redact    bool
+	inline    bool
+}
+
+func codecFor(t reflect.Type) *structCodec {
+	if c, ok := _structCodecs.Load(t); ok {
+		return c.(*structCodec)
+	}
+	c, _ := _structCodecs.LoadOrStore(t, newStructCodec(t))
+	return c.(*structCodec)
+}
+
+func newStructCodec(t reflect.Type) *structCodec {
+	c := &structCodec{fields: make([]structField, 0, t.NumField())}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup(_tagName)
+		if hasTag {
+			c.tagged = true
+		}
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		f := structField{key: name, index: i}
+		for opts != "" {
+			var opt string
+			opt, opts, _ = strings.Cut(opts, ",")
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			case "redact":
+				f.redact = true
+			}
+		}
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				f.inline = true
+				c.fields = append(c.fields, f)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if f.key == "" {
+			f.key = sf.Name
+		}
+		c.fields = append(c.fields, f)
+	}
+	return c
+}
+
+func (c *structCodec) encode(enc ladcore.ObjectEncoder, v reflect.Value, path taggedPath) error {
+	for _, f := range c.fields {
+		fv := v.Field(f.index)
+		if f.inline {
+			fpath := path
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				var err error
+				if fpath, err = path.enter(fv); err != nil {
+					return err
+				}
+				fv = fv.Elem()
+			}
+			if err := codecFor(fv.Type()).encode(enc, fv, fpath); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if f.redact {
+			enc.AddString(f.key, RedactedValue)
+			continue
+		}
+		if err := addValue(enc, f.key, fv, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addValue adds v to enc under key, picking the most specific typed method
+// available and falling back to AddReflected. path holds the values v is
+// nested in.
+func addValue(enc ladcore.ObjectEncoder, key string, v reflect.Value, path taggedPath) error {
+	switch {
+	case v.Type() == _timeType && v.CanInterface():
+		enc.AddTime(key, v.Interface().(time.Time))
+		return nil
+	case v.Type() == _durationType:
+		enc.AddDuration(key, time.Duration(v.Int()))
+		return nil
+	}
+
+	if m, ok := marshalerOf(v); ok {
+		switch m := m.(type) {
+		case ladcore.ObjectMarshaler:
+			return enc.AddObject(key, m)
+		case ladcore.ArrayMarshaler:
+			return enc.AddArray(key, m)
+		case error:
+			enc.AddString(key, m.Error())
+			return nil
+		case fmt.Stringer:
+			enc.AddString(key, m.String())
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		enc.AddBool(key, v.Bool())
+	case reflect.Int:
+		enc.AddInt(key, int(v.Int()))
+	case reflect.Int64:
+		enc.AddInt64(key, v.Int())
+	case reflect.Int32:
+		enc.AddInt32(key, int32(v.Int()))
+	case reflect.Int16:
+		enc.AddInt16(key, int16(v.Int()))
+	case reflect.Int8:
+		enc.AddInt8(key, int8(v.Int()))
+	case reflect.Uint:
+		enc.AddUint(key, uint(v.Uint()))
+	case reflect.Uint64:
+		enc.AddUint64(key, v.Uint())
+	case reflect.Uint32:
+		enc.AddUint32(key, uint32(v.Uint()))
+	case reflect.Uint16:
+		enc.AddUint16(key, uint16(v.Uint()))
+	case reflect.Uint8:
+		enc.AddUint8(key, uint8(v.Uint()))
+	case reflect.Uintptr:
+		enc.AddUintptr(key, uintptr(v.Uint()))
+	case reflect.Float64:
+		enc.AddFloat64(key, v.Float())
+	case reflect.Float32:
+		enc.AddFloat32(key, float32(v.Float()))
+	case reflect.Complex128:
+		enc.AddComplex128(key, v.Complex())
+	case reflect.Complex64:
+		enc.AddComplex64(key, complex64(v.Complex()))
+	case reflect.String:
+		enc.AddString(key, v.String())
+	case reflect.Struct:
+		if containsTags(v.Type()) {
+			return enc.AddObject(key, taggedStruct{codec: codecFor(v.Type()), v: v, path: path})
+		}
+		if !v.CanInterface() {
+			return nil
+		}
+		return enc.AddReflected(key, v.Interface())
+	case reflect.Interface:
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		return addValue(enc, key, v.Elem(), path)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		path, err := path.enter(v)
+		if err != nil {
+			return err
+		}
+		return addValue(enc, key, v.Elem(), path)
+	case reflect.Slice:
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			enc.AddBinary(key, v.Bytes())
+			return nil
+		}
+		path, err := path.enter(v)
+		if err != nil {
+			return err
+		}
+		return enc.AddArray(key, taggedArray{v: v, path: path})
+	case reflect.Array:
+		return enc.AddArray(key, taggedArray{v: v, path: path})
+	case reflect.Map:
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		path, err := path.enter(v)
+		if err != nil {
+			return err
+		}
+		return enc.AddObject(key, taggedMap{v: v, path: path})
+	default:
+		if !v.CanInterface() {
+			return nil
+		}
+		return enc.AddReflected(key, v.Interface())
+	}
+	return nil
+}
+
+type taggedArray struct {
+	v    reflect.Value
+	path taggedPath
+}
+
+func (a taggedArray) MarshalLogArray(enc ladcore.ArrayEncoder) error {
+	for i := 0; i < a.v.Len(); i++ {
+		if err := appendValue(enc, a.v.Index(i), a.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type taggedMap struct {
+	v    reflect.Value
+	path taggedPath
+}
+
+func (m taggedMap) MarshalLogObject(enc ladcore.ObjectEncoder) error {
+	type entry struct {
+		key string
+		val reflect.Value
+	}
+	entries := make([]entry, 0, m.v.Len())
+	iter := m.v.MapRange()
+	for iter.Next() {
+		entries = append(entries, entry{mapKey(iter.Key()), iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	for _, e := range entries {
+		if err := addValue(enc, e.key, e.val, m.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapKey formats a map key the way encoding/json does, falling back to fmt
+// for key types it doesn't support.
+func mapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if k.CanInterface() {
+		if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+			if text, err := tm.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+	}
+	return fmt.Sprint(k)
+}
+
+// appendValue is the ArrayEncoder counterpart of addValue.
+func appendValue(enc ladcore.ArrayEncoder, v reflect.Value, path taggedPath) error {
+	switch {
+	case v.Type() == _timeType && v.CanInterface():
+		enc.AppendTime(v.Interface().(time.Time))
+		return nil
+	case v.Type() == _durationType:
+		enc.AppendDuration(time.Duration(v.Int()))
+		return nil
+	}
+
+	if m, ok := marshalerOf(v); ok {
+		switch m := m.(type) {
+		case ladcore.ObjectMarshaler:
+			return enc.AppendObject(m)
+		case ladcore.ArrayMarshaler:
+			return enc.AppendArray(m)
+		case error:
+			enc.AppendString(m.Error())
+			return nil
+		case fmt.Stringer:
+			enc.AppendString(m.String())
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		enc.AppendBool(v.Bool())
+	case reflect.Int:
+		enc.AppendInt(int(v.Int()))
+	case reflect.Int64:
+		enc.AppendInt64(v.Int())
+	case reflect.Int32:
+		enc.AppendInt32(int32(v.Int()))
+	case reflect.Int16:
+		enc.AppendInt16(int16(v.Int()))
+	case reflect.Int8:
+		enc.AppendInt8(int8(v.Int()))
+	case reflect.Uint:
+		enc.AppendUint(uint(v.Uint()))
+	case reflect.Uint64:
+		enc.AppendUint64(v.Uint())
+	case reflect.Uint32:
+		enc.AppendUint32(uint32(v.Uint()))
+	case reflect.Uint16:
+		enc.AppendUint16(uint16(v.Uint()))
+	case reflect.Uint8:
+		enc.AppendUint8(uint8(v.Uint()))
+	case reflect.Uintptr:
+		enc.AppendUintptr(uintptr(v.Uint()))
+	case reflect.Float64:
+		enc.AppendFloat64(v.Float())
+	case reflect.Float32:
+		enc.AppendFloat32(float32(v.Float()))
+	case reflect.Complex128:
+		enc.AppendComplex128(v.Complex())
+	case reflect.Complex64:
+		enc.AppendComplex64(complex64(v.Complex()))
+	case reflect.String:
+		enc.AppendString(v.String())
+	case reflect.Struct:
+		if containsTags(v.Type()) {
+			return enc.AppendObject(taggedStruct{codec: codecFor(v.Type()), v: v, path: path})
+		}
+		if !v.CanInterface() {
+			return nil
+		}
+		return enc.AppendReflected(v.Interface())
+	case reflect.Interface:
+		if v.IsNil() {
+			return enc.AppendReflected(nil)
+		}
+		return appendValue(enc, v.Elem(), path)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return enc.AppendReflected(nil)
+		}
+		path, err := path.enter(v)
+		if err != nil {
+			return err
+		}
+		return appendValue(enc, v.Elem(), path)
+	case reflect.Slice:
+		if v.IsNil() {
+			return enc.AppendReflected(nil)
+		}
+		path, err := path.enter(v)
+		if err != nil {
+			return err
+		}
+		return enc.AppendArray(taggedArray{v: v, path: path})
+	case reflect.Array:
+		return enc.AppendArray(taggedArray{v: v, path: path})
+	case reflect.Map:
+		if v.IsNil() {
+			return enc.AppendReflected(nil)
+		}
+		path, err := path.enter(v)
+		if err != nil {
+			return err
+		}
+		return enc.AppendObject(taggedMap{v: v, path: path})
+	default:
+		if !v.CanInterface() {
+			return nil
+		}
+		return enc.AppendReflected(v.Interface())
+	}
+	return nil
+}
+
+// marshalerOf returns v as one of the interfaces that lad knows how to encode
+// natively, checking the pointer receiver when v is addressable.
+func marshalerOf(v reflect.Value) (interface{}, bool) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, false
+	}
+	for _, t := range []reflect.Type{_objectMarshalerType, _arrayMarshalerType, _errorType, _stringerType} {
+		if v.Type().Implements(t) && v.CanInterface() {
+			return v.Interface(), true
+		}
+		if v.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(v.Type()).Implements(t) && v.Addr().CanInterface() {
+			return v.Addr().Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// isEmptyValue mirrors the "omitempty" semantics of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lad
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tnngo/lad/ladcore"
+)
+
+type taggedAddress struct {
+	City string `lad:"city"`
+	Zip  string `lad:"zip,omitempty"`
+}
+
+type taggedBase struct {
+	ID int64 `lad:"id"`
+}
+
+type taggedUser struct {
+	taggedBase
+
+	Name     string         `lad:"name"`
+	Password string         `lad:"password,redact"`
+	Token    string         `lad:"token,omitempty,redact"`
+	Nickname string         `lad:"nickname,omitempty"`
+	Internal string         `lad:"-"`
+	Age      int            `lad:"age"`
+	Admin    bool           `lad:"admin"`
+	Score    float64        `lad:"score"`
+	Joined   time.Time      `lad:"joined"`
+	TTL      time.Duration  `lad:"ttl"`
+	Tags     []string       `lad:"tags"`
+	Raw      []byte         `lad:"raw,omitempty"`
+	Address  taggedAddress  `lad:"address"`
+	Previous *taggedAddress `lad:"previous"`
+	Login    username       `lad:"login"`
+	Err      error          `lad:"err,omitempty"`
+	Untagged string
+
+	secret string
+}
+
+type untaggedStruct struct {
+	Name string
+}
+
+func TestTagged(t *testing.T) {
+	joined := time.Unix(0, 1000).UTC()
+	user := taggedUser{
+		taggedBase: taggedBase{ID: 42},
+		Name:       "jane",
+		Password:   "hunter2",
+		Internal:   "ignored",
+		Age:        30,
+		Admin:      true,
+		Score:      1.5,
+		Joined:     joined,
+		TTL:        time.Second,
+		Tags:       []string{"a", "b"},
+		Address:    taggedAddress{City: "Hanoi"},
+		Login:      username("jdoe"),
+		Err:        errors.New("boom"),
+		Untagged:   "plain",
+		secret:     "shh",
+	}
+	want := map[string]interface{}{
+		"id":       int64(42),
+		"name":     "jane",
+		"password": RedactedValue,
+		"age":      30,
+		"admin":    true,
+		"score":    1.5,
+		"joined":   joined,
+		"ttl":      time.Second,
+		"tags":     []interface{}{"a", "b"},
+		"address":  map[string]interface{}{"city": "Hanoi"},
+		"previous": nil,
+		"login":    map[string]interface{}{"username": "jdoe"},
+		"err":      "boom",
+		"Untagged": "plain",
+	}
+
+	for _, tt := range []struct {
+		desc  string
+		field Field
+	}{
+		{"Tagged value", Tagged("user", user)},
+		{"Tagged pointer", Tagged("user", &user)},
+		{"Any value", Any("user", user)},
+		{"Any pointer", Any("user", &user)},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, ladcore.ObjectMarshalerType, tt.field.Type, "Unexpected field type.")
+
+			enc := ladcore.NewMapObjectEncoder()
+			tt.field.AddTo(enc)
+			assert.Equal(t, want, enc.Fields["user"], "Unexpected encoded output.")
+			assertCanBeReused(t, tt.field)
+		})
+	}
+}
+
+func TestTaggedFallsBackToReflect(t *testing.T) {
+	var nilUser *taggedUser
+	tests := []struct {
+		desc  string
+		field Field
+	}{
+		{"Tagged non-struct", Tagged("k", 42)},
+		{"Tagged nil pointer", Tagged("k", nilUser)},
+		{"Any untagged struct", Any("k", untaggedStruct{Name: "x"})},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, ladcore.ReflectType, tt.field.Type, "%s: unexpected field type.", tt.desc)
+	}
+}
+
+func TestTaggedObject(t *testing.T) {
+	assert.Nil(t, TaggedObject("not a struct"), "Expected nil for non-structs.")
+
+	m := TaggedObject(untaggedStruct{Name: "x"})
+	require.NotNil(t, m, "Expected a marshaler for untagged structs.")
+
+	enc := ladcore.NewMapObjectEncoder()
+	require.NoError(t, m.MarshalLogObject(enc), "Unexpected error marshaling struct.")
+	assert.Equal(t, map[string]interface{}{"Name": "x"}, enc.Fields, "Expected Go field names for untagged fields.")
+}
+
+type taggedSecret struct {
+	User     string `lad:"user"`
+	Password string `lad:"password,redact"`
+}
+
+type untaggedHolder struct {
+	Inner taggedSecret
+}
+
+type taggedVault struct {
+	Secrets map[string]taggedSecret `lad:"secrets"`
+}
+
+type taggedNode struct {
+	Name string      `lad:"name"`
+	Next *taggedNode `lad:"next"`
+}
+
+func TestTaggedRedactsNestedStructs(t *testing.T) {
+	secret := taggedSecret{User: "jane", Password: "hunter2"}
+	redacted := map[string]interface{}{"user": "jane", "password": RedactedValue}
+
+	tests := []struct {
+		desc   string
+		field  Field
+		expect interface{}
+	}{
+		{
+			desc:   "slice of tagged structs",
+			field:  Any("k", []taggedSecret{secret}),
+			expect: []interface{}{redacted},
+		},
+		{
+			desc:   "pointer to a slice of tagged structs",
+			field:  Any("k", &[]*taggedSecret{&secret}),
+			expect: []interface{}{redacted},
+		},
+		{
+			desc:   "untagged struct holding a tagged struct",
+			field:  Any("k", untaggedHolder{Inner: secret}),
+			expect: map[string]interface{}{"Inner": redacted},
+		},
+		{
+			desc:   "map of tagged structs in a tagged struct",
+			field:  Any("k", taggedVault{Secrets: map[string]taggedSecret{"db": secret}}),
+			expect: map[string]interface{}{"secrets": map[string]interface{}{"db": redacted}},
+		},
+		{
+			desc:   "map of tagged structs",
+			field:  Tagged("k", map[int]taggedSecret{1: secret}),
+			expect: map[string]interface{}{"1": redacted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.NotEqual(t, ladcore.ReflectType, tt.field.Type, "Expected to bypass the ReflectedEncoder.")
+
+			enc := ladcore.NewMapObjectEncoder()
+			tt.field.AddTo(enc)
+			assert.Equal(t, tt.expect, enc.Fields["k"], "Unexpected encoded output.")
+
+			buf, err := ladcore.NewJSONEncoder(ladcore.EncoderConfig{}).EncodeEntry(ladcore.Entry{}, []Field{tt.field})
+			require.NoError(t, err, "Unexpected error encoding entry.")
+			assert.NotContains(t, buf.String(), "hunter2", "Expected the password to be redacted.")
+			buf.Free()
+		})
+	}
+}
+
+func TestTaggedCycles(t *testing.T) {
+	shared := &taggedNode{Name: "shared"}
+	dag := []*taggedNode{{Name: "a", Next: shared}, {Name: "b", Next: shared}}
+	enc := ladcore.NewMapObjectEncoder()
+	Any("k", dag).AddTo(enc)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "a", "next": map[string]interface{}{"name": "shared", "next": nil}},
+		map[string]interface{}{"name": "b", "next": map[string]interface{}{"name": "shared", "next": nil}},
+	}, enc.Fields["k"], "Expected values shared between siblings not to be cycles.")
+
+	loop := &taggedNode{Name: "loop"}
+	loop.Next = loop
+	enc = ladcore.NewMapObjectEncoder()
+	Any("k", loop).AddTo(enc)
+	assert.Contains(t, enc.Fields["kError"], "encountered a cycle", "Expected cyclic values to fail.")
+}
+
+func BenchmarkTagged(b *testing.B) {
+	user := taggedUser{Name: "jane", Age: 30, Tags: []string{"a", "b"}}
+	enc := ladcore.NewMapObjectEncoder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Tagged("user", &user).AddTo(enc)
+	}
+}